@@ -5,7 +5,6 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
-	"log"
 	"math"
 	"strconv"
 	"strings"
@@ -27,6 +26,7 @@ type Asm struct {
 //
 // TODO: Conceal from outside use
 type Arg struct {
+	Pos      lexer.Position
 	Sym      *string `(@Sym Whitespace?)?`
 	Size     *uint32 `(@Size Whitespace?)?`
 	Flag     *uint8  `(@Size Whitespace?)?`
@@ -172,7 +172,7 @@ func parseOne(op vm.Opcode, instruction *Instruction, w io.Writer) (int, error)
 
 	// Catch
 	if a.Selector != nil {
-		log.Printf("have selector %v", instruction)
+		logg.Tracef("have selector", "instruction", instruction)
 		var n int
 		var err error
 		if op == vm.MOUT {
@@ -189,8 +189,11 @@ func parseOne(op vm.Opcode, instruction *Instruction, w io.Writer) (int, error)
 
 	// Catch CATCH, LOAD and twosyms with integer-as-string
 	if a.Size != nil {
-		log.Printf("have size %v (%v)", instruction, *a.Size)
+		logg.Tracef("have size", "instruction", instruction, "size", *a.Size)
 		if a.Sym == nil {
+			if a.Flag == nil {
+				return n_out, errorAt(a.Pos, "missing argument for %s", instruction.OpCode)
+			}
 			n, err := parseFlagged(b, a)
 			n_buf += n
 			if err != nil {
@@ -223,6 +226,9 @@ func parseOne(op vm.Opcode, instruction *Instruction, w io.Writer) (int, error)
 
 	// Catch HALT
 	if a.Sym == nil {
+		if op != vm.HALT && op != vm.MSINK {
+			return n_out, errorAt(instruction.Pos, "missing argument for %s", instruction.OpCode)
+		}
 		return flush(b, w)
 	}
 
@@ -257,6 +263,7 @@ func (a Arg) String() string {
 //
 // TODO: Conceal from outside use
 type Instruction struct {
+	Pos     lexer.Position
 	OpCode  string `@Ident`
 	OpArg   Arg    `(Whitespace @@)?`
 	Comment string `Comment? EOL`
@@ -334,20 +341,33 @@ func NewBatcher(mp MenuProcessor) Batcher {
 
 // MenuExit generates the instructions for the batch and writes them to the given io.Writer.
 func (bt *Batcher) MenuExit(w io.Writer) (int, error) {
+	b, _ := bt.menuExit()
+	return w.Write(b)
+}
+
+// generated instructions for the batch, with the source positions of the instructions.
+func (bt *Batcher) menuExit() ([]byte, []menuRef) {
 	if !bt.inMenu {
-		return 0, nil
+		return nil, nil
 	}
 	bt.inMenu = false
-	b := bt.menuProcessor.ToLines()
-	return w.Write(b)
+	b, refs := bt.menuProcessor.toLines()
+	bt.menuProcessor = NewMenuProcessor()
+	return b, refs
 }
 
 // MenuAdd adds a new menu instruction to the batcher.
 func (bt *Batcher) MenuAdd(w io.Writer, code string, arg Arg) (int, error) {
-	bt.inMenu = true
+	return 0, bt.menuAdd(code, arg, arg.Pos)
+}
+
+func (bt *Batcher) menuAdd(code string, arg Arg, pos lexer.Position) error {
 	var selector string
 	var sym string
 	var display string
+	if batchCode[code] == 0 {
+		return errorAt(pos, "unknown instruction: %v", code)
+	}
 	if arg.Desc != nil {
 		sym = *arg.Sym
 		display = *arg.Desc
@@ -357,14 +377,26 @@ func (bt *Batcher) MenuAdd(w io.Writer, code string, arg Arg) (int, error) {
 			sym = *arg.Sym
 		}
 		selector = strconv.FormatUint(uint64(*arg.Size), 10)
+		if arg.Selector == nil {
+			return errorAt(arg.Pos, "missing title for selector %s", selector)
+		}
 		display = *arg.Selector
-	} else {
+	} else if arg.Sym != nil {
 		selector = *arg.Sym
+		if arg.Selector == nil {
+			return errorAt(arg.Pos, "missing title for selector %s", selector)
+		}
 		display = *arg.Selector
+	} else {
+		return errorAt(pos, "missing selector for %s", code)
+	}
+	logg.Tracef("menu processor add", "code", code, "selector", selector, "display", display, "sym", sym)
+	err := bt.menuProcessor.add(code, selector, display, sym, pos)
+	if err != nil {
+		return errorAt(arg.Pos, "%v", err)
 	}
-	log.Printf("menu processor add %v '%v' '%v' '%v'", code, selector, display, sym)
-	err := bt.menuProcessor.Add(code, selector, display, sym)
-	return 0, err
+	bt.inMenu = true
+	return nil
 }
 
 // Exit is a synonym for MenuExit
@@ -372,46 +404,146 @@ func (bt *Batcher) Exit(w io.Writer) (int, error) {
 	return bt.MenuExit(w)
 }
 
+// Assembler compiles vise assembly code from a source file to bytecode.
+type Assembler struct {
+	file string
+	sm   *vm.SourceMap
+	ex   *Expander
+	st   *SymbolTable
+}
+
+// NewAssembler creates a new Assembler for the named source file.
+//
+// The name is used in error positions and in the source map.
+func NewAssembler(file string) *Assembler {
+	return &Assembler{
+		file: file,
+	}
+}
+
+// WithSourceMap is a chainable function that records the source position of every generated instruction in the given source map.
+func (as *Assembler) WithSourceMap(sm *vm.SourceMap) *Assembler {
+	as.sm = sm
+	return as
+}
+
+// WithExpander is a chainable function that sets the Expander used to resolve INCLUDE and MACRO directives.
+//
+// If not set, a new Expander reading included files from the filesystem is used for every Parse.
+func (as *Assembler) WithExpander(ex *Expander) *Assembler {
+	as.ex = ex
+	return as
+}
+
+// WithSymbols is a chainable function that sets the symbol table used to resolve named flags, sizes and selectors.
+//
+// It replaces the symbol table of the Expander.
+func (as *Assembler) WithSymbols(st *SymbolTable) *Assembler {
+	as.st = st
+	return as
+}
+
+// record source position at the current output offset.
+func (as *Assembler) mark(exp *Expansion, offset int, pos lexer.Position) {
+	if as.sm != nil {
+		line := exp.Line(pos.Line)
+		column := pos.Column
+		if line != pos.Line {
+			column = 1
+		}
+		as.sm.Add(offset, line, column)
+	}
+}
+
+// write batch instructions and record their source positions.
+func (as *Assembler) flushBatch(exp *Expansion, bt *Batcher, w io.Writer, offset int) (int, error) {
+	b, refs := bt.menuExit()
+	for _, v := range refs {
+		as.mark(exp, offset+v.offset, v.pos)
+	}
+	if len(b) == 0 {
+		return 0, nil
+	}
+	if w == nil {
+		return len(b), nil
+	}
+	return w.Write(b)
+}
+
 // Parse one or more lines of assembly code, and write assembled bytecode to the provided writer.
-func Parse(s string, w io.Writer) (int, error) {
-	rd := strings.NewReader(s)
-	ast, err := asmParser.Parse("file", rd)
+//
+// INCLUDE, MACRO and DEFINE directives are expanded before the code is assembled, see Expander.
+//
+// Errors are of type *Error, with the position in the original source of the failing instruction.
+func (as *Assembler) Parse(s string, w io.Writer) (int, error) {
+	ex := as.ex
+	if ex == nil {
+		ex = NewExpander()
+	}
+	if as.st != nil {
+		ex = ex.WithSymbols(as.st)
+	}
+	exp, err := ex.Expand(as.file, s)
 	if err != nil {
 		return 0, err
 	}
+	n, err := as.parse(exp, w)
+	if err != nil {
+		e, ok := err.(*Error)
+		if ok {
+			err = exp.Remap(e)
+		}
+	}
+	return n, err
+}
+
+func (as *Assembler) parse(exp *Expansion, w io.Writer) (int, error) {
+	rd := strings.NewReader(exp.Source)
+	ast, err := asmParser.Parse(as.file, rd)
+	if err != nil {
+		perr, ok := err.(participle.Error)
+		if ok {
+			return 0, errorAt(perr.Position(), "%s", perr.Message())
+		}
+		return 0, err
+	}
 
 	batch := Batcher{}
 
 	var rn int
 	for _, v := range ast.Instructions {
-		log.Printf("parsing line %v: %v", v.OpCode, v.OpArg)
+		logg.Tracef("parsing line", "opcode", v.OpCode, "arg", v.OpArg, "line", v.Pos.Line)
 		op, ok := vm.OpcodeIndex[v.OpCode]
 		if !ok {
-			n, err := batch.MenuAdd(w, v.OpCode, v.OpArg)
-			rn += n
+			err := batch.menuAdd(v.OpCode, v.OpArg, v.Pos)
 			if err != nil {
 				return rn, err
 			}
 		} else {
-			n, err := batch.MenuExit(w)
+			n, err := as.flushBatch(exp, &batch, w, rn)
+			rn += n
 			if err != nil {
 				return rn, err
 			}
-			rn += n
+			as.mark(exp, rn, v.Pos)
 			n, err = parseOne(op, v, w)
 			rn += n
 			if err != nil {
+				_, ok := err.(*Error)
+				if !ok {
+					err = errorAt(v.Pos, "%v", err)
+				}
 				return rn, err
 			}
-			log.Printf("wrote %v bytes for %v", n, v.OpArg)
+			logg.Tracef("wrote instruction", "bytes", n, "arg", v.OpArg)
 		}
 	}
-	n, err := batch.Exit(w)
+	n, err := as.flushBatch(exp, &batch, w, rn)
 	rn += n
-	if err != nil {
-		return rn, err
-	}
-	rn += n
-
 	return rn, err
 }
+
+// Parse one or more lines of assembly code, and write assembled bytecode to the provided writer.
+func Parse(s string, w io.Writer) (int, error) {
+	return NewAssembler("file").Parse(s, w)
+}
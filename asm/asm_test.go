@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/hex"
 	"log"
+	"strings"
 	"testing"
 
 	"github.com/grassrootseconomics/go-vise/vm"
@@ -444,3 +445,72 @@ func TestParserCapQuote(t *testing.T) {
 	}
 	_ = n
 }
+
+func TestParseInList(t *testing.T) {
+	r := bytes.NewBuffer(nil)
+	_, err := Parse("INLIST foo bar\n", r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := vm.NewLine(nil, vm.INLIST, []string{"foo", "bar"}, nil, nil)
+	if !bytes.Equal(r.Bytes(), expect) {
+		t.Fatalf("expected %x, got %x", expect, r.Bytes())
+	}
+}
+
+func TestParseDiagnostics(t *testing.T) {
+	for _, v := range [][2]string{
+		{"LOAD foo 0\nMOVE\n", "foo.vis:2:1: missing argument for MOVE"},
+		{"LOAD foo 0\nFOO bar\n", "foo.vis:2:1: unknown instruction: FOO"},
+		{"DOWN bar 1 bar_menu\nUP bar 2 back\n", "foo.vis:2:4: target is only valid for DOWN"},
+		{"DOWN bar 1 bar_menu\nUP 1 back\n", "foo.vis:2:4: duplicate selector 1 in menu"},
+		{"DOWN\n", "foo.vis:1:1: missing selector for DOWN"},
+		{"MOVE foo bar baz quux xyzzy\n", "foo.vis:1:"},
+	} {
+		_, err := NewAssembler("foo.vis").Parse(v[0], bytes.NewBuffer(nil))
+		if err == nil {
+			t.Fatalf("expected error for %s", v[0])
+		}
+		_, ok := err.(*Error)
+		if !ok {
+			t.Fatalf("expected *Error, got %T: %v", err, err)
+		}
+		if !strings.HasPrefix(err.Error(), v[1]) {
+			t.Fatalf("expected '%s', got '%s'", v[1], err)
+		}
+	}
+}
+
+func TestParseSourceMap(t *testing.T) {
+	s := `LOAD foo 0
+MAP foo
+
+DOWN bar 1 bar_menu
+UP 2 back
+MOVE baz
+`
+	sm := vm.NewSourceMap("foo.vis")
+	r := bytes.NewBuffer(nil)
+	_, err := NewAssembler("foo.vis").WithSourceMap(sm).Parse(s, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := r.Bytes()
+	ph := vm.NewParseHandler().WithDefaultHandlers().WithSourceMap(sm.WithSource(s))
+	rs, err := ph.ToString(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `LOAD foo 0	# foo.vis:1:1: LOAD foo 0
+MAP foo	# foo.vis:2:1: MAP foo
+MOUT bar_menu 1	# foo.vis:4:1: DOWN bar 1 bar_menu
+MOUT back 2	# foo.vis:5:1: UP 2 back
+HALT	# foo.vis:5:1: UP 2 back
+INCMP bar 1	# foo.vis:4:1: DOWN bar 1 bar_menu
+INCMP _ 2	# foo.vis:5:1: UP 2 back
+MOVE baz	# foo.vis:6:1: MOVE baz
+`
+	if rs != expect {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expect, rs)
+	}
+}
@@ -0,0 +1,140 @@
+package asm
+
+import (
+	"strings"
+
+	"github.com/grassrootseconomics/go-vise/vm"
+)
+
+// Disassembler generates assembly code from bytecode that assembles to the same bytecode.
+//
+// Menu instruction sequences generated by batch instructions are restored to batch syntax, and flag indices are restored to flag names if flag definitions are given.
+type Disassembler struct {
+	flags map[string]string
+}
+
+// NewDisassembler creates a new Disassembler.
+func NewDisassembler() *Disassembler {
+	return &Disassembler{}
+}
+
+// WithFlags is a chainable function that sets the flag definitions used to restore flag names.
+//
+// Code generated with flag names must be assembled with the same flag definitions.
+func (da *Disassembler) WithFlags(pp *FlagParser) *Disassembler {
+	da.flags = make(map[string]string)
+	for _, k := range pp.Names() {
+		v, err := pp.GetAsString(k)
+		if err == nil {
+			da.flags[v] = k
+		}
+	}
+	return da
+}
+
+// batch instruction for a menu instruction and the target of the input instruction with the same selector.
+func batchFor(menu []string, target string) []string {
+	switch menu[0] {
+	case "MNEXT":
+		if target == ">" {
+			return []string{"NEXT", menu[2], menu[1]}
+		}
+	case "MPREV":
+		if target == "<" {
+			return []string{"PREVIOUS", menu[2], menu[1]}
+		}
+	case "MOUT":
+		if target == "_" {
+			return []string{"UP", menu[2], menu[1]}
+		}
+		return []string{"DOWN", target, menu[2], menu[1]}
+	}
+	return nil
+}
+
+// batch instructions for the menu instructions preceding the HALT at index i, and the number of instructions replaced before and after the HALT.
+func restoreBatch(lines [][]string, i int) ([][]string, int, int) {
+	start := i
+	for start > 0 {
+		op := lines[start-1][0]
+		if op != "MOUT" && op != "MNEXT" && op != "MPREV" {
+			break
+		}
+		start--
+	}
+	// the longest suffix of the menu instructions that is matched in order after the HALT.
+	for j := start; j < i; j++ {
+		n := i - j
+		if i+n >= len(lines) {
+			continue
+		}
+		var r [][]string
+		seen := make(map[string]bool)
+		for k := 0; k < n; k++ {
+			menu := lines[j+k]
+			input := lines[i+1+k]
+			if input[0] != "INCMP" || input[2] != menu[2] || seen[menu[2]] {
+				r = nil
+				break
+			}
+			seen[menu[2]] = true
+			b := batchFor(menu, input[1])
+			if b == nil {
+				r = nil
+				break
+			}
+			r = append(r, b)
+		}
+		if r != nil {
+			return r, n, n
+		}
+	}
+	return nil, 0, 0
+}
+
+// Disassemble returns assembly code for the bytecode.
+func (da *Disassembler) Disassemble(b []byte) (string, error) {
+	s, err := vm.NewParseHandler().WithDefaultHandlers().ToString(b)
+	if err != nil {
+		return "", err
+	}
+	var lines [][]string
+	for _, v := range strings.Split(s, "\n") {
+		var f []string
+		for _, t := range Tokenize(v) {
+			f = append(f, t.Text)
+		}
+		if len(f) > 0 {
+			lines = append(lines, f)
+		}
+	}
+
+	var out [][]string
+	for i := 0; i < len(lines); i++ {
+		l := lines[i]
+		if l[0] == "HALT" {
+			r, pre, post := restoreBatch(lines, i)
+			if r != nil {
+				out = append(out[:len(out)-pre], r...)
+				i += post
+				continue
+			}
+		}
+		kinds, _ := ArgKinds(l[0])
+		for j, v := range l[1:] {
+			if j < len(kinds) && kinds[j] == ARG_FLAG {
+				k, ok := da.flags[v]
+				if ok {
+					l[j+1] = k
+				}
+			}
+		}
+		out = append(out, l)
+	}
+
+	var r string
+	for _, v := range out {
+		r += strings.Join(v, " ") + "\n"
+	}
+	return r, nil
+}
@@ -0,0 +1,75 @@
+package asm
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestDisassemble(t *testing.T) {
+	s := `LOAD foo 0
+CATCH bar 8 1
+MOUT manual 0
+DOWN inky 1 to_inky
+DOWN < 2 back
+UP 3 up
+NEXT 11 more
+PREVIOUS 22 less
+INCMP baz *
+MOUT xyzzy 4
+HALT
+INCMP plugh 5
+`
+	b := bytes.NewBuffer(nil)
+	_, err := Parse(s, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fp := path.Join(t.TempDir(), "flags.csv")
+	err = os.WriteFile(fp, []byte("flag,foo,8\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pp := NewFlagParser()
+	_, err = pp.Load(fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewDisassembler().WithFlags(pp).Disassemble(b.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `LOAD foo 0
+CATCH bar foo 1
+MOUT manual 0
+DOWN inky 1 to_inky
+DOWN < 2 back
+UP 3 up
+NEXT 11 more
+PREVIOUS 22 less
+INCMP baz *
+MOUT xyzzy 4
+HALT
+INCMP plugh 5
+`
+	if r != expect {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expect, r)
+	}
+
+	st := NewSymbolTable()
+	_, err = st.Load(fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	br := bytes.NewBuffer(nil)
+	_, err = NewAssembler("test.vis").WithSymbols(st).Parse(r, br)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b.Bytes(), br.Bytes()) {
+		t.Fatalf("bytecode differs:\n\t%x\n\t%x", b.Bytes(), br.Bytes())
+	}
+}
@@ -0,0 +1,41 @@
+package asm
+
+import (
+	"fmt"
+
+	"github.com/alecthomas/participle/v2/lexer"
+)
+
+// Error is an assembly error with the position in the source where it occurred.
+type Error struct {
+	File   string
+	Line   int
+	Column int
+	Err    error
+}
+
+// NewError creates a new Error for the given source position.
+func NewError(file string, line int, column int, err error) *Error {
+	return &Error{
+		File:   file,
+		Line:   line,
+		Column: column,
+		Err:    err,
+	}
+}
+
+func errorAt(pos lexer.Position, format string, args ...any) *Error {
+	return NewError(pos.Filename, pos.Line, pos.Column, fmt.Errorf(format, args...))
+}
+
+// Error implements the error interface.
+//
+// The format is "file:line:column: message".
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %v", e.File, e.Line, e.Column, e.Err)
+}
+
+// Unwrap returns the error without position.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
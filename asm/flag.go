@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sort"
 	"strconv"
 
 	"github.com/grassrootseconomics/go-vise/state"
@@ -74,6 +75,16 @@ func (pp *FlagParser) Last() uint32 {
 	return pp.hi
 }
 
+// Names returns the names of all registered flags, in alphabetical order.
+func (pp *FlagParser) Names() []string {
+	var r []string
+	for k := range pp.flag {
+		r = append(r, k)
+	}
+	sort.Strings(r)
+	return r
+}
+
 // Load parses a Comma Seperated Value file under the given filepath
 // to provide mappings between flag strings and flag indices.
 //
@@ -104,30 +115,43 @@ func (pp *FlagParser) Load(fp string) (int, error) {
 			if len(v) < 3 {
 				return 0, fmt.Errorf("Not enough fields for flag setting in line %d", i)
 			}
-			vv, err := strconv.Atoi(v[2])
-			if err != nil {
-				return 0, fmt.Errorf("Flag translation value must be numeric")
-			}
-			if vv < state.FLAG_USERSTART {
-				return 0, fmt.Errorf("Minimum flag value is FLAG_USERSTART (%d)", state.FLAG_USERSTART)
-			}
-			fl := uint32(vv)
-			pp.flag[v[1]] = v[2]
-			if fl > pp.hi {
-				pp.hi = fl
-			}
-
+			var desc string
 			if len(v) > 3 {
-				pp.flagDescription[uint32(fl)] = v[3]
-				logg.Debugf("added flag translation", "from", v[1], "to", v[2], "description", v[3])
-			} else {
-				logg.Debugf("added flag translation", "from", v[1], "to", v[2])
+				desc = v[3]
 			}
-			if pp.debug {
-				state.FlagDebugger.Register(fl, v[2])
+			err = pp.add(v[1], v[2], desc)
+			if err != nil {
+				return 0, err
 			}
 		}
 	}
 
 	return i, nil
 }
+
+// register a flag translation.
+func (pp *FlagParser) add(key string, value string, description string) error {
+	vv, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("Flag translation value must be numeric")
+	}
+	if vv < state.FLAG_USERSTART {
+		return fmt.Errorf("Minimum flag value is FLAG_USERSTART (%d)", state.FLAG_USERSTART)
+	}
+	fl := uint32(vv)
+	pp.flag[key] = value
+	if fl > pp.hi {
+		pp.hi = fl
+	}
+
+	if len(description) > 0 {
+		pp.flagDescription[uint32(fl)] = description
+		logg.Debugf("added flag translation", "from", key, "to", value, "description", description)
+	} else {
+		logg.Debugf("added flag translation", "from", key, "to", value)
+	}
+	if pp.debug {
+		state.FlagDebugger.Register(fl, key)
+	}
+	return nil
+}
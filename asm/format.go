@@ -0,0 +1,218 @@
+package asm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alecthomas/participle/v2"
+)
+
+// a line of assembly code split for formatting.
+type fmtLine struct {
+	tokens  []string
+	comment string
+	group   string
+	batch   bool
+	indent  bool
+}
+
+// normalize a comment to have a space after the comment character.
+func fmtComment(s string) string {
+	s = strings.TrimRight(s, " \t")
+	if len(s) > 1 && s[1] != ' ' && s[1] != '\t' && s[1] != '#' {
+		s = "# " + s[1:]
+	}
+	return s
+}
+
+// columns of a line, with an empty node column for batch instructions without target.
+func (l fmtLine) columns() []string {
+	if !l.batch || l.tokens[0] == "DOWN" {
+		return l.tokens
+	}
+	return append([]string{l.tokens[0], ""}, l.tokens[1:]...)
+}
+
+// write a block of lines with arguments and comments aligned.
+func fmtBlock(b *strings.Builder, block []fmtLine) {
+	var widths []int
+	for _, l := range block {
+		for i, v := range l.columns() {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			if len(v) > widths[i] {
+				widths[i] = len(v)
+			}
+		}
+	}
+	var code []string
+	var commentAt int
+	for _, l := range block {
+		var s string
+		cols := l.columns()
+		for i, v := range cols {
+			if i > 0 {
+				s += " "
+			}
+			if i < len(cols)-1 {
+				v += strings.Repeat(" ", widths[i]-len(v))
+			}
+			s += v
+		}
+		s = strings.TrimRight(s, " ")
+		code = append(code, s)
+		if l.comment != "" && len(s) > commentAt {
+			commentAt = len(s)
+		}
+	}
+	for i, l := range block {
+		s := code[i]
+		if l.comment != "" {
+			s += strings.Repeat(" ", commentAt-len(s)) + " " + l.comment
+		}
+		if l.indent {
+			s = "\t" + s
+		}
+		b.WriteString(s + "\n")
+	}
+}
+
+// check that an instruction line is valid assembly code.
+//
+// Named flags and sizes are replaced with a placeholder value, as they may be defined outside of the file.
+func fmtCheck(file string, n int, tk []Token) error {
+	var s string
+	var cols []int
+	kinds, _ := ArgKinds(tk[0].Text)
+	for i, t := range tk {
+		v := t.Text
+		if i > 0 && i-1 < len(kinds) && (kinds[i-1] == ARG_FLAG || kinds[i-1] == ARG_SIZE) && !isNumeric(v) {
+			v = "0"
+		}
+		if i > 0 {
+			s += " "
+		}
+		cols = append(cols, len(s)+1)
+		s += v
+	}
+	_, err := asmParser.ParseString(file, s+"\n")
+	if err == nil {
+		return nil
+	}
+	perr, ok := err.(participle.Error)
+	if !ok {
+		return NewError(file, n, 1, err)
+	}
+	col := tk[0].Column
+	for i, v := range cols {
+		if perr.Position().Column >= v {
+			col = tk[i].Column
+		}
+	}
+	return NewError(file, n, col, fmt.Errorf("%s", perr.Message()))
+}
+
+// Format rewrites assembly code of the named file to canonical form.
+//
+// In canonical form:
+//
+//   - There is no indentation, except for macro bodies, which are indented with a single tab.
+//   - Arguments of consecutive instructions are aligned in columns, separated by spaces.
+//   - Comments after instructions are aligned in the same way, and all comments have a space after the comment character.
+//   - Menu batch instructions are aligned as a separate block, preceded by an empty line, with the selector and label columns of DOWN, UP, NEXT and PREVIOUS aligned.
+//   - There is at most one consecutive empty line, and no empty lines at the beginning and end of the file.
+//
+// Instructions are checked with the assembly grammar. Directives and macro invocations are formatted like instructions, but are not checked.
+//
+// Errors are of type *Error, with the position in the original source.
+func Format(file string, src string) (string, error) {
+	var b strings.Builder
+	var block []fmtLine
+	var prev string
+	var inMacro bool
+	blank := false
+
+	flush := func() {
+		if len(block) > 0 {
+			fmtBlock(&b, block)
+			prev = "code"
+			block = nil
+		}
+	}
+
+	for i, s := range strings.Split(src, "\n") {
+		s = strings.TrimRight(s, " \t\r")
+		tk := Tokenize(s)
+		if len(tk) == 0 {
+			j := strings.Index(s, "#")
+			if j == -1 {
+				flush()
+				blank = prev != ""
+				continue
+			}
+			flush()
+			if blank {
+				b.WriteString("\n")
+				blank = false
+			}
+			c := fmtComment(strings.TrimLeft(s, " \t"))
+			if inMacro {
+				c = "\t" + c
+			}
+			b.WriteString(c + "\n")
+			prev = "comment"
+			continue
+		}
+
+		l := fmtLine{}
+		for _, v := range tk {
+			l.tokens = append(l.tokens, v.Text)
+		}
+		j := strings.Index(s, "#")
+		if j > -1 {
+			l.comment = fmtComment(s[j:])
+		}
+		op := l.tokens[0]
+		_, l.batch = batchCode[op]
+		switch op {
+		case "MACRO", "ENDMACRO":
+			l.group = fmt.Sprintf("macro%d", i)
+		case "INCLUDE", "DEFINE":
+			l.group = op
+		default:
+			l.group = "code"
+			if l.batch {
+				l.group = "batch"
+			}
+		}
+		l.indent = inMacro && op != "ENDMACRO"
+		if op == "MACRO" {
+			inMacro = true
+		} else if op == "ENDMACRO" {
+			inMacro = false
+		}
+
+		_, isOp := ArgKinds(op)
+		if isOp && !inMacro {
+			err := fmtCheck(file, i+1, tk)
+			if err != nil {
+				return "", err
+			}
+		}
+
+		if len(block) > 0 && (block[0].group != l.group || block[0].indent != l.indent) {
+			flush()
+		}
+		if len(block) == 0 && l.batch && prev == "code" {
+			blank = true
+		}
+		if blank {
+			b.WriteString("\n")
+			blank = false
+		}
+		block = append(block, l)
+	}
+	flush()
+	return b.String(), nil
+}
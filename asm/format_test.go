@@ -0,0 +1,86 @@
+package asm
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	s := `
+
+#preamble
+DEFINE   size  sz 8
+MACRO LOADMAP sym
+LOAD $sym 0
+    MAP $sym   #map it
+ENDMACRO
+	LOAD foo sz # load
+RELOAD foo
+MOUT  inky 1
+HALT
+
+
+INCMP bar 1
+DOWN foo 2 to_foo#down
+ UP 0 back
+NEXT 11 more
+
+`
+	expect := `# preamble
+DEFINE size sz 8
+MACRO LOADMAP sym
+	LOAD $sym 0
+	MAP  $sym # map it
+ENDMACRO
+LOAD   foo  sz # load
+RELOAD foo
+MOUT   inky 1
+HALT
+
+INCMP bar 1
+
+DOWN foo 2  to_foo # down
+UP       0  back
+NEXT     11 more
+`
+	r, err := Format("test.vis", s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r != expect {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expect, r)
+	}
+	rr, err := Format("test.vis", r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rr != r {
+		t.Fatalf("format not idempotent:\n%s", rr)
+	}
+
+	// formatting must not change the bytecode.
+	b := bytes.NewBuffer(nil)
+	_, err = Parse(s, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	br := bytes.NewBuffer(nil)
+	_, err = Parse(r, br)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b.Bytes(), br.Bytes()) {
+		t.Fatalf("bytecode differs:\n\t%x\n\t%x", b.Bytes(), br.Bytes())
+	}
+}
+
+func TestFormatError(t *testing.T) {
+	_, err := Format("test.vis", "HALT\n\nMOUT foo %\n")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if !strings.HasPrefix(err.Error(), "test.vis:3:") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,292 @@
+package asm
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/grassrootseconomics/go-vise/vm"
+)
+
+const (
+	// maximum nesting of includes and macro expansions.
+	expandDepth = 16
+)
+
+var (
+	paramRegex = regexp.MustCompile(`\$([a-zA-Z_][a-zA-Z0-9_]*)`)
+	macroRegex = regexp.MustCompile(`^[A-Z]+$`)
+)
+
+// ReadFunc reads the contents of an included file, at the given path resolved relative to the including file.
+type ReadFunc func(fp string) ([]byte, error)
+
+// position of a line in the original source, and of the include or macro invocation that generated it.
+type origin struct {
+	file  string
+	line  int
+	macro string
+	from  *origin
+}
+
+type macro struct {
+	name   string
+	params []string
+	body   []string
+	def    *origin
+}
+
+// Expander resolves INCLUDE, MACRO and DEFINE directives in assembly code.
+//
+// An include directive inserts the assembly code of the named file, resolved relative to the including file:
+//
+//	INCLUDE common.vis
+//
+// A macro is defined with a name, which must be upper case and must not collide with an instruction, and optional parameters. In the body, parameters are referenced with a "$" prefix:
+//
+//	MACRO LOADMAP sym
+//	LOAD $sym 0
+//	MAP $sym
+//	ENDMACRO
+//
+// It is then used like an instruction, with one argument per parameter:
+//
+//	LOADMAP foo
+//
+// Macros defined in included files are available to the including file after the include directive. Macro bodies may use includes and other macros.
+//
+// A define directive adds a named flag, size or selector to the symbol table of the Expander:
+//
+//	DEFINE flag FLAG_FOO 8
+//	DEFINE size MAX_NAME 32
+//	DEFINE selector BACK 0
+//
+// Named values are substituted in the arguments of instructions, as described by SymbolTable.Resolve. Definitions are in effect from the line after the directive.
+type Expander struct {
+	read    ReadFunc
+	macros  map[string]*macro
+	symbols *SymbolTable
+}
+
+// NewExpander creates a new Expander, which reads included files from the filesystem.
+func NewExpander() *Expander {
+	return &Expander{
+		read:    os.ReadFile,
+		macros:  make(map[string]*macro),
+		symbols: NewSymbolTable(),
+	}
+}
+
+// WithReader is a chainable function that sets how included files are read.
+func (ex *Expander) WithReader(fn ReadFunc) *Expander {
+	ex.read = fn
+	return ex
+}
+
+// WithSymbols is a chainable function that sets the symbol table used to resolve named values.
+//
+// DEFINE directives add to the given symbol table.
+func (ex *Expander) WithSymbols(st *SymbolTable) *Expander {
+	ex.symbols = st
+	return ex
+}
+
+// Expansion is assembly code with all directives resolved.
+//
+// Empty and comment-only lines are removed.
+type Expansion struct {
+	// Source is the expanded assembly code.
+	Source  string
+	origins []*origin
+}
+
+// Expand resolves all directives in the assembly code of the named file.
+//
+// Errors are of type *Error, with the position in the original source.
+func (ex *Expander) Expand(file string, src string) (*Expansion, error) {
+	exp := &Expansion{}
+	var lines []string
+	lines, err := ex.expand(exp, lines, file, src, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) > 0 {
+		exp.Source = strings.Join(lines, "\n") + "\n"
+	}
+	return exp, nil
+}
+
+// split a line in directive fields, ignoring comments.
+func fields(s string) []string {
+	i := strings.Index(s, "#")
+	if i > -1 {
+		s = s[:i]
+	}
+	return strings.Fields(s)
+}
+
+func (ex *Expander) expand(exp *Expansion, lines []string, file string, src string, from *origin, depth int) ([]string, error) {
+	if depth > expandDepth {
+		return nil, NewError(from.file, from.line, 1, fmt.Errorf("includes or macros nested deeper than %d", expandDepth))
+	}
+	var def *macro
+	for i, s := range strings.Split(src, "\n") {
+		o := &origin{file: file, line: i + 1, from: from}
+		if from != nil && from.macro != "" {
+			o = &origin{file: from.file, line: from.line, macro: from.macro, from: from.from}
+		}
+		f := fields(s)
+		if def != nil {
+			if len(f) > 0 && f[0] == "ENDMACRO" {
+				ex.macros[def.name] = def
+				def = nil
+			} else {
+				def.body = append(def.body, s)
+			}
+			continue
+		}
+		if len(f) == 0 {
+			continue
+		}
+		var err error
+		switch f[0] {
+		case "INCLUDE":
+			if len(f) != 2 {
+				return nil, NewError(o.file, o.line, 1, fmt.Errorf("INCLUDE takes one file name"))
+			}
+			fp := path.Join(path.Dir(file), f[1])
+			b, err := ex.read(fp)
+			if err != nil {
+				return nil, NewError(o.file, o.line, 1, fmt.Errorf("include %s: %v", f[1], err))
+			}
+			lines, err = ex.expand(exp, lines, fp, string(b), o, depth+1)
+			if err != nil {
+				return nil, err
+			}
+		case "MACRO":
+			def, err = ex.define(f[1:], o)
+			if err != nil {
+				return nil, err
+			}
+		case "ENDMACRO":
+			return nil, NewError(o.file, o.line, 1, fmt.Errorf("ENDMACRO without MACRO"))
+		case "DEFINE":
+			if len(f) != 4 {
+				return nil, NewError(o.file, o.line, 1, fmt.Errorf("DEFINE takes a kind, a name and a value"))
+			}
+			err = ex.symbols.Define(f[1], f[2], f[3])
+			if err != nil {
+				return nil, NewError(o.file, o.line, 1, err)
+			}
+		default:
+			m, ok := ex.macros[f[0]]
+			if !ok {
+				var col int
+				s, col, err = ex.symbols.Resolve(s)
+				if err != nil {
+					if o.macro != "" {
+						return nil, NewError(o.file, o.line, 1, fmt.Errorf("%v (in expansion of %s)", err, o.macro))
+					}
+					return nil, NewError(o.file, o.line, col, err)
+				}
+				exp.origins = append(exp.origins, o)
+				lines = append(lines, s)
+				continue
+			}
+			lines, err = ex.invoke(exp, lines, m, f[1:], o, depth)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if def != nil {
+		return nil, NewError(def.def.file, def.def.line, 1, fmt.Errorf("MACRO %s without ENDMACRO", def.name))
+	}
+	return lines, nil
+}
+
+// start a macro definition.
+func (ex *Expander) define(f []string, o *origin) (*macro, error) {
+	if len(f) == 0 {
+		return nil, NewError(o.file, o.line, 1, fmt.Errorf("MACRO needs a name"))
+	}
+	name := f[0]
+	if !macroRegex.MatchString(name) {
+		return nil, NewError(o.file, o.line, 7, fmt.Errorf("macro name must be upper case: %s", name))
+	}
+	_, isOp := vm.OpcodeIndex[name]
+	_, isBatch := batchCode[name]
+	if isOp || isBatch || name == "INCLUDE" || name == "MACRO" || name == "ENDMACRO" || name == "DEFINE" {
+		return nil, NewError(o.file, o.line, 7, fmt.Errorf("macro name is reserved: %s", name))
+	}
+	return &macro{
+		name:   name,
+		params: f[1:],
+		def:    o,
+	}, nil
+}
+
+// expand a macro invocation with the given arguments.
+func (ex *Expander) invoke(exp *Expansion, lines []string, m *macro, args []string, o *origin, depth int) ([]string, error) {
+	if len(args) != len(m.params) {
+		return nil, NewError(o.file, o.line, 1, fmt.Errorf("macro %s expects %d arguments, got %d", m.name, len(m.params), len(args)))
+	}
+	values := make(map[string]string)
+	for i, v := range m.params {
+		values[v] = args[i]
+	}
+	var body []string
+	for i, s := range m.body {
+		var err error
+		s = paramRegex.ReplaceAllStringFunc(s, func(p string) string {
+			v, ok := values[p[1:]]
+			if !ok {
+				err = NewError(m.def.file, m.def.line+i+1, strings.Index(s, p)+1, fmt.Errorf("unknown macro parameter %s", p))
+			}
+			return v
+		})
+		if err != nil {
+			return nil, err
+		}
+		body = append(body, s)
+	}
+	from := &origin{
+		file:  o.file,
+		line:  o.line,
+		macro: m.name,
+		from:  o.from,
+	}
+	return ex.expand(exp, lines, m.def.file, strings.Join(body, "\n"), from, depth+1)
+}
+
+// Line returns the line in the top-level source file that generated the given line of the expanded source.
+//
+// Lines included from other files map to the include directive, and macro bodies map to the macro invocation.
+func (exp *Expansion) Line(n int) int {
+	if n < 1 || n > len(exp.origins) {
+		return n
+	}
+	o := exp.origins[n-1]
+	for o.from != nil {
+		o = o.from
+	}
+	return o.line
+}
+
+// Remap translates the position of an error in the expanded source to the original source.
+//
+// Errors in lines generated by macros refer to the macro invocation.
+func (exp *Expansion) Remap(e *Error) *Error {
+	if e.Line < 1 || e.Line > len(exp.origins) {
+		return e
+	}
+	o := exp.origins[e.Line-1]
+	r := NewError(o.file, o.line, e.Column, e.Err)
+	if o.macro != "" {
+		r.Column = 1
+		r.Err = fmt.Errorf("%v (in expansion of %s)", e.Err, o.macro)
+	}
+	return r
+}
@@ -0,0 +1,101 @@
+package asm
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/grassrootseconomics/go-vise/vm"
+)
+
+func newTestExpander(files map[string]string) *Expander {
+	return NewExpander().WithReader(func(fp string) ([]byte, error) {
+		s, ok := files[fp]
+		if !ok {
+			return nil, fmt.Errorf("not found: %s", fp)
+		}
+		return []byte(s), nil
+	})
+}
+
+func TestExpand(t *testing.T) {
+	ex := newTestExpander(map[string]string{
+		"lib/common.vis": `MACRO LOADMAP sym
+LOAD $sym 0
+MAP $sym
+ENDMACRO
+MACRO BACK
+INCMP _ 0
+ENDMACRO
+`,
+	})
+	s := `# preamble
+INCLUDE common.vis
+LOADMAP foo
+MOUT back 0
+HALT
+BACK
+`
+	exp, err := ex.Expand("lib/main.vis", s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := "LOAD foo 0\nMAP foo\nMOUT back 0\nHALT\nINCMP _ 0\n"
+	if exp.Source != expect {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expect, exp.Source)
+	}
+	for i, v := range []int{3, 3, 4, 5, 6} {
+		if exp.Line(i+1) != v {
+			t.Fatalf("expected line %d to map to %d, got %d", i+1, v, exp.Line(i+1))
+		}
+	}
+
+	sm := vm.NewSourceMap("main.vis")
+	b := bytes.NewBuffer(nil)
+	_, err = NewAssembler("lib/main.vis").WithExpander(ex).WithSourceMap(sm).Parse(s, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCode := vm.NewLine(nil, vm.LOAD, []string{"foo"}, []byte{0x00}, nil)
+	expectCode = vm.NewLine(expectCode, vm.MAP, []string{"foo"}, nil, nil)
+	expectCode = vm.NewLine(expectCode, vm.MOUT, []string{"back", "0"}, nil, nil)
+	expectCode = vm.NewLine(expectCode, vm.HALT, nil, nil, nil)
+	expectCode = vm.NewLine(expectCode, vm.INCMP, []string{"_", "0"}, nil, nil)
+	if !bytes.Equal(b.Bytes(), expectCode) {
+		t.Fatalf("expected %x, got %x", expectCode, b.Bytes())
+	}
+	loc, ok := sm.Locate(len(expectCode) - 1)
+	if !ok || loc.Line != 6 {
+		t.Fatalf("unexpected location: %v", loc)
+	}
+}
+
+func TestExpandErrors(t *testing.T) {
+	ex := newTestExpander(map[string]string{
+		"bad.vis":  "MACRO FOO x\nLOAD $x\nMOVE\nENDMACRO\n",
+		"self.vis": "INCLUDE self.vis\n",
+	})
+	for _, v := range [][2]string{
+		{"MACRO FOO x\nLOAD $x 0\nENDMACRO\nFOO\n", "main.vis:4:1: macro FOO expects 1 arguments, got 0"},
+		{"MACRO FOO x\nLOAD $y 0\nENDMACRO\nFOO bar\n", "main.vis:2:6: unknown macro parameter $y"},
+		{"MACRO MOVE x\nENDMACRO\n", "main.vis:1:7: macro name is reserved: MOVE"},
+		{"MACRO FOO x\nLOAD $x 0\n", "main.vis:1:1: MACRO FOO without ENDMACRO"},
+		{"ENDMACRO\n", "main.vis:1:1: ENDMACRO without MACRO"},
+		{"INCLUDE nonexistent.vis\n", "main.vis:1:1: include nonexistent.vis"},
+		{"INCLUDE self.vis\n", "self.vis:1:1: includes or macros nested deeper"},
+		{"INCLUDE bad.vis\nHALT\nFOO bar\n", "main.vis:3:1: missing argument for MOVE (in expansion of FOO)"},
+	} {
+		_, err := NewAssembler("main.vis").WithExpander(ex).Parse(v[0], bytes.NewBuffer(nil))
+		if err == nil {
+			t.Fatalf("expected error for %s", v[0])
+		}
+		_, ok := err.(*Error)
+		if !ok {
+			t.Fatalf("expected *Error, got %T: %v", err, err)
+		}
+		if !strings.HasPrefix(err.Error(), v[1]) {
+			t.Fatalf("expected '%s', got '%s'", v[1], err)
+		}
+	}
+}
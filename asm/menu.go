@@ -3,6 +3,8 @@ package asm
 import (
 	"fmt"
 
+	"github.com/alecthomas/participle/v2/lexer"
+
 	"github.com/grassrootseconomics/go-vise/vm"
 )
 
@@ -31,6 +33,13 @@ type menuItem struct {
 	choice  string
 	display string
 	target  string
+	pos     lexer.Position
+}
+
+// source position of generated instruction at offset in batch output.
+type menuRef struct {
+	offset int
+	pos    lexer.Position
 }
 
 // MenuProcessor handles code lines with BatchCode quasi-opcodes that control menu generation.
@@ -50,6 +59,10 @@ func NewMenuProcessor() MenuProcessor {
 //
 // Instructions will be rendered in the order in which they have been added.
 func (mp *MenuProcessor) Add(bop string, choice string, display string, target string) error {
+	return mp.add(bop, choice, display, target, lexer.Position{})
+}
+
+func (mp *MenuProcessor) add(bop string, choice string, display string, target string, pos lexer.Position) error {
 	bopCode := batchCode[bop]
 	if bopCode == 0 {
 		return fmt.Errorf("unknown menu instruction: %v", bop)
@@ -57,11 +70,17 @@ func (mp *MenuProcessor) Add(bop string, choice string, display string, target s
 	if len(target) > 0 && bopCode != _MENU_DOWN {
 		return fmt.Errorf("target is only valid for DOWN")
 	}
+	for _, v := range mp.items {
+		if v.choice == choice {
+			return fmt.Errorf("duplicate selector %s in menu", choice)
+		}
+	}
 	m := menuItem{
 		code:    bopCode,
 		choice:  choice,
 		display: display,
 		target:  target,
+		pos:     pos,
 	}
 	mp.items = append(mp.items, m)
 	return nil
@@ -69,10 +88,20 @@ func (mp *MenuProcessor) Add(bop string, choice string, display string, target s
 
 // ToLines returns the generated bytecode from the added menu batch instructions.
 func (mp *MenuProcessor) ToLines() []byte {
+	b, _ := mp.toLines()
+	return b
+}
+
+// generated bytecode, with the source position of every instruction.
+func (mp *MenuProcessor) toLines() ([]byte, []menuRef) {
+	var preRefs []menuRef
+	var postRefs []menuRef
 	preLines := []byte{}
 	postLines := []byte{}
 
 	for _, v := range mp.items {
+		preRefs = append(preRefs, menuRef{len(preLines), v.pos})
+		postRefs = append(postRefs, menuRef{len(postLines), v.pos})
 		switch v.code {
 		case _MENU_UP:
 			preLines = vm.NewLine(preLines, vm.MOUT, []string{v.display, v.choice}, nil, nil)
@@ -89,6 +118,12 @@ func (mp *MenuProcessor) ToLines() []byte {
 		}
 	}
 
+	if len(mp.items) > 0 {
+		preRefs = append(preRefs, menuRef{len(preLines), mp.items[len(mp.items)-1].pos})
+	}
 	preLines = vm.NewLine(preLines, vm.HALT, nil, nil, nil)
-	return append(preLines, postLines...)
+	for _, v := range postRefs {
+		preRefs = append(preRefs, menuRef{len(preLines) + v.offset, v.pos})
+	}
+	return append(preLines, postLines...), preRefs
 }
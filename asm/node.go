@@ -0,0 +1,233 @@
+package asm
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/grassrootseconomics/go-vise/db"
+	"github.com/grassrootseconomics/go-vise/lang"
+)
+
+const (
+	// File extension of node definition files.
+	NODE_EXT = ".node"
+
+	sectionCode     = "code"
+	sectionTemplate = "template"
+	sectionMenu     = "menu"
+)
+
+var (
+	sectionRegex = regexp.MustCompile(`^\[(code|template|menu)((?:[ \t]+[^ \t\]]+)*)[ \t]*\]$`)
+)
+
+// Node is a node definition split into bytecode, templates and menu labels.
+type Node struct {
+	// Sym is the node symbol, which is the base name of the node definition file.
+	Sym string
+	// Code is the assembled bytecode.
+	Code []byte
+	// Templates are the templates of the node, by language code. The default template has an empty language code.
+	Templates map[string]string
+	// Menus are the menu labels of the node, by label and language code. The default label has an empty language code.
+	Menus map[string]map[string]string
+}
+
+// NodeEntry is a resource generated from a node definition, corresponding to a single file of a resource directory.
+type NodeEntry struct {
+	// Type is the db datatype of the resource; DATATYPE_BIN, DATATYPE_TEMPLATE or DATATYPE_MENU.
+	Type uint8
+	// Key is the key of the resource, without language suffix.
+	Key string
+	// Language is the language code of the resource, or empty for the default language.
+	Language string
+	// Value is the contents of the resource.
+	Value []byte
+}
+
+// FileName returns the name of the file for the resource in a resource directory, as read by db/fs.
+func (ne NodeEntry) FileName() string {
+	s := ne.Key
+	if ne.Language != "" {
+		s += "_" + ne.Language
+	}
+	if ne.Type == db.DATATYPE_BIN {
+		s += ".bin"
+	}
+	return s
+}
+
+// Entries returns the resources of the node, in the order bytecode, templates and menus, with the default language first.
+//
+// Menu labels are stored under the key of the label with the "_menu" suffix, which is where resource.DbResource looks them up.
+func (n *Node) Entries() []NodeEntry {
+	r := []NodeEntry{
+		{
+			Type:  db.DATATYPE_BIN,
+			Key:   n.Sym,
+			Value: n.Code,
+		},
+	}
+	for _, ln := range slices.Sorted(maps.Keys(n.Templates)) {
+		r = append(r, NodeEntry{
+			Type:     db.DATATYPE_TEMPLATE,
+			Key:      n.Sym,
+			Language: ln,
+			Value:    []byte(n.Templates[ln]),
+		})
+	}
+	for _, label := range slices.Sorted(maps.Keys(n.Menus)) {
+		for _, ln := range slices.Sorted(maps.Keys(n.Menus[label])) {
+			r = append(r, NodeEntry{
+				Type:     db.DATATYPE_MENU,
+				Key:      label + "_menu",
+				Language: ln,
+				Value:    []byte(n.Menus[label][ln]),
+			})
+		}
+	}
+	return r
+}
+
+// section contents without trailing empty lines, terminated with a newline.
+func sectionText(lines []string) string {
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// ParseNode parses a node definition, and assembles its code.
+//
+// A node definition combines the assembly code, templates and menu labels of a node in sections, each starting with a header line:
+//
+//	[code]
+//	MOUT foo 1
+//	HALT
+//	INCMP bar 1
+//	[template]
+//	Hello
+//	[template nor]
+//	Hallo
+//	[menu foo]
+//	Go to foo
+//	[menu foo nor]
+//	Gå til foo
+//
+// The code section is required, and the others are optional. Only empty lines and comments may precede the first section. Section headers are only recognized for the section types above; other lines are part of the section contents. Trailing empty lines of templates and menu labels are removed.
+//
+// The node symbol is the base name of the file given to NewAssembler, without extension.
+//
+// Errors are of type *Error, with the position in the node definition.
+func (as *Assembler) ParseNode(src string) (*Node, error) {
+	n := &Node{
+		Sym:       strings.TrimSuffix(path.Base(as.file), path.Ext(as.file)),
+		Templates: make(map[string]string),
+		Menus:     make(map[string]map[string]string),
+	}
+	lines := strings.Split(src, "\n")
+	// code with lines of other sections blanked, keeping the line numbers of the node definition.
+	code := make([]string, len(lines))
+	var content []string
+	var haveCode bool
+	var done func()
+	seen := make(map[string]bool)
+	for i, s := range lines {
+		m := sectionRegex.FindStringSubmatch(strings.TrimRight(s, " \t\r"))
+		if m == nil {
+			if done == nil {
+				t := strings.TrimSpace(s)
+				if t != "" && !strings.HasPrefix(t, "#") {
+					return nil, NewError(as.file, i+1, 1, fmt.Errorf("content outside section"))
+				}
+				continue
+			}
+			content = append(content, s)
+			continue
+		}
+		if done != nil {
+			done()
+		}
+		content = nil
+		args := strings.Fields(m[2])
+		id := strings.Join(append([]string{m[1]}, args...), " ")
+		if seen[id] {
+			return nil, NewError(as.file, i+1, 1, fmt.Errorf("duplicate section: %s", id))
+		}
+		seen[id] = true
+
+		var ln string
+		switch m[1] {
+		case sectionCode:
+			if len(args) > 0 {
+				return nil, NewError(as.file, i+1, 1, fmt.Errorf("code section takes no arguments"))
+			}
+			haveCode = true
+			start := i + 1
+			done = func() {
+				for j, v := range content {
+					code[start+j] = v
+				}
+			}
+			continue
+		case sectionTemplate:
+			if len(args) > 1 {
+				return nil, NewError(as.file, i+1, 1, fmt.Errorf("template section takes at most one argument"))
+			}
+			if len(args) == 1 {
+				ln = args[0]
+			}
+		case sectionMenu:
+			if len(args) < 1 || len(args) > 2 {
+				return nil, NewError(as.file, i+1, 1, fmt.Errorf("menu section takes a label and an optional language"))
+			}
+			if len(args) == 2 {
+				ln = args[1]
+			}
+		}
+		if ln != "" {
+			_, err := lang.LanguageFromCode(ln)
+			if err != nil {
+				return nil, NewError(as.file, i+1, 1, err)
+			}
+		}
+		typ := m[1]
+		label := ""
+		if typ == sectionMenu {
+			label = args[0]
+		}
+		done = func() {
+			s := sectionText(content)
+			if typ == sectionTemplate {
+				n.Templates[ln] = s
+				return
+			}
+			if n.Menus[label] == nil {
+				n.Menus[label] = make(map[string]string)
+			}
+			n.Menus[label][ln] = s
+		}
+	}
+	if done != nil {
+		done()
+	}
+	if !haveCode {
+		return nil, NewError(as.file, 1, 1, fmt.Errorf("missing code section"))
+	}
+
+	b := bytes.NewBuffer(nil)
+	_, err := as.Parse(strings.Join(code, "\n"), b)
+	if err != nil {
+		return nil, err
+	}
+	n.Code = b.Bytes()
+	return n, nil
+}
@@ -0,0 +1,94 @@
+package asm
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/grassrootseconomics/go-vise/db"
+)
+
+func TestParseNode(t *testing.T) {
+	src := `# the root node
+[code]
+MOUT foo 1
+HALT
+INCMP foo 1
+
+[template]
+Hello
+
+[template nor]
+Hallo
+[menu foo]
+Go to foo
+[menu foo nor]
+Gå til foo
+`
+	n, err := NewAssembler("dir/root.node").ParseNode(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.Sym != "root" {
+		t.Fatalf("expected symbol root, got %s", n.Sym)
+	}
+
+	b := bytes.NewBuffer(nil)
+	_, err = Parse("MOUT foo 1\nHALT\nINCMP foo 1\n", b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(n.Code, b.Bytes()) {
+		t.Fatalf("expected bytecode %x, got %x", b.Bytes(), n.Code)
+	}
+
+	expect := []NodeEntry{
+		{db.DATATYPE_BIN, "root", "", b.Bytes()},
+		{db.DATATYPE_TEMPLATE, "root", "", []byte("Hello\n")},
+		{db.DATATYPE_TEMPLATE, "root", "nor", []byte("Hallo\n")},
+		{db.DATATYPE_MENU, "foo_menu", "", []byte("Go to foo\n")},
+		{db.DATATYPE_MENU, "foo_menu", "nor", []byte("Gå til foo\n")},
+	}
+	r := n.Entries()
+	if len(r) != len(expect) {
+		t.Fatalf("expected %d entries, got %d", len(expect), len(r))
+	}
+	for i, v := range expect {
+		if r[i].Type != v.Type || r[i].Key != v.Key || r[i].Language != v.Language || !bytes.Equal(r[i].Value, v.Value) {
+			t.Fatalf("entry %d: expected %v, got %v", i, v, r[i])
+		}
+	}
+	for i, v := range []string{"root.bin", "root", "root_nor", "foo_menu", "foo_menu_nor"} {
+		if r[i].FileName() != v {
+			t.Fatalf("entry %d: expected file name %s, got %s", i, v, r[i].FileName())
+		}
+	}
+}
+
+func TestParseNodeError(t *testing.T) {
+	for _, v := range []struct {
+		src  string
+		line int
+	}{
+		{"[template]\nHello\n", 1},
+		{"HALT\n[code]\nHALT\n", 1},
+		{"[code]\nHALT\n[code]\nHALT\n", 3},
+		{"[code]\nHALT\n[template xyz]\nHello\n", 3},
+		{"[code]\nHALT\n[menu]\nfoo\n", 3},
+		{"[code]\nHALT\n[template]\nHello\n[code]\nHALT\n", 5},
+		{"[code]\nHALT\n[template]\nHello\n[template]\nHallo\n", 5},
+		{"[template]\nHello\n[code]\nMOUT foo 1\nFOO bar\n", 5},
+	} {
+		_, err := NewAssembler("root.node").ParseNode(v.src)
+		if err == nil {
+			t.Fatalf("expected error for %q", v.src)
+		}
+		var e *Error
+		if !errors.As(err, &e) {
+			t.Fatalf("expected *Error, got %T: %v", err, err)
+		}
+		if e.Line != v.line {
+			t.Fatalf("expected error on line %d for %q, got %v", v.line, v.src, err)
+		}
+	}
+}
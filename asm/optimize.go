@@ -0,0 +1,184 @@
+package asm
+
+import (
+	"fmt"
+
+	"github.com/grassrootseconomics/go-vise/vm"
+)
+
+// a decoded instruction of the bytecode being optimized.
+type optInstruction struct {
+	op     vm.Opcode
+	offset int
+	code   []byte
+	sym    string
+	flag   uint32
+	mode   bool
+}
+
+// split bytecode into instructions.
+func optDecode(b []byte) ([]optInstruction, error) {
+	var r []optInstruction
+	var offset int
+	for len(b) > 0 {
+		v := optInstruction{
+			offset: offset,
+		}
+		op, bb, err := vm.ParseOp(b)
+		if err != nil {
+			return nil, fmt.Errorf("offset %d: %v", offset, err)
+		}
+		v.op = op
+		switch op {
+		case vm.LOAD:
+			v.sym, _, bb, err = vm.ParseLoad(bb)
+		case vm.RELOAD:
+			v.sym, bb, err = vm.ParseReload(bb)
+		case vm.MAP:
+			v.sym, bb, err = vm.ParseMap(bb)
+		case vm.MOVE:
+			v.sym, bb, err = vm.ParseMove(bb)
+		case vm.HALT:
+			bb, err = vm.ParseHalt(bb)
+		case vm.CATCH:
+			v.sym, v.flag, v.mode, bb, err = vm.ParseCatch(bb)
+		case vm.CROAK:
+			v.flag, v.mode, bb, err = vm.ParseCroak(bb)
+		case vm.INCMP:
+			v.sym, _, bb, err = vm.ParseInCmp(bb)
+		case vm.INLIST:
+			v.sym, _, bb, err = vm.ParseInList(bb)
+		case vm.MOUT:
+			_, _, bb, err = vm.ParseMOut(bb)
+		case vm.MNEXT:
+			_, _, bb, err = vm.ParseMNext(bb)
+		case vm.MPREV:
+			_, _, bb, err = vm.ParseMPrev(bb)
+		case vm.MSINK:
+			bb, err = vm.ParseMSink(bb)
+		default:
+			err = fmt.Errorf("unhandled opcode %d", op)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("offset %d: %v", offset, err)
+		}
+		n := len(b) - len(bb)
+		v.code = b[:n]
+		r = append(r, v)
+		b = bb
+		offset += n
+	}
+	return r, nil
+}
+
+// what is known about the vm state at a point in the bytecode, from the instructions executed before it since the last control transfer.
+type optFacts struct {
+	// symbols that are in the cache.
+	loaded map[string]bool
+	// symbols that are mapped to the page.
+	mapped map[string]bool
+	// flags with a known value, as the mode of a CATCH or CROAK that did not match.
+	checked map[uint32]bool
+}
+
+func newOptFacts() optFacts {
+	return optFacts{
+		loaded:  make(map[string]bool),
+		mapped:  make(map[string]bool),
+		checked: make(map[uint32]bool),
+	}
+}
+
+// Optimizer removes redundant and unreachable instructions from bytecode, without changing the behavior of the vm.
+//
+// Instructions are analyzed in runs of code without control transfer, which end at MOVE, INCMP, INLIST and HALT. Within a run:
+//
+//   - A MAP of a symbol that has already been mapped by MAP or RELOAD is removed.
+//   - A LOAD of a symbol that is certainly in the cache, because of a previous LOAD, RELOAD or MAP, is removed. A LOAD is a no-op for cached symbols.
+//   - A CATCH or CROAK on a flag and mode that was just checked by a CATCH or CROAK that did not match is removed, as it cannot match either. LOAD and RELOAD end the knowledge of flag values, as external code may change flags.
+//   - A CATCH or CROAK on a flag that was just checked with the opposite mode always matches, and all code after it is removed as unreachable.
+//
+// RELOAD after LOAD of the same symbol is not merged, as LOAD does not refresh a symbol that is already cached, e.g. when the node is visited again. Code after MOVE is not unreachable; the vm executes it before the code of the node moved to.
+type Optimizer struct {
+	sm *vm.SourceMap
+}
+
+// NewOptimizer creates a new Optimizer.
+func NewOptimizer() *Optimizer {
+	return &Optimizer{}
+}
+
+// WithSourceMap is a chainable function that sets a source map for the bytecode, which is updated with the offsets of the optimized bytecode.
+//
+// Entries for removed instructions are removed from the source map.
+func (o *Optimizer) WithSourceMap(sm *vm.SourceMap) *Optimizer {
+	o.sm = sm
+	return o
+}
+
+// Optimize returns the optimized bytecode.
+func (o *Optimizer) Optimize(b []byte) ([]byte, error) {
+	ins, err := optDecode(b)
+	if err != nil {
+		return nil, err
+	}
+	keep := make([]bool, len(ins))
+	facts := newOptFacts()
+	end := len(ins)
+	for i := 0; i < end; i++ {
+		v := ins[i]
+		keep[i] = true
+		switch v.op {
+		case vm.LOAD:
+			if facts.loaded[v.sym] {
+				keep[i] = false
+				continue
+			}
+			facts.loaded[v.sym] = true
+			facts.checked = make(map[uint32]bool)
+		case vm.RELOAD:
+			facts.loaded[v.sym] = true
+			facts.mapped[v.sym] = true
+			facts.checked = make(map[uint32]bool)
+		case vm.MAP:
+			if facts.mapped[v.sym] {
+				keep[i] = false
+				continue
+			}
+			facts.loaded[v.sym] = true
+			facts.mapped[v.sym] = true
+		case vm.CATCH, vm.CROAK:
+			mode, ok := facts.checked[v.flag]
+			if ok {
+				if mode == v.mode {
+					keep[i] = false
+					continue
+				}
+				logg.Debugf("unreachable code after always matching instruction", "offset", v.offset, "op", vm.OpcodeString[v.op])
+				end = i + 1
+			}
+			facts.checked[v.flag] = v.mode
+		case vm.MOUT, vm.MNEXT, vm.MPREV, vm.MSINK:
+		default:
+			facts = newOptFacts()
+		}
+	}
+
+	var r []byte
+	offsets := make(map[int]int)
+	for i, v := range ins {
+		if i < end && keep[i] {
+			offsets[v.offset] = len(r)
+			r = append(r, v.code...)
+		} else {
+			logg.Tracef("remove instruction", "offset", v.offset, "op", vm.OpcodeString[v.op])
+		}
+	}
+	if o.sm != nil {
+		o.sm.MapOffsets(func(offset int) (int, bool) {
+			n, ok := offsets[offset]
+			return n, ok
+		})
+	}
+	return r, nil
+}
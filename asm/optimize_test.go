@@ -0,0 +1,239 @@
+package asm
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/grassrootseconomics/go-vise/cache"
+	"github.com/grassrootseconomics/go-vise/internal/resourcetest"
+	"github.com/grassrootseconomics/go-vise/resource"
+	"github.com/grassrootseconomics/go-vise/state"
+	"github.com/grassrootseconomics/go-vise/vm"
+)
+
+func assemble(t *testing.T, s string) []byte {
+	b := bytes.NewBuffer(nil)
+	_, err := Parse(s, b)
+	if err != nil {
+		t.Fatalf("%v:\n%s", err, s)
+	}
+	return b.Bytes()
+}
+
+// resource for equivalence runs, with the node code under test as root.
+func newOptResource(t *testing.T, code []byte) *resourcetest.TestResource {
+	ctx := context.Background()
+	var count int
+	rs := resourcetest.NewTestResource()
+	rs.AddTemplate(ctx, "root", "root {{.one}}")
+	rs.AddTemplate(ctx, "foo", "foo")
+	rs.AddTemplate(ctx, "bar", "bar {{.count}}")
+	rs.AddTemplate(ctx, "_catch", "catch")
+	rs.AddBytecode(ctx, "root", code)
+	rs.AddBytecode(ctx, "foo", assemble(t, "MOUT back 0\nHALT\nINCMP _ 0\n"))
+	rs.AddBytecode(ctx, "bar", assemble(t, "LOAD count 0\nMAP count\nHALT\n"))
+	rs.AddBytecode(ctx, "_catch", assemble(t, "HALT\n"))
+	rs.AddFunc(ctx, "one", func(ctx context.Context, sym string, input []byte) (resource.Result, error) {
+		return resource.Result{
+			Content: "one",
+		}, nil
+	})
+	rs.AddFunc(ctx, "count", func(ctx context.Context, sym string, input []byte) (resource.Result, error) {
+		count += 1
+		return resource.Result{
+			Content: fmt.Sprintf("%d", count),
+		}, nil
+	})
+	rs.AddFunc(ctx, "flag", func(ctx context.Context, sym string, input []byte) (resource.Result, error) {
+		return resource.Result{
+			FlagSet: []uint32{state.FLAG_USERSTART},
+		}, nil
+	})
+	rs.AddFunc(ctx, "unflag", func(ctx context.Context, sym string, input []byte) (resource.Result, error) {
+		return resource.Result{
+			FlagReset: []uint32{state.FLAG_USERSTART},
+		}, nil
+	})
+	rs.Lock()
+	return rs
+}
+
+// run the vm, with panics returned as errors.
+func runStep(ctx context.Context, vmi *vm.Vm, b []byte) (r []byte, err error) {
+	defer func() {
+		e := recover()
+		if e != nil {
+			err = fmt.Errorf("panic: %v", e)
+		}
+	}()
+	return vmi.Run(ctx, b)
+}
+
+// observable results of running the node code as root, and then with each of the inputs.
+//
+// Panics of the vm are observable results too.
+func runTrace(t *testing.T, code []byte, inputs []string) []string {
+	var r []string
+	ctx := context.Background()
+	st := state.NewState(2)
+	ca := cache.NewCache()
+	rs := newOptResource(t, code)
+	vmi := vm.NewVm(st, rs, ca, nil)
+	st.Down("root")
+	ca.Push()
+	b := code
+	for i := 0; ; i++ {
+		var err error
+		var out string
+		b, err = runStep(ctx, vmi, b)
+		if err == nil {
+			out, err = vmi.Render(ctx)
+		}
+		where, idx := st.Where()
+		r = append(r, fmt.Sprintf("err: %v\nout: %s\nflags: %x\nat: %s/%d %v\ncache: %v", err, out, st.Flags, where, idx, st.ExecPath, ca.Cache))
+		if err != nil || len(b) == 0 || i == len(inputs) {
+			break
+		}
+		st.SetInput([]byte(inputs[i]))
+	}
+	return r
+}
+
+// check that the original and optimized code have the same observable results.
+func checkEquivalent(t *testing.T, src string, inputs []string) bool {
+	b := assemble(t, src)
+	ob, err := NewOptimizer().Optimize(b)
+	if err != nil {
+		t.Fatalf("%v:\n%s", err, src)
+	}
+	x := runTrace(t, b, inputs)
+	y := runTrace(t, ob, inputs)
+	if len(x) != len(y) {
+		t.Fatalf("expected %d steps, got %d, inputs %v:\n%s", len(x), len(y), inputs, src)
+	}
+	for i, v := range x {
+		if y[i] != v {
+			t.Fatalf("step %d differs, inputs %v:\n%s\nexpected:\n%s\ngot:\n%s", i, inputs, src, v, y[i])
+		}
+	}
+	return !bytes.Equal(b, ob)
+}
+
+func TestOptimize(t *testing.T) {
+	for _, v := range [][2]string{
+		{"LOAD one 0\nMAP one\nMAP one\nHALT\n", "LOAD one 0\nMAP one\nHALT\n"},
+		{"LOAD one 0\nRELOAD one\nMAP one\nHALT\n", "LOAD one 0\nRELOAD one\nHALT\n"},
+		{"LOAD one 0\nMAP one\nLOAD one 0\nHALT\n", "LOAD one 0\nMAP one\nHALT\n"},
+		{"LOAD one 0\nMAP one\nHALT\nMAP one\n", "LOAD one 0\nMAP one\nHALT\nMAP one\n"},
+		{"LOAD one 0\nMAP one\nMOVE foo\nMAP one\n", "LOAD one 0\nMAP one\nMOVE foo\nMAP one\n"},
+		{"CATCH foo 8 1\nCATCH bar 8 1\nHALT\n", "CATCH foo 8 1\nHALT\n"},
+		{"CATCH foo 8 1\nCROAK 8 1\nHALT\n", "CATCH foo 8 1\nHALT\n"},
+		{"CATCH foo 8 1\nLOAD flag 0\nCATCH bar 8 1\nHALT\n", "CATCH foo 8 1\nLOAD flag 0\nCATCH bar 8 1\nHALT\n"},
+		{"CATCH foo 8 1\nMOUT back 0\nCATCH bar 8 0\nHALT\nINCMP foo 0\n", "CATCH foo 8 1\nMOUT back 0\nCATCH bar 8 0\n"},
+		{"LOAD one 0\nRELOAD one\nHALT\n", "LOAD one 0\nRELOAD one\nHALT\n"},
+	} {
+		r, err := NewOptimizer().Optimize(assemble(t, v[0]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect := assemble(t, v[1])
+		if !bytes.Equal(r, expect) {
+			t.Fatalf("optimizing:\n%sexpected:\n%sgot:\n%x", v[0], v[1], r)
+		}
+	}
+}
+
+func TestOptimizeSourceMap(t *testing.T) {
+	src := "LOAD one 0\nMAP one\nMAP one\nHALT\n"
+	sm := vm.NewSourceMap("root.vis")
+	b := bytes.NewBuffer(nil)
+	_, err := NewAssembler("root.vis").WithSourceMap(sm).Parse(src, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewOptimizer().WithSourceMap(sm).Optimize(b.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sm.Len() != 3 {
+		t.Fatalf("expected 3 source map entries, got %d", sm.Len())
+	}
+	loc, ok := sm.Locate(len(r) - 1)
+	if !ok || loc.Line != 4 {
+		t.Fatalf("expected HALT at line 4, got %v", loc)
+	}
+}
+
+func TestOptimizeEquivalent(t *testing.T) {
+	for _, v := range []string{
+		"LOAD one 0\nMAP one\nMAP one\nHALT\n",
+		"LOAD count 0\nRELOAD count\nMAP count\nMOVE bar\n",
+		"LOAD flag 0\nCATCH foo 8 0\nCATCH bar 8 0\nCROAK 8 1\nMOVE foo\n",
+		"LOAD one 0\nCATCH foo 9 1\nMAP one\nCATCH bar 9 0\nMOVE foo\n",
+		"LOAD one 0\nMAP one\nHALT\nINCMP foo 0\nINCMP bar 1\nLOAD one 0\nMAP one\n",
+	} {
+		for _, inputs := range [][]string{{"0", "0"}, {"1", "0"}, {"2"}} {
+			checkEquivalent(t, v, inputs)
+		}
+	}
+}
+
+// random node code from a small set of instructions and symbols, so that redundant instructions are likely.
+//
+// Targets do not lead back to the root node, as the vm would loop forever on code that moves to root unconditionally.
+func randomCode(rnd *rand.Rand) string {
+	syms := []string{"one", "count", "flag", "unflag"}
+	targets := []string{"foo", "bar"}
+	var s string
+	n := 2 + rnd.Intn(12)
+	for i := 0; i < n; i++ {
+		sym := syms[rnd.Intn(len(syms))]
+		target := targets[rnd.Intn(len(targets))]
+		flag := 8 + rnd.Intn(2)
+		switch rnd.Intn(10) {
+		case 0, 1:
+			s += fmt.Sprintf("LOAD %s 0\n", sym)
+		case 2:
+			s += fmt.Sprintf("RELOAD %s\n", sym)
+		case 3, 4:
+			s += fmt.Sprintf("MAP %s\n", sym)
+		case 5:
+			s += fmt.Sprintf("CATCH %s %d %d\n", target, flag, rnd.Intn(2))
+		case 6:
+			s += fmt.Sprintf("CROAK %d %d\n", flag, rnd.Intn(2))
+		case 7:
+			s += fmt.Sprintf("MOUT %s %d\n", sym, rnd.Intn(3))
+		case 8:
+			s += fmt.Sprintf("INCMP %s %d\n", target, rnd.Intn(3))
+		case 9:
+			if rnd.Intn(2) == 0 {
+				s += "HALT\n"
+			} else {
+				s += fmt.Sprintf("MOVE %s\n", target)
+			}
+		}
+	}
+	return s
+}
+
+func TestOptimizeEquivalentRandom(t *testing.T) {
+	var changed int
+	rnd := rand.New(rand.NewSource(42))
+	for i := 0; i < 500; i++ {
+		src := randomCode(rnd)
+		var inputs []string
+		for j := 0; j < 3; j++ {
+			inputs = append(inputs, fmt.Sprintf("%d", rnd.Intn(3)))
+		}
+		if checkEquivalent(t, src, inputs) {
+			changed += 1
+		}
+	}
+	if changed == 0 {
+		t.Fatal("no code was changed by the optimizer")
+	}
+	t.Logf("optimized %d of 500 random nodes", changed)
+}
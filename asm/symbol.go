@@ -0,0 +1,282 @@
+package asm
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// SymbolTable resolves named flags, sizes and selectors in assembly code to their values.
+//
+// Flags are handled by the embedded FlagParser.
+type SymbolTable struct {
+	*FlagParser
+	size     map[string]uint32
+	selector map[string]string
+}
+
+// NewSymbolTable creates a new, empty SymbolTable.
+func NewSymbolTable() *SymbolTable {
+	return &SymbolTable{
+		FlagParser: NewFlagParser(),
+		size:       make(map[string]uint32),
+		selector:   make(map[string]string),
+	}
+}
+
+// Define adds a named value of the given kind, which is one of "flag", "size" or "selector".
+//
+// Flag values must be numeric and no lower than state.FLAG_USERSTART. Size values must be numeric.
+func (st *SymbolTable) Define(kind string, name string, value string) error {
+	switch kind {
+	case "flag":
+		return st.FlagParser.add(name, value, "")
+	case "size":
+		n, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return fmt.Errorf("size value must be numeric: %s", value)
+		}
+		st.size[name] = uint32(n)
+		logg.Debugf("added size definition", "from", name, "to", n)
+	case "selector":
+		st.selector[name] = value
+		logg.Debugf("added selector definition", "from", name, "to", value)
+	default:
+		return fmt.Errorf("unknown definition kind: %s", kind)
+	}
+	return nil
+}
+
+// GetSize returns the value for a named size.
+func (st *SymbolTable) GetSize(name string) (uint32, error) {
+	v, ok := st.size[name]
+	if !ok {
+		return 0, fmt.Errorf("no size registered under key: %s", name)
+	}
+	return v, nil
+}
+
+// SizeNames returns the names of all defined sizes, in alphabetical order.
+func (st *SymbolTable) SizeNames() []string {
+	var r []string
+	for k := range st.size {
+		r = append(r, k)
+	}
+	sort.Strings(r)
+	return r
+}
+
+// SelectorNames returns the names of all defined selectors, in alphabetical order.
+func (st *SymbolTable) SelectorNames() []string {
+	var r []string
+	for k := range st.selector {
+		r = append(r, k)
+	}
+	sort.Strings(r)
+	return r
+}
+
+// GetSelector returns the value for a named selector.
+func (st *SymbolTable) GetSelector(name string) (string, error) {
+	v, ok := st.selector[name]
+	if !ok {
+		return "", fmt.Errorf("no selector registered under key: %s", name)
+	}
+	return v, nil
+}
+
+// Load parses a Comma Seperated Value file under the given filepath
+// to provide named values.
+//
+// The expected format is:
+//
+// Field 1: The literal string "flag", "size" or "selector"
+// Field 2: Name
+// Field 3: Value
+// Field 4: Flag description (optional, only for "flag")
+//
+// Lines with any other value in the first field are ignored.
+func (st *SymbolTable) Load(fp string) (int, error) {
+	var i int
+	f, err := os.Open(fp)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	for i = 0; true; i++ {
+		v, err := r.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return 0, err
+		}
+		switch v[0] {
+		case "flag", "size", "selector":
+		default:
+			continue
+		}
+		if len(v) < 3 {
+			return 0, fmt.Errorf("Not enough fields for %s setting in line %d", v[0], i)
+		}
+		if v[0] == "flag" && len(v) > 3 {
+			err = st.FlagParser.add(v[1], v[2], v[3])
+		} else {
+			err = st.Define(v[0], v[1], v[2])
+		}
+		if err != nil {
+			return 0, fmt.Errorf("line %d: %v", i, err)
+		}
+	}
+	return i, nil
+}
+
+// ArgKind is the meaning of an instruction argument.
+type ArgKind uint8
+
+const (
+	// Target node of a move.
+	ARG_NODE ArgKind = iota + 1
+	// Data symbol.
+	ARG_SYMBOL
+	// Flag index.
+	ARG_FLAG
+	// Size.
+	ARG_SIZE
+	// Menu or input selector.
+	ARG_SELECTOR
+	// Menu label.
+	ARG_LABEL
+	// Flag match mode.
+	ARG_MODE
+)
+
+var argKinds = map[string][]ArgKind{
+	"CATCH":    {ARG_NODE, ARG_FLAG, ARG_MODE},
+	"CROAK":    {ARG_FLAG, ARG_MODE},
+	"LOAD":     {ARG_SYMBOL, ARG_SIZE},
+	"RELOAD":   {ARG_SYMBOL},
+	"MAP":      {ARG_SYMBOL},
+	"MOVE":     {ARG_NODE},
+	"HALT":     {},
+	"INCMP":    {ARG_NODE, ARG_SELECTOR},
+	"INLIST":   {ARG_NODE, ARG_SYMBOL},
+	"MSINK":    {},
+	"MOUT":     {ARG_LABEL, ARG_SELECTOR},
+	"MNEXT":    {ARG_LABEL, ARG_SELECTOR},
+	"MPREV":    {ARG_LABEL, ARG_SELECTOR},
+	"DOWN":     {ARG_NODE, ARG_SELECTOR, ARG_LABEL},
+	"UP":       {ARG_SELECTOR, ARG_LABEL},
+	"NEXT":     {ARG_SELECTOR, ARG_LABEL},
+	"PREVIOUS": {ARG_SELECTOR, ARG_LABEL},
+}
+
+// Instructions returns the names of all instructions, including batch instructions, in alphabetical order.
+func Instructions() []string {
+	var r []string
+	for k := range argKinds {
+		r = append(r, k)
+	}
+	sort.Strings(r)
+	return r
+}
+
+// ArgKinds returns the kinds of the arguments of the named instruction.
+//
+// Returns false if the instruction does not exist.
+func ArgKinds(op string) ([]ArgKind, bool) {
+	r, ok := argKinds[op]
+	return r, ok
+}
+
+// Token is a field of an assembly code line.
+type Token struct {
+	Text string
+	// Column is the 1-based byte position of the token in the line.
+	Column int
+}
+
+// Tokenize splits a line of assembly code into tokens, up to any comment.
+func Tokenize(s string) []Token {
+	var r []Token
+	start := -1
+	for i, c := range s + " " {
+		if c == '#' || c == ' ' || c == '\t' || c == '\r' || c == '\n' {
+			if start > -1 {
+				r = append(r, Token{s[start:i], start + 1})
+				start = -1
+			}
+			if c == '#' {
+				break
+			}
+			continue
+		}
+		if start == -1 {
+			start = i
+		}
+	}
+	return r
+}
+
+func isNumeric(s string) bool {
+	_, err := strconv.ParseUint(s, 10, 32)
+	return err == nil
+}
+
+// Resolve substitutes named flags, sizes and selectors in the arguments of an instruction line with their values.
+//
+// All arguments that can be resolved are substituted. Named flags and sizes that are not defined are errors. Selectors that are not defined are left as they are.
+//
+// On error, the column of the failing argument is returned.
+func (st *SymbolTable) Resolve(line string) (string, int, error) {
+	tk := Tokenize(line)
+	if len(tk) < 2 {
+		return line, 0, nil
+	}
+	kinds := argKinds[tk[0].Text]
+	var r string
+	// end of the part of the line already copied to the result.
+	var c int
+	for i, t := range tk[1:] {
+		if i >= len(kinds) {
+			break
+		}
+		var v string
+		switch kinds[i] {
+		case ARG_FLAG:
+			if isNumeric(t.Text) {
+				continue
+			}
+			fv, err := st.GetAsString(t.Text)
+			if err != nil {
+				return line, t.Column, fmt.Errorf("unknown flag: %s", t.Text)
+			}
+			v = fv
+		case ARG_SIZE:
+			if isNumeric(t.Text) {
+				continue
+			}
+			sz, err := st.GetSize(t.Text)
+			if err != nil {
+				return line, t.Column, fmt.Errorf("unknown size: %s", t.Text)
+			}
+			v = strconv.FormatUint(uint64(sz), 10)
+		case ARG_SELECTOR:
+			sel, err := st.GetSelector(t.Text)
+			if err != nil {
+				continue
+			}
+			v = sel
+		default:
+			continue
+		}
+		r += line[c:t.Column-1] + v
+		c = t.Column - 1 + len(t.Text)
+	}
+	return r + line[c:], 0, nil
+}
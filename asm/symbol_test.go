@@ -0,0 +1,168 @@
+package asm
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/grassrootseconomics/go-vise/vm"
+)
+
+func TestSymbolResolve(t *testing.T) {
+	st := NewSymbolTable()
+	err := st.Define("flag", "foo", "8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = st.Define("size", "bar", "32")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = st.Define("selector", "back", "0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range [][2]string{
+		{"CATCH xyzzy foo 1", "CATCH xyzzy 8 1"},
+		{"CROAK foo 0 # foo", "CROAK 8 0 # foo"},
+		{"LOAD baz bar", "LOAD baz 32"},
+		{"LOAD baz 0", "LOAD baz 0"},
+		{"INCMP _ back", "INCMP _ 0"},
+		{"INCMP back back", "INCMP back 0"},
+		{"UP back back", "UP 0 back"},
+		{"DOWN foo back to_foo", "DOWN foo 0 to_foo"},
+		{"INCMP foo *", "INCMP foo *"},
+		{"MAP foo", "MAP foo"},
+	} {
+		r, _, err := st.Resolve(v[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r != v[1] {
+			t.Fatalf("expected %q, got %q", v[1], r)
+		}
+	}
+
+	// no instruction has more than one argument that can be resolved, so use a test instruction for substitution of several.
+	argKinds["TEST"] = []ArgKind{ARG_FLAG, ARG_NODE, ARG_SIZE, ARG_SELECTOR}
+	t.Cleanup(func() {
+		delete(argKinds, "TEST")
+	})
+	r, _, err := st.Resolve("TEST foo foo  bar back # foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r != "TEST 8 foo  32 0 # foo" {
+		t.Fatalf("expected all symbolic arguments substituted, got %q", r)
+	}
+	_, col, err := st.Resolve("TEST foo foo inky back")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if col != 14 {
+		t.Fatalf("expected column 14, got %d", col)
+	}
+
+	_, col, err = st.Resolve("CATCH xyzzy inky 1")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if col != 13 {
+		t.Fatalf("expected column 13, got %d", col)
+	}
+	_, _, err = st.Resolve("LOAD baz pinky")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	err = st.Define("flag", "inky", "1")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	err = st.Define("size", "inky", "pinky")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	err = st.Define("color", "inky", "pink")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestParseDefine(t *testing.T) {
+	s := `DEFINE flag foo 8
+DEFINE size bar 32
+DEFINE selector back 0
+LOAD baz bar
+CATCH xyzzy foo 1
+UP back to_back
+`
+	b := bytes.NewBuffer(nil)
+	_, err := Parse(s, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sr := `LOAD baz 32
+CATCH xyzzy 8 1
+UP 0 to_back
+`
+	br := bytes.NewBuffer(nil)
+	_, err = Parse(sr, br)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b.Bytes(), br.Bytes()) {
+		t.Fatalf("expected:\n\t%x\ngot:\n\t%x", br.Bytes(), b.Bytes())
+	}
+}
+
+func TestParseSymbols(t *testing.T) {
+	st := NewSymbolTable()
+	err := st.Define("flag", "foo", "8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := bytes.NewBuffer(nil)
+	_, err = NewAssembler("main.vis").WithSymbols(st).Parse("CROAK foo 1\n", b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := vm.NewLine(nil, vm.CROAK, nil, []byte{0x08}, []uint8{1})
+	if !bytes.Equal(b.Bytes(), expect) {
+		t.Fatalf("expected %x, got %x", expect, b.Bytes())
+	}
+
+	_, err = NewAssembler("main.vis").WithSymbols(st).Parse("HALT\nCATCH xyzzy bar 1\n", b)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if !strings.HasPrefix(err.Error(), "main.vis:2:13: unknown flag") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	tk := Tokenize("\tCATCH foo  bar 1# comment")
+	expect := []Token{{"CATCH", 2}, {"foo", 8}, {"bar", 13}, {"1", 17}}
+	if len(tk) != len(expect) {
+		t.Fatalf("expected %v, got %v", expect, tk)
+	}
+	for i, v := range expect {
+		if tk[i] != v {
+			t.Fatalf("expected %v, got %v", v, tk[i])
+		}
+	}
+	kinds, ok := ArgKinds(tk[0].Text)
+	if !ok {
+		t.Fatal("expected instruction")
+	}
+	if kinds[0] != ARG_NODE || kinds[1] != ARG_FLAG {
+		t.Fatalf("unexpected argument kinds: %v", kinds)
+	}
+	_, ok = ArgKinds("FOO")
+	if ok {
+		t.Fatal("expected no instruction")
+	}
+}
@@ -20,7 +20,9 @@ type Cache struct {
 	Sizes map[string]uint16
 	// Last inserted value (regardless of scope)
 	LastValue string
-	invalid   bool
+	// Item ids of list symbols, by level
+	Items   []map[string][]string
+	invalid bool
 }
 
 // NewCache creates a new ready-to-use Cache object
@@ -134,6 +136,8 @@ func (ca *Cache) ResetFull() {
 	ca.Cache = []map[string]string{make(map[string]string)}
 	ca.Sizes = make(map[string]uint16)
 	ca.CacheUseSize = 0
+	ca.Items = nil
+	ca.syncItems()
 }
 
 // Get implements the Memory interface.
@@ -166,6 +170,7 @@ func (ca *Cache) Reset() {
 	for _, v = range ca.Cache[0] {
 		ca.CacheUseSize += uint32(len(v))
 	}
+	ca.syncItems()
 }
 
 // Push implements the Memory interface.
@@ -175,6 +180,7 @@ func (ca *Cache) Push() error {
 
 	m := make(map[string]string)
 	ca.Cache = append(ca.Cache, m)
+	ca.syncItems()
 	return nil
 }
 
@@ -187,6 +193,7 @@ func (ca *Cache) Pop() error {
 	if l == 0 {
 		return fmt.Errorf("already at top level")
 	}
+	ca.syncItems()
 	l -= 1
 	m := ca.Cache[l]
 	for k, v := range m {
@@ -196,10 +203,12 @@ func (ca *Cache) Pop() error {
 		logg.Debugf("Cache free", "frame", l, "key", k, "size", sz)
 	}
 	ca.Cache = ca.Cache[:l]
+	ca.Items = ca.Items[:l]
 	if l == 0 {
 		// Call push without locking since we already hold the lock
 		m := make(map[string]string)
 		ca.Cache = append(ca.Cache, m)
+		ca.syncItems()
 	}
 	return nil
 }
@@ -225,3 +225,66 @@ func TestCacheKeys(t *testing.T) {
 		t.Fatalf("Missing 'clyde'")
 	}
 }
+
+func TestCacheItems(t *testing.T) {
+	ca := NewCache().WithCacheSize(8)
+	err := ca.Add("foo", "inky", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca.Last()
+	ca.Push()
+	ids := []string{"0xdead", "0xbe\nef"}
+	err = ca.SetItems("foo", ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ca.SetItems("bar", ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ca.CacheUseSize != 4 {
+		t.Fatalf("expected items not to count towards cache size, got %d", ca.CacheUseSize)
+	}
+	if ca.Last() != "" {
+		t.Fatal("expected items not to change last value")
+	}
+	_, err = ca.ReservedSize("bar")
+	if err == nil {
+		t.Fatal("expected items not to reserve size")
+	}
+	r, err := ca.GetItems("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r) != 2 || r[1] != "0xbe\nef" {
+		t.Fatalf("unexpected items: %v", r)
+	}
+
+	// items are freed with the level of the symbol.
+	ca.Pop()
+	_, err = ca.GetItems("bar")
+	if err == nil {
+		t.Fatal("expected items of undefined symbol to be freed with current level")
+	}
+	_, err = ca.GetItems("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca.ResetFull()
+	_, err = ca.GetItems("foo")
+	if err == nil {
+		t.Fatal("expected no items after full reset")
+	}
+
+	// cache restored without items.
+	ca.Items = nil
+	ca.Push()
+	err = ca.SetItems("foo", ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ca.Items) != len(ca.Cache) {
+		t.Fatalf("expected %d item levels, got %d", len(ca.Cache), len(ca.Items))
+	}
+}
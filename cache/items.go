@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"fmt"
+	"slices"
+)
+
+// ItemMemory is implemented by Memory implementations that can store the item ids of list symbols.
+//
+// Item ids are kept apart from the symbol values, and do not count towards the cache size.
+type ItemMemory interface {
+	// SetItems stores the item ids of a list symbol, replacing any ids previously stored for it.
+	//
+	// The ids are stored in the level where the symbol is defined, or in the current level if the symbol is not defined, and are freed with that level.
+	SetItems(key string, ids []string) error
+	// GetItems returns the item ids stored for a list symbol, at any level.
+	//
+	// Must fail if no ids are stored for the symbol.
+	GetItems(key string) ([]string, error)
+}
+
+// SetItems implements the ItemMemory interface.
+func (ca *Cache) SetItems(key string, ids []string) error {
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+	ca.syncItems()
+	for _, m := range ca.Items {
+		delete(m, key)
+	}
+	i := ca.frameOf(key)
+	if i == -1 {
+		i = len(ca.Cache) - 1
+	}
+	ca.Items[i][key] = slices.Clone(ids)
+	logg.Debugf("Cache set items", "key", key, "frame", i, "count", len(ids))
+	return nil
+}
+
+// GetItems implements the ItemMemory interface.
+func (ca *Cache) GetItems(key string) ([]string, error) {
+	ca.mu.RLock()
+	defer ca.mu.RUnlock()
+	for _, m := range ca.Items {
+		ids, ok := m[key]
+		if ok {
+			return slices.Clone(ids), nil
+		}
+	}
+	return nil, fmt.Errorf("no items for key '%s'", key)
+}
+
+// match the item levels with the value levels, e.g. for a cache restored from data persisted without items.
+//
+// caller must hold the lock.
+func (ca *Cache) syncItems() {
+	for len(ca.Items) < len(ca.Cache) {
+		ca.Items = append(ca.Items, make(map[string][]string))
+	}
+	ca.Items = ca.Items[:len(ca.Cache)]
+}
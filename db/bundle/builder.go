@@ -0,0 +1,238 @@
+package bundle
+
+import (
+	"archive/zip"
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/grassrootseconomics/go-vise/db"
+	"github.com/grassrootseconomics/go-vise/lang"
+)
+
+// Builder creates a bundle from resource contents.
+type Builder struct {
+	name      string
+	version   string
+	flags     []byte
+	key       ed25519.PrivateKey
+	entries   map[string][]byte
+	languages map[string]bool
+}
+
+// NewBuilder creates a new Builder for the named application.
+func NewBuilder(name string) *Builder {
+	return &Builder{
+		name:      name,
+		entries:   make(map[string][]byte),
+		languages: make(map[string]bool),
+	}
+}
+
+// WithVersion is a chainable function that sets the application version recorded in the manifest.
+func (bb *Builder) WithVersion(version string) *Builder {
+	bb.version = version
+	return bb
+}
+
+// WithFlags is a chainable function that sets the contents of the flag definitions file to include in the bundle.
+func (bb *Builder) WithFlags(flags []byte) *Builder {
+	bb.flags = flags
+	return bb
+}
+
+// WithSigningKey is a chainable function that sets the key used to sign the manifest.
+func (bb *Builder) WithSigningKey(key ed25519.PrivateKey) *Builder {
+	bb.key = key
+	return bb
+}
+
+// Add adds a value for the given datatype and key to the bundle.
+//
+// If language is not nil, the value is added as the translation for that language.
+//
+// Only DATATYPE_BIN, DATATYPE_MENU, DATATYPE_TEMPLATE and DATATYPE_STATICLOAD can be added. Translations are only valid for the latter three.
+func (bb *Builder) Add(typ uint8, key []byte, ln *lang.Language, v []byte) error {
+	if ln != nil && typ == db.DATATYPE_BIN {
+		return fmt.Errorf("bytecode cannot have translations")
+	}
+	k := db.ToDbKey(typ, append([]byte{}, key...), ln)
+	s, err := entryName(k)
+	if err != nil {
+		return err
+	}
+	_, ok := bb.entries[s]
+	if ok {
+		return fmt.Errorf("duplicate bundle entry: %s", s)
+	}
+	bb.entries[s] = v
+	if ln != nil {
+		bb.languages[ln.Code] = true
+	}
+	return nil
+}
+
+// language of a translation file name, and the name without the language suffix.
+func splitLanguage(s string) (string, *lang.Language) {
+	i := strings.LastIndex(s, "_")
+	if i < 1 || len(s)-i != 4 {
+		return s, nil
+	}
+	ln, err := lang.LanguageFromCode(s[i+1:])
+	if err != nil {
+		return s, nil
+	}
+	return s[:i], &ln
+}
+
+// add a file of the legacy resource directory layout, skipping files that are not resources.
+func (bb *Builder) addFile(fp string, fb string) error {
+	var typ uint8
+	var ln *lang.Language
+	if fb[0] < 0x61 || fb[0] > 0x7A {
+		logg.Debugf("skip non-resource file", "file", fp)
+		return nil
+	}
+	s := fb
+	if path.Ext(s) == ".bin" {
+		typ = db.DATATYPE_BIN
+		s = strings.TrimSuffix(s, ".bin")
+	} else {
+		s, ln = splitLanguage(s)
+		switch {
+		case path.Ext(s) == ".txt":
+			typ = db.DATATYPE_STATICLOAD
+			s = strings.TrimSuffix(s, ".txt")
+		case path.Ext(s) != "":
+			logg.Debugf("skip non-resource file", "file", fp)
+			return nil
+		case strings.HasSuffix(s, "_menu"):
+			typ = db.DATATYPE_MENU
+		default:
+			typ = db.DATATYPE_TEMPLATE
+		}
+	}
+	v, err := os.ReadFile(fp)
+	if err != nil {
+		return err
+	}
+	logg.Tracef("add bundle file", "file", fp, "typ", typ, "key", s, "lang", ln)
+	return bb.Add(typ, []byte(s), ln, v)
+}
+
+// AddDir adds the contents of a resource directory to the bundle.
+//
+// The directory has the layout read by db/fs and dev/dbconvert:
+//
+//   - Bytecode in files with the .bin extension.
+//   - Static content in files with the .txt extension.
+//   - Menus in files with the _menu suffix.
+//   - Templates in files without extension.
+//   - Translations of menus, templates and static content in files with the language code appended, e.g. root_nor or root_menu_nor.
+//   - Gettext files in the locale subdirectory.
+//
+// All other files are ignored.
+func (bb *Builder) AddDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, v := range entries {
+		if v.IsDir() {
+			continue
+		}
+		err = bb.addFile(path.Join(dir, v.Name()), v.Name())
+		if err != nil {
+			return err
+		}
+	}
+	localeDir := path.Join(dir, LOCALE_DIR)
+	_, err = os.Stat(localeDir)
+	if err != nil {
+		return nil
+	}
+	return filepath.WalkDir(localeDir, func(fp string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, fp)
+		if err != nil {
+			return err
+		}
+		v, err := os.ReadFile(fp)
+		if err != nil {
+			return err
+		}
+		bb.entries[filepath.ToSlash(rel)] = v
+		return nil
+	})
+}
+
+// Write writes the bundle to the writer, and returns the manifest of the written bundle.
+//
+// If a signing key has been set, the signature of the manifest is included.
+func (bb *Builder) Write(w io.Writer) (*Manifest, error) {
+	entries := maps.Clone(bb.entries)
+	if bb.flags != nil {
+		entries[FLAGS_FILE] = bb.flags
+	}
+	names := slices.Sorted(maps.Keys(entries))
+	ch := newContentHash()
+	for _, s := range names {
+		ch.add(s, entries[s])
+	}
+	mf := &Manifest{
+		Format:    FORMAT_VERSION,
+		Name:      bb.name,
+		Version:   bb.version,
+		Languages: slices.Sorted(maps.Keys(bb.languages)),
+		Entries:   ch.n,
+		Hash:      ch.String(),
+	}
+	mfv, err := json.MarshalIndent(mf, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+
+	zw := zip.NewWriter(w)
+	put := func(name string, v []byte) error {
+		f, err := zw.CreateHeader(&zip.FileHeader{
+			Name:   name,
+			Method: zip.Deflate,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(v)
+		return err
+	}
+	err = put(MANIFEST_FILE, mfv)
+	if err != nil {
+		return nil, err
+	}
+	if bb.key != nil {
+		err = put(SIGNATURE_FILE, ed25519.Sign(bb.key, mfv))
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, s := range names {
+		err = put(s, entries[s])
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = zw.Close()
+	if err != nil {
+		return nil, err
+	}
+	return mf, nil
+}
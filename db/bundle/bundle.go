@@ -0,0 +1,137 @@
+package bundle
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+
+	"github.com/grassrootseconomics/go-vise/db"
+)
+
+const (
+	// Version of the bundle format written by the Builder.
+	FORMAT_VERSION = 1
+
+	// Archive entry holding the manifest.
+	MANIFEST_FILE = "manifest.json"
+	// Archive entry holding the ed25519 signature of the manifest.
+	SIGNATURE_FILE = "manifest.sig"
+	// Archive entry holding the flag definitions, in the csv format read by asm.FlagParser.
+	FLAGS_FILE = "flags.csv"
+	// Archive directory holding gettext locale files, in the layout read by resource.PoResource.
+	LOCALE_DIR = "locale"
+
+	integrityPrefix = "bundle failed integrity check: "
+)
+
+var (
+	// archive directories for the datatypes that can be stored in a bundle.
+	typDirs = map[uint8]string{
+		db.DATATYPE_BIN:        "bin",
+		db.DATATYPE_MENU:       "menu",
+		db.DATATYPE_TEMPLATE:   "template",
+		db.DATATYPE_STATICLOAD: "static",
+	}
+
+	// ErrReadOnly is returned when attempting to modify the contents of a bundle.
+	ErrReadOnly = errors.New("bundle is read-only")
+)
+
+// Manifest describes the contents of a bundle.
+type Manifest struct {
+	// Format is the version of the bundle format.
+	Format int `json:"format"`
+	// Name of the application.
+	Name string `json:"name"`
+	// Version of the application.
+	Version string `json:"version,omitempty"`
+	// Languages are the codes of the languages with translations in the bundle.
+	Languages []string `json:"languages,omitempty"`
+	// Entries is the number of archive entries covered by the content hash.
+	Entries int `json:"entries"`
+	// Hash is the hex encoded SHA256 content hash of the archive entries.
+	Hash string `json:"hash"`
+}
+
+// ErrIntegrity is returned when the bundle contents do not match the manifest, or the manifest signature cannot be verified.
+type ErrIntegrity struct {
+	reason string
+}
+
+// NewErrIntegrity creates a new ErrIntegrity with the given reason.
+func NewErrIntegrity(reason string) error {
+	return ErrIntegrity{reason}
+}
+
+// Error implements Error.
+func (e ErrIntegrity) Error() string {
+	return integrityPrefix + e.reason
+}
+
+// IsIntegrity returns true if the error is or wraps an ErrIntegrity.
+func IsIntegrity(err error) bool {
+	var target ErrIntegrity
+	return errors.As(err, &target)
+}
+
+// content hash over archive entries, which must be written in lexical order of their names.
+type contentHash struct {
+	h hash.Hash
+	n int
+}
+
+func newContentHash() *contentHash {
+	return &contentHash{
+		h: sha256.New(),
+	}
+}
+
+// add an entry to the hash, with the name and length of the content as prefix.
+func (ch *contentHash) add(name string, v []byte) {
+	var b [8]byte
+	ch.h.Write([]byte(name))
+	ch.h.Write([]byte{0})
+	binary.BigEndian.PutUint64(b[:], uint64(len(v)))
+	ch.h.Write(b[:])
+	ch.h.Write(v)
+	ch.n += 1
+}
+
+func (ch *contentHash) String() string {
+	return hex.EncodeToString(ch.h.Sum(nil))
+}
+
+// true if the archive entry is covered by the content hash.
+func isContent(name string) bool {
+	return name != MANIFEST_FILE && name != SIGNATURE_FILE
+}
+
+// archive entry name for a storage key as generated by db.ToDbKey.
+func entryName(k []byte) (string, error) {
+	if len(k) < 2 {
+		return "", fmt.Errorf("invalid db key")
+	}
+	d, ok := typDirs[k[0]]
+	if !ok {
+		return "", fmt.Errorf("datatype %d cannot be stored in bundle", k[0])
+	}
+	return d + "/" + string(k[1:]), nil
+}
+
+// storage key for an archive entry name, or nil if the entry is not a storage entry.
+func entryKey(name string) []byte {
+	d, s, ok := strings.Cut(name, "/")
+	if !ok || s == "" {
+		return nil
+	}
+	for typ, v := range typDirs {
+		if v == d {
+			return append([]byte{typ}, []byte(s)...)
+		}
+	}
+	return nil
+}
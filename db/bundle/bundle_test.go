@@ -0,0 +1,326 @@
+package bundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/grassrootseconomics/go-vise/db"
+	"github.com/grassrootseconomics/go-vise/lang"
+)
+
+var (
+	testDirFiles = map[string]string{
+		"root.bin":              "\x00\x01\x00\x00",
+		"root":                  "hello",
+		"root_nor":              "hallo",
+		"root_menu":             "back",
+		"root_menu_nor":         "tilbake",
+		"foo.txt":               "static",
+		"root.vis":              "HALT\n",
+		"pp.csv":                "flag,foo,8\n",
+		"locale/nor/default.po": "msgid \"hello\"\nmsgstr \"hallo\"\n",
+	}
+)
+
+func writeTestDir(t *testing.T) string {
+	dir := t.TempDir()
+	err := os.MkdirAll(path.Join(dir, "locale", "nor"), 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range testDirFiles {
+		err = os.WriteFile(path.Join(dir, k), []byte(v), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func writeTestBundle(t *testing.T, bb *Builder) string {
+	fp := path.Join(t.TempDir(), "app.zip")
+	f, err := os.Create(fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	_, err = bb.Write(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fp
+}
+
+func TestBundleGet(t *testing.T) {
+	ctx := context.Background()
+	bb := NewBuilder("test").WithVersion("1.0.0").WithFlags([]byte(testDirFiles["pp.csv"]))
+	err := bb.AddDir(writeTestDir(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fp := writeTestBundle(t, bb)
+
+	store := NewBundleDb()
+	err = store.Connect(ctx, fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close(ctx)
+
+	mf := store.Manifest()
+	if mf.Name != "test" || mf.Version != "1.0.0" {
+		t.Fatalf("unexpected manifest: %v", mf)
+	}
+	if len(mf.Languages) != 1 || mf.Languages[0] != "nor" {
+		t.Fatalf("expected languages [nor], got %v", mf.Languages)
+	}
+
+	ln, err := lang.LanguageFromCode("nor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lctx := context.WithValue(ctx, "Language", ln)
+	for _, v := range []struct {
+		typ uint8
+		ctx context.Context
+		key string
+		val string
+	}{
+		{db.DATATYPE_BIN, ctx, "root", testDirFiles["root.bin"]},
+		{db.DATATYPE_TEMPLATE, ctx, "root", "hello"},
+		{db.DATATYPE_TEMPLATE, lctx, "root", "hallo"},
+		{db.DATATYPE_MENU, lctx, "root_menu", "tilbake"},
+		{db.DATATYPE_STATICLOAD, lctx, "foo", "static"},
+	} {
+		store.SetPrefix(v.typ)
+		r, err := store.Get(v.ctx, []byte(v.key))
+		if err != nil {
+			t.Fatalf("%d/%s: %v", v.typ, v.key, err)
+		}
+		if string(r) != v.val {
+			t.Fatalf("%d/%s: expected %q, got %q", v.typ, v.key, v.val, r)
+		}
+	}
+
+	store.SetPrefix(db.DATATYPE_TEMPLATE)
+	_, err = store.Get(ctx, []byte("root.vis"))
+	if !db.IsNotFound(err) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	err = store.Put(ctx, []byte("root"), []byte("bar"))
+	if err != ErrReadOnly {
+		t.Fatalf("expected read-only error, got %v", err)
+	}
+
+	v, err := store.Flags()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(v) != testDirFiles["pp.csv"] {
+		t.Fatalf("unexpected flags: %s", v)
+	}
+	v, err = fs.ReadFile(store.FS(), "locale/nor/default.po")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(v) != testDirFiles["locale/nor/default.po"] {
+		t.Fatalf("unexpected locale file: %s", v)
+	}
+}
+
+func TestBundleDump(t *testing.T) {
+	ctx := context.Background()
+	bb := NewBuilder("test")
+	err := bb.AddDir(writeTestDir(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewBundleDb()
+	err = store.Connect(ctx, writeTestBundle(t, bb))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close(ctx)
+
+	o, err := store.Dump(ctx, []byte{db.DATATYPE_TEMPLATE})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for k, _ := o.Next(ctx); k != nil; k, _ = o.Next(ctx) {
+		keys = append(keys, string(k[1:]))
+	}
+	if len(keys) != 2 || keys[0] != "root" || keys[1] != "root_nor" {
+		t.Fatalf("unexpected dump keys: %v", keys)
+	}
+}
+
+func TestBundleSignature(t *testing.T) {
+	ctx := context.Background()
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bb := NewBuilder("test")
+	err = bb.Add(db.DATATYPE_BIN, []byte("root"), nil, []byte{0x00, 0x01, 0x00, 0x00})
+	if err != nil {
+		t.Fatal(err)
+	}
+	unsigned := writeTestBundle(t, bb)
+	signed := writeTestBundle(t, bb.WithSigningKey(priv))
+
+	err = NewBundleDb().WithPublicKey(pub).Connect(ctx, signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = NewBundleDb().Connect(ctx, signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = NewBundleDb().Connect(ctx, unsigned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = NewBundleDb().WithPublicKey(otherPub).Connect(ctx, signed)
+	if !IsIntegrity(err) {
+		t.Fatalf("expected integrity error, got %v", err)
+	}
+	err = NewBundleDb().WithPublicKey(pub).Connect(ctx, unsigned)
+	if !IsIntegrity(err) {
+		t.Fatalf("expected integrity error, got %v", err)
+	}
+}
+
+func TestBundleTamper(t *testing.T) {
+	ctx := context.Background()
+	bb := NewBuilder("test")
+	err := bb.Add(db.DATATYPE_TEMPLATE, []byte("root"), nil, []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fp := writeTestBundle(t, bb)
+
+	// rewrite the bundle with a modified template, keeping the original manifest.
+	zr, err := zip.OpenReader(fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	for _, f := range zr.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		v, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.Name == "template/root" {
+			v = []byte("hullo")
+		}
+		w, err := zw.Create(f.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(v)
+	}
+	zr.Close()
+	zw.Close()
+	err = os.WriteFile(fp, b.Bytes(), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = NewBundleDb().Connect(ctx, fp)
+	if !IsIntegrity(err) {
+		t.Fatalf("expected integrity error, got %v", err)
+	}
+}
+
+func TestBundleDuplicate(t *testing.T) {
+	ctx := context.Background()
+	bb := NewBuilder("test")
+	err := bb.Add(db.DATATYPE_TEMPLATE, []byte("root"), nil, []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fp := writeTestBundle(t, bb)
+
+	// rewrite the bundle with a modified template inserted before the original.
+	zr, err := zip.OpenReader(fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	for _, f := range zr.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		v, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.Name == "template/root" {
+			w, err := zw.Create(f.Name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.Write([]byte("hullo"))
+		}
+		w, err := zw.Create(f.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(v)
+	}
+	zr.Close()
+	zw.Close()
+	err = os.WriteFile(fp, b.Bytes(), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = NewBundleDb().Connect(ctx, fp)
+	if !IsIntegrity(err) {
+		t.Fatalf("expected integrity error, got %v", err)
+	}
+}
+
+func TestBuilderAdd(t *testing.T) {
+	ln, err := lang.LanguageFromCode("nor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bb := NewBuilder("test")
+	err = bb.Add(db.DATATYPE_BIN, []byte("root"), &ln, []byte{})
+	if err == nil {
+		t.Fatal("expected error for translated bytecode")
+	}
+	err = bb.Add(db.DATATYPE_STATE, []byte("root"), nil, []byte{})
+	if err == nil {
+		t.Fatal("expected error for state datatype")
+	}
+	err = bb.Add(db.DATATYPE_TEMPLATE, []byte("root"), nil, []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = bb.Add(db.DATATYPE_TEMPLATE, []byte("root"), nil, []byte("hello"))
+	if err == nil {
+		t.Fatal("expected error for duplicate entry")
+	}
+}
@@ -0,0 +1,252 @@
+package bundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"slices"
+
+	"github.com/grassrootseconomics/go-vise/db"
+)
+
+// bundleDb is a read-only bundle backend implementation of the Db interface.
+type bundleDb struct {
+	*db.DbBase
+	zr         *zip.ReadCloser
+	pubKey     ed25519.PublicKey
+	manifest   Manifest
+	store      map[string]*zip.File
+	dumpIdx    int
+	dumpKeys   []string
+	dumpPrefix []byte
+}
+
+// NewBundleDb creates a Db implementation serving the contents of a bundle file.
+func NewBundleDb() *bundleDb {
+	return &bundleDb{
+		DbBase:  db.NewDbBase(),
+		dumpIdx: -1,
+	}
+}
+
+// WithPublicKey is a chainable function that sets the key used to verify the manifest signature.
+//
+// When set, Connect will fail with ErrIntegrity for bundles without a valid signature. When not set, the signature is not checked, and unsigned bundles are accepted.
+func (bdb *bundleDb) WithPublicKey(key ed25519.PublicKey) *bundleDb {
+	bdb.pubKey = key
+	return bdb
+}
+
+// Base implements Db
+func (bdb *bundleDb) Base() *db.DbBase {
+	return bdb.DbBase
+}
+
+// String implements the string interface.
+func (bdb *bundleDb) String() string {
+	return "bundledb: " + bdb.Connection()
+}
+
+func readEntry(f *zip.File) ([]byte, error) {
+	r, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// Connect implements Db.
+//
+// The connection string is the path to the bundle file. The content hash of the bundle is verified against the manifest, and the manifest signature if a public key has been set. Without a public key, the content hash only protects against corruption, not against a modified bundle with a recomputed manifest.
+//
+// Archives with more than one entry of the same name are rejected with ErrIntegrity.
+func (bdb *bundleDb) Connect(ctx context.Context, connStr string) error {
+	if bdb.zr != nil {
+		logg.WarnCtxf(ctx, "already connected", "conn", bdb.Connection())
+		return nil
+	}
+	zr, err := zip.OpenReader(connStr)
+	if err != nil {
+		return err
+	}
+	err = bdb.load(ctx, &zr.Reader)
+	if err != nil {
+		zr.Close()
+		return err
+	}
+	bdb.zr = zr
+	bdb.DbBase.Connect(ctx, connStr)
+	return nil
+}
+
+// verify and index the archive contents.
+func (bdb *bundleDb) load(ctx context.Context, zr *zip.Reader) error {
+	var mfv []byte
+	var sig []byte
+	var err error
+	content := make(map[string]*zip.File)
+	seen := make(map[string]bool)
+	for _, f := range zr.File {
+		if seen[f.Name] {
+			return NewErrIntegrity(fmt.Sprintf("duplicate entry: %s", f.Name))
+		}
+		seen[f.Name] = true
+		switch f.Name {
+		case MANIFEST_FILE:
+			mfv, err = readEntry(f)
+		case SIGNATURE_FILE:
+			sig, err = readEntry(f)
+		default:
+			if !f.FileInfo().IsDir() {
+				content[f.Name] = f
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if mfv == nil {
+		return NewErrIntegrity("missing manifest")
+	}
+	if bdb.pubKey != nil {
+		if sig == nil {
+			return NewErrIntegrity("missing signature")
+		}
+		if !ed25519.Verify(bdb.pubKey, mfv, sig) {
+			return NewErrIntegrity("invalid signature")
+		}
+	} else {
+		logg.DebugCtxf(ctx, "no public key set, skipping signature check")
+	}
+	err = json.Unmarshal(mfv, &bdb.manifest)
+	if err != nil {
+		return fmt.Errorf("manifest: %v", err)
+	}
+	if bdb.manifest.Format > FORMAT_VERSION {
+		return fmt.Errorf("unsupported bundle format version %d", bdb.manifest.Format)
+	}
+
+	ch := newContentHash()
+	bdb.store = make(map[string]*zip.File)
+	for _, s := range slices.Sorted(maps.Keys(content)) {
+		f := content[s]
+		v, err := readEntry(f)
+		if err != nil {
+			return err
+		}
+		ch.add(s, v)
+		k := entryKey(s)
+		if k != nil {
+			bdb.store[string(k)] = f
+		}
+	}
+	if ch.n != bdb.manifest.Entries || ch.String() != bdb.manifest.Hash {
+		return NewErrIntegrity("content hash mismatch")
+	}
+	logg.DebugCtxf(ctx, "bundle loaded", "name", bdb.manifest.Name, "version", bdb.manifest.Version, "entries", ch.n)
+	return nil
+}
+
+// Manifest returns the manifest of the connected bundle.
+func (bdb *bundleDb) Manifest() Manifest {
+	return bdb.manifest
+}
+
+// Flags returns the contents of the flag definitions file of the bundle, or nil if the bundle has no flag definitions.
+func (bdb *bundleDb) Flags() ([]byte, error) {
+	v, err := fs.ReadFile(bdb.zr, FLAGS_FILE)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return v, err
+}
+
+// FS returns the contents of the bundle as a read-only filesystem, e.g. to access the gettext files in LOCALE_DIR.
+func (bdb *bundleDb) FS() fs.FS {
+	return bdb.zr
+}
+
+// Get implements Db
+func (bdb *bundleDb) Get(ctx context.Context, key []byte) ([]byte, error) {
+	lk, err := bdb.ToKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	logg.TraceCtxf(ctx, "bundle get", "lk", lk)
+	for _, k := range [][]byte{lk.Translation, lk.Default} {
+		if k == nil {
+			continue
+		}
+		f, ok := bdb.store[string(k)]
+		if ok {
+			return readEntry(f)
+		}
+	}
+	return nil, db.NewErrNotFound(key)
+}
+
+// Put implements Db.
+//
+// Always fails with ErrReadOnly.
+func (bdb *bundleDb) Put(ctx context.Context, key []byte, val []byte) error {
+	return ErrReadOnly
+}
+
+// Close implements Db
+func (bdb *bundleDb) Close(ctx context.Context) error {
+	if bdb.zr == nil {
+		return nil
+	}
+	err := bdb.zr.Close()
+	bdb.zr = nil
+	return err
+}
+
+// Dump implements Db.
+func (bdb *bundleDb) Dump(ctx context.Context, key []byte) (*db.Dumper, error) {
+	bdb.dumpKeys = slices.Sorted(maps.Keys(bdb.store))
+	bdb.dumpIdx = -1
+	bdb.dumpPrefix = key
+	for i, s := range bdb.dumpKeys {
+		if bytes.HasPrefix([]byte(s), key) {
+			v, err := readEntry(bdb.store[s])
+			if err != nil {
+				return nil, err
+			}
+			logg.DebugCtxf(ctx, "starting dump", "key", s)
+			bdb.dumpIdx = i
+			return db.NewDumper(bdb.dumpFunc).WithFirst([]byte(s), v), nil
+		}
+	}
+	return nil, db.NewErrNotFound(key)
+}
+
+func (bdb *bundleDb) dumpFunc(ctx context.Context) ([]byte, []byte) {
+	if bdb.dumpIdx == -1 {
+		return nil, nil
+	}
+	bdb.dumpIdx += 1
+	if bdb.dumpIdx >= len(bdb.dumpKeys) {
+		bdb.dumpIdx = -1
+		return nil, nil
+	}
+	s := bdb.dumpKeys[bdb.dumpIdx]
+	if !bytes.HasPrefix([]byte(s), bdb.dumpPrefix) {
+		bdb.dumpIdx = -1
+		return nil, nil
+	}
+	v, err := readEntry(bdb.store[s])
+	if err != nil {
+		bdb.dumpIdx = -1
+		return nil, nil
+	}
+	return []byte(s), v
+}
@@ -0,0 +1,4 @@
+// Package bundle is a read-only implementation of the db.Db interface serving resources from a single-file application bundle.
+//
+// A bundle is a zip archive containing bytecode, templates, menus and static content for all languages of an application, together with the flag definitions and gettext locale files. A manifest records the name and version of the application, and a content hash of the archive entries. The manifest may be signed with an ed25519 key.
+package bundle
@@ -0,0 +1,9 @@
+package bundle
+
+import (
+	slogging "github.com/grassrootseconomics/go-vise/slog"
+)
+
+var (
+	logg = slogging.Get().With("component", "bundledb")
+)
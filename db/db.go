@@ -91,6 +91,22 @@ type Db interface {
 	Base() *DbBase
 }
 
+// Deleter is implemented by Db backends that support removal of stored values.
+type Deleter interface {
+	// Delete removes the value stored under a key.
+	//
+	// The key is resolved using the same storage context as Put.
+	//
+	// Errors with ErrNotFound if the key does not exist.
+	Delete(ctx context.Context, key []byte) error
+}
+
+// RawDumper is implemented by Db backends whose Dump matches and returns complete storage keys, including the leading datatype byte, instead of keys relative to the current datatype and session.
+type RawDumper interface {
+	// RawDump returns true if the keys of Dump are complete storage keys.
+	RawDump() bool
+}
+
 // LookupKey encapsulates two keys for a database entry; one for the default language, the other for the language in the context at which the LookupKey was generated.
 type LookupKey struct {
 	Default     []byte
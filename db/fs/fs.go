@@ -157,6 +157,33 @@ func (fdb *fsDb) Put(ctx context.Context, key []byte, val []byte) error {
 	return ioutil.WriteFile(flk.Default, val, 0600)
 }
 
+var _ db.Deleter = (*fsDb)(nil)
+
+// Delete implements the db.Deleter interface.
+func (fdb *fsDb) Delete(ctx context.Context, key []byte) error {
+	if !fdb.CheckPut() {
+		return errors.New("unsafe delete and safety set")
+	}
+	lk, err := fdb.ToKey(ctx, key)
+	if err != nil {
+		return err
+	}
+	flk, err := fdb.pathFor(ctx, &lk)
+	if err != nil {
+		return err
+	}
+	fp := flk.Default
+	if flk.Translation != "" {
+		fp = flk.Translation
+	}
+	logg.TraceCtxf(ctx, "fs delete", "key", key, "path", fp)
+	err = os.Remove(fp)
+	if errors.Is(err, fs.ErrNotExist) {
+		return db.NewErrNotFound(key)
+	}
+	return err
+}
+
 // Close implements the Db interface.
 func (fdb *fsDb) Close(ctx context.Context) error {
 	return nil
@@ -188,3 +188,35 @@ func TestNoKey(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestDeleteFs(t *testing.T) {
+	ctx := context.Background()
+	d, err := ioutil.TempDir("", "vise-db-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewFsDb()
+	store.SetPrefix(db.DATATYPE_USERDATA)
+	store.SetSession("ses")
+
+	err = store.Connect(ctx, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.Put(ctx, []byte("foo"), []byte("bar"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.Delete(ctx, []byte("foo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = store.Get(ctx, []byte("foo"))
+	if !db.IsNotFound(err) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	err = store.Delete(ctx, []byte("foo"))
+	if !db.IsNotFound(err) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
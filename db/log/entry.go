@@ -0,0 +1,166 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/grassrootseconomics/go-vise/db"
+)
+
+var (
+	// DatatypeName maps the datatypes recorded in a log entry to a human readable string.
+	DatatypeName = map[uint8]string{
+		db.DATATYPE_BIN:        "bin",
+		db.DATATYPE_MENU:       "menu",
+		db.DATATYPE_TEMPLATE:   "template",
+		db.DATATYPE_STATICLOAD: "staticload",
+		db.DATATYPE_STATE:      "state",
+		db.DATATYPE_USERDATA:   "userdata",
+	}
+)
+
+// DecodeLogEntry decodes a logdb entry as returned by a Dump of the log database.
+//
+// Unlike logDb.ToLogDbEntry, the decoding does not depend on the session context of any database, and can be used on entries of all sessions.
+//
+// The key may be given with or without the leading db.DATATYPE_UNKNOWN prefix byte.
+func DecodeLogEntry(key []byte, val []byte) (LogEntry, error) {
+	var e LogEntry
+
+	if len(key) < 8 {
+		return e, fmt.Errorf("log key too short: %x", key)
+	}
+	nsecs := binary.BigEndian.Uint64(key[len(key)-8:])
+	e.When = time.Unix(0, int64(nsecs))
+
+	sk := key[:len(key)-8]
+	if len(sk) > 0 && sk[0] == db.DATATYPE_UNKNOWN {
+		sk = sk[1:]
+	}
+	e.SessionId = string(bytes.TrimSuffix(sk, []byte{0x2E}))
+
+	l, c := binary.Uvarint(val)
+	if c <= 0 || uint64(len(val)-c) < l {
+		return e, fmt.Errorf("invalid log value for key %x", key)
+	}
+	lk := val[c : uint64(c)+l]
+	e.Val = val[uint64(c)+l:]
+
+	k, err := db.FromDbKey(lk)
+	if err != nil {
+		return e, err
+	}
+	e.Pfx = lk[0]
+	if len(k) < len(lk)-1 {
+		e.Lang = string(lk[len(k)+2:])
+	}
+	if e.Pfx > db.DATATYPE_STATICLOAD && e.SessionId != "" {
+		k = bytes.TrimPrefix(k, append([]byte(e.SessionId), 0x2E))
+	}
+	e.Key = k
+	return e, nil
+}
+
+// Filter selects log entries by their metadata.
+//
+// Zero values match any entry.
+type Filter struct {
+	// Only match entries of this session.
+	SessionId string
+	// Only match entries with datatypes in this bitmask.
+	Pfx uint8
+	// Only match entries recorded at or after this time.
+	Since time.Time
+	// Only match entries recorded before this time.
+	Until time.Time
+}
+
+// Match returns true if the log entry matches all criteria of the filter.
+func (f Filter) Match(e LogEntry) bool {
+	if f.SessionId != "" && f.SessionId != e.SessionId {
+		return false
+	}
+	if f.Pfx != 0 && f.Pfx&e.Pfx == 0 {
+		return false
+	}
+	if !f.Since.IsZero() && e.When.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !e.When.Before(f.Until) {
+		return false
+	}
+	return true
+}
+
+// Reader iterates the entries of a log database.
+type Reader struct {
+	store  db.Db
+	filter Filter
+	dumper *db.Dumper
+	done   bool
+}
+
+// NewReader creates a new Reader for the log database.
+//
+// The log database is the second argument given to NewLogDb, and may be used with any db.Db backend.
+func NewReader(store db.Db) *Reader {
+	store.Base().AllowUnknownPrefix()
+	return &Reader{
+		store: store,
+	}
+}
+
+// WithFilter is a chainable function that limits the entries returned by the reader.
+func (r *Reader) WithFilter(filter Filter) *Reader {
+	r.filter = filter
+	return r
+}
+
+// Next returns the next log entry matching the filter.
+//
+// When no more entries are available, nil is returned.
+func (r *Reader) Next(ctx context.Context) (*LogEntry, error) {
+	var err error
+	if r.done {
+		return nil, nil
+	}
+	if r.dumper == nil {
+		r.store.SetPrefix(db.DATATYPE_UNKNOWN)
+		r.store.SetSession("")
+		r.dumper, err = r.store.Dump(ctx, []byte{})
+		if err != nil {
+			r.done = true
+			if db.IsNotFound(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+	}
+	for {
+		k, v := r.dumper.Next(ctx)
+		if k == nil {
+			r.done = true
+			return nil, nil
+		}
+		e, err := DecodeLogEntry(k, v)
+		if err != nil {
+			return nil, err
+		}
+		if r.filter.Match(e) {
+			return &e, nil
+		}
+		logg.TraceCtxf(ctx, "skip log entry", "key", k)
+	}
+}
+
+// Close releases the resources held by the reader.
+func (r *Reader) Close() error {
+	r.done = true
+	if r.dumper != nil {
+		return r.dumper.Close()
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grassrootseconomics/go-vise/db"
+	"github.com/grassrootseconomics/go-vise/db/mem"
+)
+
+func TestLogReader(t *testing.T) {
+	ctx := context.Background()
+	main := mem.NewMemDb()
+	sub := mem.NewMemDb()
+	store := NewLogDb(main, sub)
+	err := store.Connect(ctx, "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store.SetPrefix(db.DATATYPE_USERDATA)
+	store.SetSession("foo")
+	err = store.Put(ctx, []byte("inky"), []byte("pinky"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmid := time.Now()
+	store.SetSession("bar")
+	err = store.Put(ctx, []byte("blinky"), []byte("clyde"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.SetPrefix(db.DATATYPE_STATE)
+	err = store.Put(ctx, []byte("bar"), []byte("sue"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var r []*LogEntry
+	rd := NewReader(sub)
+	for {
+		e, err := rd.Next(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e == nil {
+			break
+		}
+		r = append(r, e)
+	}
+	if len(r) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(r))
+	}
+
+	rd = NewReader(sub).WithFilter(Filter{
+		SessionId: "bar",
+		Pfx:       db.DATATYPE_USERDATA,
+	})
+	e, err := rd.Next(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e == nil {
+		t.Fatal("expected entry")
+	}
+	if !bytes.Equal(e.Key, []byte("blinky")) {
+		t.Fatalf("expected 'blinky', got '%s'", e.Key)
+	}
+	if !bytes.Equal(e.Val, []byte("clyde")) {
+		t.Fatalf("expected 'clyde', got '%s'", e.Val)
+	}
+	if e.SessionId != "bar" {
+		t.Fatalf("expected 'bar', got '%s'", e.SessionId)
+	}
+	e, err = rd.Next(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e != nil {
+		t.Fatalf("expected no more entries, got %v", e)
+	}
+
+	rd = NewReader(sub).WithFilter(Filter{
+		Until: tmid,
+	})
+	e, err = rd.Next(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e == nil || e.SessionId != "foo" {
+		t.Fatalf("expected entry from session 'foo', got %v", e)
+	}
+}
+
+func TestLogDecodeTranslation(t *testing.T) {
+	lk := append([]byte{db.DATATYPE_TEMPLATE}, []byte("root_nor")...)
+	v := append([]byte{byte(len(lk))}, lk...)
+	v = append(v, []byte("hei")...)
+	k := append([]byte{db.DATATYPE_UNKNOWN}, []byte("xyzzy.")...)
+	k = append(k, []byte{0x17, 0, 0, 0, 0, 0, 0, 0x2a}...)
+	e, err := DecodeLogEntry(k, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(e.Key, []byte("root")) {
+		t.Fatalf("expected 'root', got '%s'", e.Key)
+	}
+	if e.Lang != "nor" {
+		t.Fatalf("expected 'nor', got '%s'", e.Lang)
+	}
+	if e.SessionId != "xyzzy" {
+		t.Fatalf("expected 'xyzzy', got '%s'", e.SessionId)
+	}
+	if e.Pfx != db.DATATYPE_TEMPLATE {
+		t.Fatalf("expected %d, got %d", db.DATATYPE_TEMPLATE, e.Pfx)
+	}
+}
@@ -14,18 +14,20 @@ var (
 	logg = slogging.Get().With("component", "logdb")
 )
 
-// TODO: add a formatted dumper for the logdb
 type logDb struct {
 	db.Db
 	logDb db.Db
 }
 
+// LogEntry is a decoded logdb entry.
 type LogEntry struct {
 	Key       []byte
 	Val       []byte
 	SessionId string
 	When      time.Time
 	Pfx       uint8
+	// Language code of translated entries. Empty if not translated.
+	Lang string
 }
 
 // NewLogDb creates a wrapper for the main Db in the first argument, which write an entry for every Put to the second database.
@@ -101,6 +103,12 @@ func (ldb *logDb) Base() *db.DbBase {
 	return ldb.Db.Base()
 }
 
+// RawDump implements db.RawDumper, reporting the dump key format of the main Db.
+func (ldb *logDb) RawDump() bool {
+	rd, ok := ldb.Db.(db.RawDumper)
+	return ok && rd.RawDump()
+}
+
 // create the chronological logentry key to store the put under.
 func (ldb *logDb) toLogDbEntry(ctx context.Context, key []byte, val []byte) ([]byte, []byte) {
 	var innerKey []byte
@@ -0,0 +1,333 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+
+	"github.com/grassrootseconomics/go-vise/db"
+)
+
+var (
+	summaryEncoder cbor.EncMode
+)
+
+func init() {
+	var err error
+	summaryEncoder, err = cbor.EncOptions{Time: cbor.TimeRFC3339Nano}.EncMode()
+	if err != nil {
+		panic(err)
+	}
+}
+
+var (
+	// SummaryKey is the key of the inner entry of a compacted session summary record.
+	SummaryKey = []byte("_summary")
+)
+
+// Retention defines which entries of a log database will be removed by Prune.
+//
+// Zero values disable the respective limit.
+//
+// None of the limits remove summary records. A summary record is only replaced when compaction merges it with newly removed entries of the same session.
+type Retention struct {
+	// Remove entries older than this.
+	//
+	// Summary records are not removed.
+	MaxAge time.Duration
+	// Keep at most this many entries per session, removing the oldest first.
+	//
+	// Summary records are not counted.
+	MaxEntries int
+	// Keep at most this many bytes of keys and values in total, removing the oldest first.
+	//
+	// Summary records are counted, but will not be removed.
+	MaxSize int
+	// Replace the removed entries of each session with a single summary record.
+	Compact bool
+}
+
+// PruneStats reports the result of a Prune.
+type PruneStats struct {
+	// Number of entries removed, including summary records replaced by compaction.
+	Entries int
+	// Cumulative byte size of keys and values removed.
+	Size int
+	// Number of summary records written.
+	Summaries int
+}
+
+// String implements the String interface.
+func (s PruneStats) String() string {
+	return fmt.Sprintf("entries: %d size: %d summaries: %d", s.Entries, s.Size, s.Summaries)
+}
+
+// SummaryItem is the last value recorded for a single key in a compacted session history.
+type SummaryItem struct {
+	Pfx  uint8
+	Key  []byte
+	Lang string `cbor:",omitempty"`
+	Val  []byte
+}
+
+// Summary is the decoded value of a compacted session history.
+type Summary struct {
+	// Time of the oldest compacted entry.
+	From time.Time
+	// Time of the newest compacted entry.
+	To time.Time
+	// Number of compacted entries.
+	Count int
+	// Last value of each compacted key, sorted by datatype and key.
+	Last []SummaryItem
+}
+
+// IsSummary returns true if the log entry is a compacted session summary record.
+func (e LogEntry) IsSummary() bool {
+	return e.Pfx == db.DATATYPE_UNKNOWN && string(e.Key) == string(SummaryKey)
+}
+
+// DecodeSummary decodes the value of a summary record.
+func DecodeSummary(v []byte) (Summary, error) {
+	var sm Summary
+	err := cbor.Unmarshal(v, &sm)
+	return sm, err
+}
+
+type pruneEntry struct {
+	LogEntry
+	size   int
+	pruned bool
+}
+
+// add an entry to the summary. entries must be added in chronological order.
+func (sm *Summary) add(e LogEntry, idx map[string]int) error {
+	if e.IsSummary() {
+		old, err := DecodeSummary(e.Val)
+		if err != nil {
+			return err
+		}
+		if sm.Count == 0 || old.From.Before(sm.From) {
+			sm.From = old.From
+		}
+		if old.To.After(sm.To) {
+			sm.To = old.To
+		}
+		sm.Count += old.Count
+		for _, v := range old.Last {
+			sm.set(v, idx)
+		}
+		return nil
+	}
+	if sm.Count == 0 || e.When.Before(sm.From) {
+		sm.From = e.When
+	}
+	if e.When.After(sm.To) {
+		sm.To = e.When
+	}
+	sm.Count += 1
+	sm.set(SummaryItem{
+		Pfx:  e.Pfx,
+		Key:  e.Key,
+		Lang: e.Lang,
+		Val:  e.Val,
+	}, idx)
+	return nil
+}
+
+func (sm *Summary) set(v SummaryItem, idx map[string]int) {
+	k := fmt.Sprintf("%d.%x.%s", v.Pfx, v.Key, v.Lang)
+	i, ok := idx[k]
+	if ok {
+		sm.Last[i] = v
+		return
+	}
+	idx[k] = len(sm.Last)
+	sm.Last = append(sm.Last, v)
+}
+
+// Prune removes the entries of the log database that fall outside the retention policy.
+//
+// The log database is the second argument given to NewLogDb, and its backend must implement db.Deleter.
+//
+// The now argument is the reference time for the MaxAge limit.
+//
+// If the policy enables compaction, the removed entries of each session are merged with any existing summary record of the same session, and stored as a single summary record under the time of the newest removed entry. The inner key of the summary record is SummaryKey with the db.DATATYPE_UNKNOWN datatype. The summary record of a session is written before its entries are deleted, so that an interrupted Prune never loses history.
+//
+// All entries of the log database are read into memory before any are removed, since the MaxSize limit applies across sessions. Memory use is therefore bounded by the size of the log database, and Prune should be run often enough to keep it small.
+func Prune(ctx context.Context, store db.Db, policy Retention, now time.Time) (PruneStats, error) {
+	var stats PruneStats
+	var entries []*pruneEntry
+	var total int
+
+	dl, ok := store.(db.Deleter)
+	if !ok {
+		return stats, fmt.Errorf("log db does not support delete")
+	}
+
+	rd := NewReader(store)
+	for {
+		e, err := rd.Next(ctx)
+		if err != nil {
+			rd.Close()
+			return stats, err
+		}
+		if e == nil {
+			break
+		}
+		pe := &pruneEntry{
+			LogEntry: *e,
+			size:     len(e.Key) + len(e.Val),
+		}
+		total += pe.size
+		entries = append(entries, pe)
+	}
+	rd.Close()
+	sort.SliceStable(entries, func(i int, j int) bool {
+		return entries[i].When.Before(entries[j].When)
+	})
+
+	if policy.MaxAge > 0 {
+		limit := now.Add(-policy.MaxAge)
+		for _, pe := range entries {
+			if pe.When.Before(limit) && !pe.IsSummary() {
+				pe.pruned = true
+			}
+		}
+	}
+
+	if policy.MaxEntries > 0 {
+		counts := make(map[string]int)
+		for i := len(entries) - 1; i >= 0; i-- {
+			pe := entries[i]
+			if pe.pruned || pe.IsSummary() {
+				continue
+			}
+			counts[pe.SessionId] += 1
+			if counts[pe.SessionId] > policy.MaxEntries {
+				pe.pruned = true
+			}
+		}
+	}
+
+	if policy.MaxSize > 0 {
+		for _, pe := range entries {
+			if pe.pruned {
+				total -= pe.size
+			}
+		}
+		for _, pe := range entries {
+			if total <= policy.MaxSize {
+				break
+			}
+			if pe.pruned || pe.IsSummary() {
+				continue
+			}
+			pe.pruned = true
+			total -= pe.size
+		}
+	}
+
+	if policy.Compact {
+		compacted := make(map[string]bool)
+		for _, pe := range entries {
+			if pe.pruned && !pe.IsSummary() {
+				compacted[pe.SessionId] = true
+			}
+		}
+		for _, pe := range entries {
+			if pe.IsSummary() && compacted[pe.SessionId] {
+				pe.pruned = true
+			}
+		}
+	}
+
+	var sessions []string
+	pruned := make(map[string][]*pruneEntry)
+	for _, pe := range entries {
+		if !pe.pruned {
+			continue
+		}
+		_, ok := pruned[pe.SessionId]
+		if !ok {
+			sessions = append(sessions, pe.SessionId)
+		}
+		pruned[pe.SessionId] = append(pruned[pe.SessionId], pe)
+	}
+
+	for _, sessionId := range sessions {
+		var summaryKey []byte
+		store.SetPrefix(db.DATATYPE_UNKNOWN)
+		store.SetSession(sessionId)
+		if policy.Compact {
+			sm, err := summarize(pruned[sessionId])
+			if err != nil {
+				return stats, fmt.Errorf("summary decode fail for session %s: %v", sessionId, err)
+			}
+			v, err := summaryEncoder.Marshal(sm)
+			if err != nil {
+				return stats, err
+			}
+			summaryKey = toTimeKey(sm.To)
+			err = store.Put(ctx, summaryKey, toLogValue(db.ToDbKey(db.DATATYPE_UNKNOWN, SummaryKey, nil), v))
+			if err != nil {
+				return stats, err
+			}
+			logg.DebugCtxf(ctx, "compacted log entries", "session", sessionId, "count", sm.Count)
+			stats.Summaries += 1
+		}
+		for _, pe := range pruned[sessionId] {
+			k := toTimeKey(pe.When)
+			// the summary record has already replaced the entry under its key.
+			if !bytes.Equal(k, summaryKey) {
+				err := dl.Delete(ctx, k)
+				if err != nil {
+					return stats, err
+				}
+			}
+			logg.TraceCtxf(ctx, "pruned log entry", "session", sessionId, "time", pe.When)
+			stats.Entries += 1
+			stats.Size += pe.size
+		}
+	}
+	return stats, nil
+}
+
+// merge the removed entries of a session into a single summary.
+//
+// entries must be in chronological order.
+func summarize(entries []*pruneEntry) (*Summary, error) {
+	sm := &Summary{}
+	idx := make(map[string]int)
+	for _, pe := range entries {
+		err := sm.add(pe.LogEntry, idx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.SliceStable(sm.Last, func(i int, j int) bool {
+		if sm.Last[i].Pfx != sm.Last[j].Pfx {
+			return sm.Last[i].Pfx < sm.Last[j].Pfx
+		}
+		return string(sm.Last[i].Key) < string(sm.Last[j].Key)
+	})
+	return sm, nil
+}
+
+func toTimeKey(t time.Time) []byte {
+	k := make([]byte, 8)
+	binary.BigEndian.PutUint64(k, uint64(t.UnixNano()))
+	return k
+}
+
+func toLogValue(k []byte, v []byte) []byte {
+	l := make([]byte, 8)
+	c := binary.PutUvarint(l, uint64(len(k)))
+	r := append(l[:c], k...)
+	return append(r, v...)
+}
@@ -0,0 +1,234 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grassrootseconomics/go-vise/db"
+	"github.com/grassrootseconomics/go-vise/db/mem"
+)
+
+// failPutDb is a log db backend whose writes always fail.
+type failPutDb struct {
+	db.Db
+}
+
+func (fdb *failPutDb) Put(ctx context.Context, key []byte, val []byte) error {
+	return errors.New("put fail")
+}
+
+func (fdb *failPutDb) Delete(ctx context.Context, key []byte) error {
+	return fdb.Db.(db.Deleter).Delete(ctx, key)
+}
+
+func readAll(ctx context.Context, t *testing.T, store db.Db) []*LogEntry {
+	var r []*LogEntry
+	rd := NewReader(store)
+	defer rd.Close()
+	for {
+		e, err := rd.Next(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e == nil {
+			return r
+		}
+		r = append(r, e)
+	}
+}
+
+func TestLogPrune(t *testing.T) {
+	ctx := context.Background()
+	main := mem.NewMemDb()
+	sub := mem.NewMemDb()
+	store := NewLogDb(main, sub)
+	err := store.Connect(ctx, "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store.SetPrefix(db.DATATYPE_USERDATA)
+	store.SetSession("foo")
+	for _, v := range []string{"inky", "pinky", "blinky"} {
+		err = store.Put(ctx, []byte("ghost"), []byte(v))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	store.SetSession("bar")
+	err = store.Put(ctx, []byte("ghost"), []byte("clyde"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := Prune(ctx, sub, Retention{MaxEntries: 1}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Entries != 2 || stats.Summaries != 0 {
+		t.Fatalf("unexpected stats: %s", stats)
+	}
+	r := readAll(ctx, t, sub)
+	if len(r) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(r))
+	}
+	for _, e := range r {
+		if e.SessionId == "foo" && !bytes.Equal(e.Val, []byte("blinky")) {
+			t.Fatalf("expected newest entry to be kept, got '%s'", e.Val)
+		}
+	}
+
+	stats, err = Prune(ctx, sub, Retention{MaxAge: time.Hour}, time.Now().Add(time.Hour*2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Entries != 2 {
+		t.Fatalf("unexpected stats: %s", stats)
+	}
+	r = readAll(ctx, t, sub)
+	if len(r) != 0 {
+		t.Fatalf("expected no entries, got %d", len(r))
+	}
+}
+
+func TestLogPruneCompact(t *testing.T) {
+	ctx := context.Background()
+	main := mem.NewMemDb()
+	sub := mem.NewMemDb()
+	store := NewLogDb(main, sub)
+	err := store.Connect(ctx, "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store.SetPrefix(db.DATATYPE_USERDATA)
+	store.SetSession("foo")
+	for _, v := range []string{"inky", "pinky", "blinky", "clyde"} {
+		err = store.Put(ctx, []byte("ghost"), []byte(v))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = store.Put(ctx, []byte("pacman"), []byte("waka"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	policy := Retention{
+		MaxEntries: 3,
+		Compact:    true,
+	}
+	stats, err := Prune(ctx, sub, policy, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Entries != 2 || stats.Summaries != 1 {
+		t.Fatalf("unexpected stats: %s", stats)
+	}
+
+	policy.MaxEntries = 1
+	stats, err = Prune(ctx, sub, policy, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Entries != 3 || stats.Summaries != 1 {
+		t.Fatalf("unexpected stats: %s", stats)
+	}
+
+	r := readAll(ctx, t, sub)
+	if len(r) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(r))
+	}
+	var sm Summary
+	for _, e := range r {
+		if e.IsSummary() {
+			sm, err = DecodeSummary(e.Val)
+			if err != nil {
+				t.Fatal(err)
+			}
+		} else if !bytes.Equal(e.Key, []byte("pacman")) {
+			t.Fatalf("expected newest entry to be kept, got '%s'", e.Key)
+		}
+	}
+	if sm.Count != 4 {
+		t.Fatalf("expected 4 compacted entries, got %d", sm.Count)
+	}
+	if len(sm.Last) != 1 || !bytes.Equal(sm.Last[0].Val, []byte("clyde")) {
+		t.Fatalf("unexpected summary values: %v", sm.Last)
+	}
+	if !sm.From.Before(sm.To) {
+		t.Fatalf("invalid summary range %v - %v", sm.From, sm.To)
+	}
+}
+
+func TestLogPruneSummaryAge(t *testing.T) {
+	ctx := context.Background()
+	main := mem.NewMemDb()
+	sub := mem.NewMemDb()
+	store := NewLogDb(main, sub)
+	err := store.Connect(ctx, "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store.SetPrefix(db.DATATYPE_USERDATA)
+	store.SetSession("foo")
+	for _, v := range []string{"inky", "pinky"} {
+		err = store.Put(ctx, []byte("ghost"), []byte(v))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats, err := Prune(ctx, sub, Retention{MaxEntries: 1, Compact: true}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Entries != 1 || stats.Summaries != 1 {
+		t.Fatalf("unexpected stats: %s", stats)
+	}
+
+	stats, err = Prune(ctx, sub, Retention{MaxAge: time.Hour}, time.Now().Add(time.Hour*2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Entries != 1 || stats.Summaries != 0 {
+		t.Fatalf("unexpected stats: %s", stats)
+	}
+	r := readAll(ctx, t, sub)
+	if len(r) != 1 || !r[0].IsSummary() {
+		t.Fatalf("expected summary record to be kept, got %v", r)
+	}
+}
+
+func TestLogPruneCompactFail(t *testing.T) {
+	ctx := context.Background()
+	main := mem.NewMemDb()
+	sub := mem.NewMemDb()
+	store := NewLogDb(main, sub)
+	err := store.Connect(ctx, "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store.SetPrefix(db.DATATYPE_USERDATA)
+	store.SetSession("foo")
+	for _, v := range []string{"inky", "pinky", "blinky"} {
+		err = store.Put(ctx, []byte("ghost"), []byte(v))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err = Prune(ctx, &failPutDb{Db: sub}, Retention{MaxEntries: 1, Compact: true}, time.Now())
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	r := readAll(ctx, t, sub)
+	if len(r) != 3 {
+		t.Fatalf("expected entries to be kept when summary write fails, got %d", len(r))
+	}
+}
@@ -0,0 +1,187 @@
+package lru
+
+import (
+	"bytes"
+	"container/list"
+	"fmt"
+	"sync"
+)
+
+// Stats is a snapshot of the usage counters of a Cache.
+type Stats struct {
+	// Number of lookups served from the cache.
+	Hits uint64
+	// Number of lookups passed on to the wrapped database.
+	Misses uint64
+	// Number of entries removed to satisfy the size bounds.
+	Evictions uint64
+	// Number of entries currently in the cache.
+	Entries int
+	// Cumulative byte size of all values currently in the cache.
+	Size int
+}
+
+// String implements the String interface.
+func (s Stats) String() string {
+	return fmt.Sprintf("hits: %d misses: %d evictions: %d entries: %d size: %d", s.Hits, s.Misses, s.Evictions, s.Entries, s.Size)
+}
+
+type entry struct {
+	k     string
+	group string
+	v     []byte
+}
+
+// Cache is a size bounded least-recently-used store of database values.
+//
+// It is safe for concurrent use. Keys are not namespaced by the Cache itself; see NewLruDb for sharing a single instance between several wrapped databases.
+type Cache struct {
+	mu         sync.Mutex
+	ll         *list.List
+	items      map[string]*list.Element
+	groups     map[string]map[string]bool
+	maxEntries int
+	maxSize    int
+	size       int
+	stats      Stats
+}
+
+// NewCache creates a new Cache holding at most maxEntries values.
+//
+// If maxEntries is 0, the number of entries is not limited.
+func NewCache(maxEntries int) *Cache {
+	return &Cache{
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+		groups:     make(map[string]map[string]bool),
+		maxEntries: maxEntries,
+	}
+}
+
+// WithMaxSize is a chainable function that limits the cumulative byte size of all cached values.
+//
+// Values larger than the limit will never be cached.
+func (c *Cache) WithMaxSize(maxSize int) *Cache {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxSize = maxSize
+	c.prune()
+	return c
+}
+
+// Get retrieves a copy of the value cached under the key, and marks it as most recently used.
+func (c *Cache) Get(k []byte) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[string(k)]
+	if !ok {
+		c.stats.Misses += 1
+		return nil, false
+	}
+	c.stats.Hits += 1
+	c.ll.MoveToFront(el)
+	return bytes.Clone(el.Value.(*entry).v), true
+}
+
+// Add caches a copy of the value under the key, replacing any existing value.
+//
+// The group associates the key with other keys that must be invalidated together with it, e.g. all translations of the same symbol.
+//
+// Least recently used entries will be evicted until the size bounds are satisfied.
+func (c *Cache) Add(group []byte, k []byte, v []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.maxSize > 0 && len(v) > c.maxSize {
+		logg.Debugf("value too large for cache", "key", k, "size", len(v), "max", c.maxSize)
+		c.remove(string(k))
+		return
+	}
+	v = bytes.Clone(v)
+	el, ok := c.items[string(k)]
+	if ok {
+		e := el.Value.(*entry)
+		c.size += len(v) - len(e.v)
+		e.v = v
+		c.ll.MoveToFront(el)
+	} else {
+		el = c.ll.PushFront(&entry{k: string(k), group: string(group), v: v})
+		c.items[string(k)] = el
+		c.size += len(v)
+		g, ok := c.groups[string(group)]
+		if !ok {
+			g = make(map[string]bool)
+			c.groups[string(group)] = g
+		}
+		g[string(k)] = true
+	}
+	c.prune()
+}
+
+// Invalidate removes all values cached in the given group.
+//
+// Returns the number of entries removed.
+func (c *Cache) Invalidate(group []byte) int {
+	var r int
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k := range c.groups[string(group)] {
+		if c.remove(k) {
+			r += 1
+		}
+	}
+	return r
+}
+
+// Reset removes all values from the cache.
+//
+// Usage counters are not affected.
+func (c *Cache) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ll.Init()
+	c.items = make(map[string]*list.Element)
+	c.groups = make(map[string]map[string]bool)
+	c.size = 0
+}
+
+// Stats returns a snapshot of the current usage counters.
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := c.stats
+	s.Entries = c.ll.Len()
+	s.Size = c.size
+	return s
+}
+
+// remove a single entry. caller must hold the lock.
+func (c *Cache) remove(k string) bool {
+	el, ok := c.items[k]
+	if !ok {
+		return false
+	}
+	e := el.Value.(*entry)
+	c.ll.Remove(el)
+	delete(c.items, k)
+	g := c.groups[e.group]
+	delete(g, k)
+	if len(g) == 0 {
+		delete(c.groups, e.group)
+	}
+	c.size -= len(e.v)
+	return true
+}
+
+// evict least recently used entries until within bounds. caller must hold the lock.
+func (c *Cache) prune() {
+	for c.ll.Len() > 0 {
+		if (c.maxEntries == 0 || c.ll.Len() <= c.maxEntries) && (c.maxSize == 0 || c.size <= c.maxSize) {
+			return
+		}
+		el := c.ll.Back()
+		e := el.Value.(*entry)
+		c.remove(e.k)
+		c.stats.Evictions += 1
+		logg.Tracef("evicted", "key", []byte(e.k))
+	}
+}
@@ -0,0 +1,2 @@
+// Package lru provides a read-through caching wrapper for the db.Db interface, limited to the datatypes that are immutable in the vm context.
+package lru
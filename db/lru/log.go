@@ -0,0 +1,9 @@
+package lru
+
+import (
+	slogging "github.com/grassrootseconomics/go-vise/slog"
+)
+
+var (
+	logg = slogging.Get().With("component", "lrudb")
+)
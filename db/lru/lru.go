@@ -0,0 +1,148 @@
+package lru
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"sync/atomic"
+
+	"github.com/grassrootseconomics/go-vise/db"
+)
+
+const (
+	// Datatypes that are cached by default.
+	DefaultTypes = db.DATATYPE_BIN | db.DATATYPE_MENU | db.DATATYPE_TEMPLATE | db.DATATYPE_STATICLOAD
+)
+
+var (
+	// source of cache key namespaces, unique per wrapper.
+	lastNamespace atomic.Uint32
+)
+
+// lruDb wraps a db.Db, serving repeated Get calls on immutable datatypes from an in-process Cache.
+type lruDb struct {
+	db.Db
+	ca   *Cache
+	typs uint8
+	ns   []byte
+}
+
+// NewLruDb creates a read-through caching wrapper for the main Db in the first argument, using the Cache in the second argument.
+//
+// All interface methods operate like normal on the main Db, except Get on the datatypes in DefaultTypes. Those will be served from the cache if present, and added to the cache if not.
+//
+// Values are cached under the storage key for the language in effect at the time of the Get. Errors, including db.ErrNotFound, are never cached.
+//
+// The same Cache may be shared between several wrappers. Each wrapper keeps its values in a separate namespace of the cache, so wrappers of different databases never serve each other's values.
+//
+// A Put to a cached datatype through the wrapper invalidates the default and all translated values for the key.
+func NewLruDb(mainDb db.Db, ca *Cache) *lruDb {
+	if ca == nil {
+		ca = NewCache(0)
+	}
+	return &lruDb{
+		Db:   mainDb,
+		ca:   ca,
+		typs: DefaultTypes,
+		ns:   binary.BigEndian.AppendUint32(nil, lastNamespace.Add(1)),
+	}
+}
+
+// WithTypes is a chainable function that sets which datatypes will be cached.
+//
+// Only datatypes in DefaultTypes can be cached. Any other datatype in the argument will be ignored.
+func (ldb *lruDb) WithTypes(typs uint8) *lruDb {
+	ldb.typs = typs & DefaultTypes
+	return ldb
+}
+
+// Cache returns the cache used by the wrapper.
+func (ldb *lruDb) Cache() *Cache {
+	return ldb.ca
+}
+
+// Stats returns a snapshot of the usage counters of the cache.
+func (ldb *lruDb) Stats() Stats {
+	return ldb.ca.Stats()
+}
+
+// RawDump implements db.RawDumper, reporting the dump key format of the main Db.
+func (ldb *lruDb) RawDump() bool {
+	rd, ok := ldb.Db.(db.RawDumper)
+	return ok && rd.RawDump()
+}
+
+// Base implements Db.
+func (ldb *lruDb) Base() *db.DbBase {
+	return ldb.Db.Base()
+}
+
+// true if the currently selected datatype is cached.
+func (ldb *lruDb) cached() bool {
+	pfx := ldb.Prefix()
+	return pfx != db.DATATYPE_UNKNOWN && pfx&ldb.typs == pfx
+}
+
+// resolve the cache key and invalidation group for the key in the current storage context.
+func (ldb *lruDb) toCacheKey(ctx context.Context, key []byte) ([]byte, []byte, error) {
+	lk, err := ldb.Base().ToKey(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	group := append(bytes.Clone(ldb.ns), lk.Default...)
+	if lk.Translation != nil {
+		return group, append(bytes.Clone(ldb.ns), lk.Translation...), nil
+	}
+	return group, group, nil
+}
+
+// Get implements Db.
+func (ldb *lruDb) Get(ctx context.Context, key []byte) ([]byte, error) {
+	if !ldb.cached() {
+		return ldb.Db.Get(ctx, key)
+	}
+	group, k, err := ldb.toCacheKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	v, ok := ldb.ca.Get(k)
+	if ok {
+		logg.TraceCtxf(ctx, "lru hit", "key", k)
+		return v, nil
+	}
+	v, err = ldb.Db.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	logg.TraceCtxf(ctx, "lru miss", "key", k)
+	ldb.ca.Add(group, k, v)
+	return v, nil
+}
+
+// Put implements Db.
+func (ldb *lruDb) Put(ctx context.Context, key []byte, val []byte) error {
+	err := ldb.Db.Put(ctx, key, val)
+	if err != nil {
+		return err
+	}
+	if ldb.cached() {
+		return ldb.Invalidate(ctx, key)
+	}
+	return nil
+}
+
+// Invalidate removes the default and all translated values cached for the key under the currently selected datatype.
+func (ldb *lruDb) Invalidate(ctx context.Context, key []byte) error {
+	group, _, err := ldb.toCacheKey(ctx, key)
+	if err != nil {
+		return err
+	}
+	c := ldb.ca.Invalidate(group)
+	logg.DebugCtxf(ctx, "lru invalidate", "key", key, "count", c)
+	return nil
+}
+
+// Reset removes all values from the cache.
+func (ldb *lruDb) Reset() {
+	ldb.ca.Reset()
+}
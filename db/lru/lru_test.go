@@ -0,0 +1,235 @@
+package lru
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/grassrootseconomics/go-vise/db"
+	"github.com/grassrootseconomics/go-vise/db/mem"
+	"github.com/grassrootseconomics/go-vise/lang"
+)
+
+func TestLruCacheBounds(t *testing.T) {
+	ca := NewCache(2)
+	ca.Add([]byte("foo"), []byte("foo"), []byte("inky"))
+	ca.Add([]byte("bar"), []byte("bar"), []byte("pinky"))
+	_, ok := ca.Get([]byte("foo"))
+	if !ok {
+		t.Fatal("expected hit")
+	}
+	ca.Add([]byte("baz"), []byte("baz"), []byte("blinky"))
+	_, ok = ca.Get([]byte("bar"))
+	if ok {
+		t.Fatal("expected least recently used entry to be evicted")
+	}
+	stats := ca.Stats()
+	if stats.Hits != 1 || stats.Misses != 1 || stats.Evictions != 1 || stats.Entries != 2 {
+		t.Fatalf("unexpected stats: %s", stats)
+	}
+	if stats.Size != 10 {
+		t.Fatalf("expected size 10, got %d", stats.Size)
+	}
+
+	ca = NewCache(0).WithMaxSize(8)
+	ca.Add([]byte("foo"), []byte("foo"), []byte("inky"))
+	ca.Add([]byte("bar"), []byte("bar"), []byte("pinky"))
+	_, ok = ca.Get([]byte("foo"))
+	if ok {
+		t.Fatal("expected entry to be evicted by size")
+	}
+	ca.Add([]byte("baz"), []byte("baz"), []byte("tinkywinky"))
+	_, ok = ca.Get([]byte("baz"))
+	if ok {
+		t.Fatal("expected oversize value not to be cached")
+	}
+}
+
+func TestLruCacheCopy(t *testing.T) {
+	ca := NewCache(0)
+	v := []byte("inky")
+	ca.Add([]byte("foo"), []byte("foo"), v)
+	v[0] = 'p'
+	r, _ := ca.Get([]byte("foo"))
+	if !bytes.Equal(r, []byte("inky")) {
+		t.Fatalf("expected 'inky', got '%s'", r)
+	}
+	r[0] = 'p'
+	r, _ = ca.Get([]byte("foo"))
+	if !bytes.Equal(r, []byte("inky")) {
+		t.Fatalf("expected 'inky', got '%s'", r)
+	}
+}
+
+func TestLruDbGet(t *testing.T) {
+	ctx := context.Background()
+	main := mem.NewMemDb()
+	err := main.Connect(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewLruDb(main, NewCache(16))
+
+	store.SetLock(db.DATATYPE_TEMPLATE, false)
+	store.SetPrefix(db.DATATYPE_TEMPLATE)
+	err = store.Put(ctx, []byte("foo"), []byte("inky"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		v, err := store.Get(ctx, []byte("foo"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(v, []byte("inky")) {
+			t.Fatalf("expected 'inky', got '%s'", v)
+		}
+	}
+	stats := store.Stats()
+	if stats.Hits != 1 || stats.Misses != 1 {
+		t.Fatalf("unexpected stats: %s", stats)
+	}
+
+	// the cache must not hide changes made through the wrapper.
+	err = store.Put(ctx, []byte("foo"), []byte("pinky"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := store.Get(ctx, []byte("foo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(v, []byte("pinky")) {
+		t.Fatalf("expected 'pinky', got '%s'", v)
+	}
+
+	// mutable datatypes pass straight through.
+	store.SetPrefix(db.DATATYPE_USERDATA)
+	err = store.Put(ctx, []byte("bar"), []byte("blinky"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = store.Get(ctx, []byte("bar"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.Stats().Entries != 1 {
+		t.Fatalf("expected 1 entry, got %d", store.Stats().Entries)
+	}
+}
+
+func TestLruDbLanguage(t *testing.T) {
+	ctx := context.Background()
+	main := mem.NewMemDb()
+	err := main.Connect(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	main.SetLock(db.DATATYPE_TEMPLATE, false)
+	main.SetPrefix(db.DATATYPE_TEMPLATE)
+	err = main.Put(ctx, []byte("foo"), []byte("inky"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln, err := lang.LanguageFromCode("nor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	main.SetLanguage(&ln)
+	err = main.Put(ctx, []byte("foo"), []byte("pinky"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	main.SetLanguage(nil)
+	main.SetLock(db.DATATYPE_TEMPLATE, true)
+
+	store := NewLruDb(main, nil)
+	store.SetPrefix(db.DATATYPE_TEMPLATE)
+	v, err := store.Get(ctx, []byte("foo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(v, []byte("inky")) {
+		t.Fatalf("expected 'inky', got '%s'", v)
+	}
+	nctx := context.WithValue(ctx, "Language", ln)
+	v, err = store.Get(nctx, []byte("foo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(v, []byte("pinky")) {
+		t.Fatalf("expected 'pinky', got '%s'", v)
+	}
+	if store.Stats().Entries != 2 {
+		t.Fatalf("expected 2 entries, got %d", store.Stats().Entries)
+	}
+
+	err = store.Invalidate(ctx, []byte("foo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.Stats().Entries != 0 {
+		t.Fatalf("expected all translations invalidated, got %d entries", store.Stats().Entries)
+	}
+}
+
+func TestLruDbNotFound(t *testing.T) {
+	ctx := context.Background()
+	main := mem.NewMemDb()
+	err := main.Connect(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewLruDb(main, nil)
+	store.SetPrefix(db.DATATYPE_MENU)
+	_, err = store.Get(ctx, []byte("foo"))
+	if !db.IsNotFound(err) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if store.Stats().Entries != 0 {
+		t.Fatal("expected errors not to be cached")
+	}
+}
+
+func TestLruDbShared(t *testing.T) {
+	ctx := context.Background()
+	ca := NewCache(0)
+	var stores []*lruDb
+	for _, v := range []string{"inky", "pinky"} {
+		main := mem.NewMemDb()
+		err := main.Connect(ctx, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		store := NewLruDb(main, ca)
+		store.SetLock(db.DATATYPE_TEMPLATE, false)
+		store.SetPrefix(db.DATATYPE_TEMPLATE)
+		err = store.Put(ctx, []byte("foo"), []byte(v))
+		if err != nil {
+			t.Fatal(err)
+		}
+		stores = append(stores, store)
+	}
+	for i := 0; i < 2; i++ {
+		for j, expect := range []string{"inky", "pinky"} {
+			v, err := stores[j].Get(ctx, []byte("foo"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(v, []byte(expect)) {
+				t.Fatalf("expected '%s', got '%s'", expect, v)
+			}
+		}
+	}
+	if ca.Stats().Entries != 2 {
+		t.Fatalf("expected 2 entries, got %d", ca.Stats().Entries)
+	}
+
+	err := stores[0].Invalidate(ctx, []byte("foo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ca.Stats().Entries != 1 {
+		t.Fatalf("expected invalidation to leave other wrapper's entry, got %d entries", ca.Stats().Entries)
+	}
+}
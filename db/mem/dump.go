@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"context"
 	"encoding/hex"
-	"fmt"
 	"maps"
 	"slices"
 
@@ -15,6 +14,7 @@ import (
 func (mdb *memDb) Dump(ctx context.Context, key []byte) (*db.Dumper, error) {
 	mdb.dumpKeys = slices.Sorted(maps.Keys(mdb.store))
 	mdb.dumpIdx = -1
+	mdb.dumpPrefix = key
 	for i := 0; i < len(mdb.dumpKeys); i++ {
 		s := mdb.dumpKeys[i]
 		k, err := hex.DecodeString(s)
@@ -24,15 +24,7 @@ func (mdb *memDb) Dump(ctx context.Context, key []byte) (*db.Dumper, error) {
 		if bytes.HasPrefix(k, key) {
 			logg.DebugCtxf(ctx, "starting dump", "key", k)
 			mdb.dumpIdx = i
-			kk, err := mdb.Base().FromSessionKey(k[1:])
-			if err != nil {
-				return nil, fmt.Errorf("invalid dump key %x: %v", k, err)
-			}
-			v, err := mdb.Get(ctx, kk[:])
-			if err != nil {
-				return nil, fmt.Errorf("value err for key %x: %v", k, err)
-			}
-			return db.NewDumper(mdb.dumpFunc).WithFirst(k, v), nil
+			return db.NewDumper(mdb.dumpFunc).WithFirst(k, mdb.store[s]), nil
 		}
 	}
 	return nil, db.NewErrNotFound(key)
@@ -42,6 +34,7 @@ func (mdb *memDb) dumpFunc(ctx context.Context) ([]byte, []byte) {
 	if mdb.dumpIdx == -1 {
 		return nil, nil
 	}
+	mdb.dumpIdx += 1
 	if mdb.dumpIdx >= len(mdb.dumpKeys) {
 		mdb.dumpIdx = -1
 		return nil, nil
@@ -52,13 +45,9 @@ func (mdb *memDb) dumpFunc(ctx context.Context) ([]byte, []byte) {
 		mdb.dumpIdx = -1
 		return nil, nil
 	}
-	kk, err := mdb.Base().FromSessionKey(k[1:])
-	if err != nil {
-		return nil, nil
-	}
-	v, err := mdb.Get(ctx, kk)
-	if err != nil {
+	if !bytes.HasPrefix(k, mdb.dumpPrefix) {
+		mdb.dumpIdx = -1
 		return nil, nil
 	}
-	return k, v
+	return k, mdb.store[s]
 }
@@ -0,0 +1,54 @@
+package mem
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/grassrootseconomics/go-vise/db"
+)
+
+func TestDumpMem(t *testing.T) {
+	ctx := context.Background()
+
+	store := NewMemDb()
+	err := store.Connect(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store.SetPrefix(db.DATATYPE_USERDATA)
+	for _, v := range [][2]string{
+		{"bar", "inky"},
+		{"foobar", "pinky"},
+		{"foobarbaz", "blinky"},
+		{"xyzzy", "clyde"},
+	} {
+		err = store.Put(ctx, []byte(v[0]), []byte(v[1]))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	o, err := store.Dump(ctx, []byte{db.DATATYPE_USERDATA, 'f', 'o', 'o'})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range [][2]string{
+		{"foobar", "pinky"},
+		{"foobarbaz", "blinky"},
+	} {
+		k, val := o.Next(ctx)
+		expect := append([]byte{db.DATATYPE_USERDATA}, []byte(v[0])...)
+		if !bytes.Equal(k, expect) {
+			t.Fatalf("expected key %x, got %x", expect, k)
+		}
+		if !bytes.Equal(val, []byte(v[1])) {
+			t.Fatalf("expected val '%s', got '%s'", v[1], val)
+		}
+	}
+	k, _ := o.Next(ctx)
+	if k != nil {
+		t.Fatalf("expected nil, got %x", k)
+	}
+}
@@ -20,6 +20,7 @@ type memDb struct {
 	store map[string][]byte
 	dumpIdx int
 	dumpKeys []string
+	dumpPrefix []byte
 }
 
 // NewmemDb returns an in-process volatile Db implementation.
@@ -106,6 +107,41 @@ func (mdb *memDb) Put(ctx context.Context, key []byte, val []byte) error {
 	return nil
 }
 
+var _ db.RawDumper = (*memDb)(nil)
+
+// RawDump implements db.RawDumper.
+//
+// The mem backend dumps all stored keys matching the prefix given to Dump, regardless of the current datatype and session.
+func (mdb *memDb) RawDump() bool {
+	return true
+}
+
+var _ db.Deleter = (*memDb)(nil)
+
+// Delete implements db.Deleter
+func (mdb *memDb) Delete(ctx context.Context, key []byte) error {
+	var k string
+	if !mdb.CheckPut() {
+		return errors.New("unsafe delete and safety set")
+	}
+	mk, err := mdb.toHexKey(ctx, key)
+	if err != nil {
+		return err
+	}
+	if mk.Translation != "" {
+		k = mk.Translation
+	} else {
+		k = mk.Default
+	}
+	_, ok := mdb.store[k]
+	if !ok {
+		return db.NewErrNotFound(key)
+	}
+	delete(mdb.store, k)
+	logg.TraceCtxf(ctx, "mem delete", "k", k)
+	return nil
+}
+
 // Close implements Db
 func (mdb *memDb) Close(ctx context.Context) error {
 	return nil
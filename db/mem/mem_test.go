@@ -55,3 +55,31 @@ func TestPutGetMem(t *testing.T) {
 		t.Fatal("expected get error for key 'bar'")
 	}
 }
+
+func TestDeleteMem(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemDb()
+	store.SetPrefix(db.DATATYPE_USERDATA)
+	store.SetSession("ses")
+
+	err := store.Connect(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.Put(ctx, []byte("foo"), []byte("bar"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.Delete(ctx, []byte("foo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = store.Get(ctx, []byte("foo"))
+	if !db.IsNotFound(err) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	err = store.Delete(ctx, []byte("foo"))
+	if !db.IsNotFound(err) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 
@@ -16,7 +17,7 @@ func (pdb *pgDb) Dump(ctx context.Context, key []byte) (*db.Dumper, error) {
 	}
 	k := lk.Default
 
-	query := fmt.Sprintf("SELECT key, value FROM %s.kv_vise WHERE key >= $1", pdb.schema)
+	query := fmt.Sprintf("SELECT key, value FROM %s.kv_vise WHERE key >= $1 ORDER BY key", pdb.schema)
 	rs, err := pdb.conn.Query(ctx, query, k)
 	if err != nil {
 		pdb.logg.Debugf("query fail", "err", err)
@@ -30,6 +31,10 @@ func (pdb *pgDb) Dump(ctx context.Context, key []byte) (*db.Dumper, error) {
 		if err != nil {
 			return nil, err
 		}
+		if !bytes.HasPrefix(kk, k) {
+			rs.Close()
+			return nil, db.NewErrNotFound(k)
+		}
 		pdb.it = rs
 		pdb.itBase = k
 		kk, err = pdb.DecodeKey(ctx, kk)
@@ -55,6 +60,13 @@ func (pdb *pgDb) dumpFunc(ctx context.Context) ([]byte, []byte) {
 	if err != nil {
 		return nil, nil
 	}
+	// rows are ordered by key, so the first key without the prefix ends the dump.
+	if !bytes.HasPrefix(kk, pdb.itBase) {
+		pdb.logg.DebugCtxf(ctx, "end of prefix in pg iterator")
+		pdb.closeFunc()
+		pdb.itBase = nil
+		return nil, nil
+	}
 	k, err := pdb.DecodeKey(ctx, kk)
 	if err != nil {
 		return nil, nil
@@ -60,6 +60,7 @@ func TestDumpPg(t *testing.T) {
 	//rows = rows.AddRow([]byte("bar"), []byte("inky"))
 	rows = rows.AddRow(append([]byte{db.DATATYPE_USERDATA}, []byte("xyzzy.foobar")...), []byte("pinky"))
 	rows = rows.AddRow(append([]byte{db.DATATYPE_USERDATA}, []byte("xyzzy.foobarbaz")...), []byte("blinky"))
+	rows = rows.AddRow(append([]byte{db.DATATYPE_USERDATA}, []byte("xyzzy.xyzzy")...), []byte("clyde"))
 	//rows = rows.AddRow([]byte("xyzzy"), []byte("clyde"))
 
 	mock.ExpectQuery("SELECT key, value FROM vvise.kv_vise").WithArgs(append([]byte{db.DATATYPE_USERDATA}, k...)).WillReturnRows(rows)
@@ -27,6 +27,7 @@ type (
 		migrate string
 		get     string
 		put     string
+		del     string
 	}
 
 	// pgDb is a Postgres backend implementation of the Db interface.
@@ -83,6 +84,7 @@ func (pdb *pgDb) updateQueries() {
 		migrate: fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s.kv_vise (id SERIAL NOT NULL, key BYTEA NOT NULL UNIQUE, value BYTEA NOT NULL, updated TIMESTAMP NOT NULL);", pdb.schema),
 		get:     fmt.Sprintf("SELECT value FROM %s.kv_vise WHERE key = $1", pdb.schema),
 		put:     fmt.Sprintf("INSERT INTO %s.kv_vise (key, value, updated) VALUES ($1, $2, 'now') ON CONFLICT(key) DO UPDATE SET value = $2, updated = 'now';", pdb.schema),
+		del:     fmt.Sprintf("DELETE FROM %s.kv_vise WHERE key = $1", pdb.schema),
 	}
 }
 
@@ -173,6 +175,35 @@ func (pdb *pgDb) Get(ctx context.Context, key []byte) ([]byte, error) {
 	return rr, nil
 }
 
+var _ db.Deleter = (*pgDb)(nil)
+
+// Delete implements db.Deleter.
+func (pdb *pgDb) Delete(ctx context.Context, key []byte) error {
+	if !pdb.CheckPut() {
+		return ErrUnsafePut
+	}
+
+	lk, err := pdb.ToKey(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	pdb.logg.TraceCtxf(ctx, "delete", "key", key)
+	actualKey := lk.Default
+	if lk.Translation != nil {
+		actualKey = lk.Translation
+	}
+
+	tag, err := pdb.conn.Exec(ctx, pdb.queries.del, actualKey)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return db.NewErrNotFound(key)
+	}
+	return nil
+}
+
 // Close implements Db.
 func (pdb *pgDb) Close(ctx context.Context) error {
 	if pdb.conn == nil {
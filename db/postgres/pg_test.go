@@ -506,3 +506,39 @@ func TestGetDatabaseError(t *testing.T) {
 		t.Fatalf("expected database error, got: %v", err)
 	}
 }
+
+func TestDeletePg(t *testing.T) {
+	ses := "xyzzy"
+
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	store := NewPgDb().WithConnection(mock).WithSchema("vvise")
+	store.SetPrefix(db.DATATYPE_USERDATA)
+	store.SetSession(ses)
+	ctx := context.Background()
+
+	k := []byte("foo")
+	ks := append([]byte{db.DATATYPE_USERDATA}, []byte(ses)...)
+	ks = append(ks, []byte(".")...)
+	ks = append(ks, k...)
+
+	mock.ExpectExec("DELETE FROM vvise.kv_vise").WithArgs(ks).WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	err = store.Delete(ctx, k)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectExec("DELETE FROM vvise.kv_vise").WithArgs(ks).WillReturnResult(pgxmock.NewResult("DELETE", 0))
+	err = store.Delete(ctx, k)
+	if !db.IsNotFound(err) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,345 @@
+package debug
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/grassrootseconomics/go-vise/asm"
+	"github.com/grassrootseconomics/go-vise/lang"
+	"github.com/grassrootseconomics/go-vise/resource"
+	"github.com/grassrootseconomics/go-vise/vm"
+)
+
+// Identifiers of the checks performed by the Linter.
+const (
+	// An INCMP that can never match, because a wildcard INCMP precedes it.
+	LINT_UNREACHABLE = "unreachable-input"
+	// A menu selector without an INCMP that matches it.
+	LINT_UNMATCHED = "unmatched-selector"
+	// A menu or input selector used more than once before the same HALT.
+	LINT_DUPLICATE = "duplicate-selector"
+	// A LOAD size smaller than the static content for the symbol.
+	LINT_LOADSIZE = "load-size"
+	// A flag declared in the flag definitions but never used in CATCH or CROAK.
+	LINT_UNUSEDFLAG = "unused-flag"
+	// A node that is the target of a move, but has no bytecode.
+	LINT_MISSINGNODE = "missing-node"
+)
+
+// Diagnostic is a likely mistake found by the Linter.
+type Diagnostic struct {
+	// Node where the mistake was found. Empty if it does not belong to a node.
+	Node string `json:"node,omitempty"`
+	// Bytecode offset of the instruction in the node.
+	Offset int `json:"offset"`
+	// Source position of the instruction, if a source map is available.
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line,omitempty"`
+	Column int    `json:"column,omitempty"`
+	// Identifier of the check, one of LINT_*.
+	Check   string `json:"check"`
+	Message string `json:"message"`
+}
+
+// String implements the String interface.
+//
+// The source position is used as prefix if available, otherwise the node and bytecode offset.
+func (d Diagnostic) String() string {
+	pos := d.File
+	if d.Line > 0 {
+		pos = fmt.Sprintf("%s:%d:%d", d.File, d.Line, d.Column)
+	} else if d.Node != "" {
+		pos = fmt.Sprintf("%s+%d", d.Node, d.Offset)
+	}
+	return fmt.Sprintf("%s: %s: %s", pos, d.Check, d.Message)
+}
+
+// instruction arguments recorded for lint.
+type lintOp struct {
+	op     vm.Opcode
+	offset int
+	sym    string
+	sel    string
+	size   uint32
+	flag   uint32
+}
+
+// retrieves static content for a symbol without calling its entry function, e.g. resource.DbResource.
+type staticGetter interface {
+	DbGetStatic(ctx context.Context, sym string) ([]byte, error)
+}
+
+// Linter reports likely mistakes in the bytecode of all nodes reachable from a root node.
+//
+// The nodes are found with a NodeMap.
+type Linter struct {
+	rs     resource.Resource
+	flags  *asm.FlagParser
+	langs  []lang.Language
+	sm     vm.SourceMapFunc
+	have   map[string]bool
+	used   map[uint32]bool
+	result []Diagnostic
+}
+
+// NewLinter creates a new Linter for the nodes in the resource.
+func NewLinter(rs resource.Resource) *Linter {
+	return &Linter{
+		rs: rs,
+	}
+}
+
+// WithFlags is a chainable function that sets the flag definitions to check for unused flags.
+func (li *Linter) WithFlags(pp *asm.FlagParser) *Linter {
+	li.flags = pp
+	return li
+}
+
+// WithLanguages is a chainable function that sets the languages to retrieve static content for when checking LOAD sizes.
+//
+// If not set, the content is retrieved with the given context only.
+func (li *Linter) WithLanguages(ln ...lang.Language) *Linter {
+	li.langs = ln
+	return li
+}
+
+// WithSourceMaps is a chainable function that sets the source maps used to add source positions to diagnostics.
+func (li *Linter) WithSourceMaps(fn vm.SourceMapFunc) *Linter {
+	li.sm = fn
+	return li
+}
+
+// Run checks the nodes reachable from the root node, and returns all diagnostics found.
+//
+// LOAD sizes are only checked if the resource provides static content, as resource.DbResource does for db.DATATYPE_STATICLOAD. Entry functions are never called.
+//
+// An error is returned if the root node cannot be retrieved, or if the bytecode of any node cannot be parsed.
+func (li *Linter) Run(ctx context.Context, root string) ([]Diagnostic, error) {
+	li.have = make(map[string]bool)
+	li.used = make(map[uint32]bool)
+	li.result = []Diagnostic{}
+
+	nm := NewNodeMap(root)
+	err := nm.Run(ctx, li.rs)
+	if err != nil {
+		return nil, err
+	}
+	var syms []string
+	for k := range NodeIndex {
+		if k != root {
+			syms = append(syms, k)
+		}
+	}
+	sort.Strings(syms)
+	syms = append([]string{root}, syms...)
+
+	for _, sym := range syms {
+		b, err := li.rs.GetCode(ctx, sym)
+		if err != nil {
+			continue
+		}
+		li.have[sym] = true
+		ops, err := li.parse(b)
+		if err != nil {
+			return nil, fmt.Errorf("node %s: %v", sym, err)
+		}
+		li.node(ctx, sym, ops)
+	}
+
+	if li.flags != nil {
+		for _, k := range li.flags.Names() {
+			fl, err := li.flags.GetFlag(k)
+			if err != nil || li.used[fl] {
+				continue
+			}
+			li.result = append(li.result, Diagnostic{
+				Check:   LINT_UNUSEDFLAG,
+				Message: fmt.Sprintf("flag %s (%d) is never used in CATCH or CROAK", k, fl),
+			})
+		}
+	}
+	return li.result, nil
+}
+
+// record the instructions in the bytecode.
+func (li *Linter) parse(b []byte) ([]lintOp, error) {
+	var ops []lintOp
+	ph := vm.NewParseHandler().WithDefaultHandlers()
+	add := func(o lintOp) error {
+		o.offset = ph.Offset()
+		ops = append(ops, o)
+		return nil
+	}
+	ph.Catch = func(sym string, flag uint32, inv bool) error {
+		return add(lintOp{op: vm.CATCH, sym: sym, flag: flag})
+	}
+	ph.Croak = func(flag uint32, inv bool) error {
+		return add(lintOp{op: vm.CROAK, flag: flag})
+	}
+	ph.Load = func(sym string, size uint32) error {
+		return add(lintOp{op: vm.LOAD, sym: sym, size: size})
+	}
+	ph.Move = func(sym string) error {
+		return add(lintOp{op: vm.MOVE, sym: sym})
+	}
+	ph.Halt = func() error {
+		return add(lintOp{op: vm.HALT})
+	}
+	ph.InCmp = func(sym string, sel string) error {
+		return add(lintOp{op: vm.INCMP, sym: sym, sel: sel})
+	}
+	ph.InList = func(sym string, sel string) error {
+		return add(lintOp{op: vm.INLIST, sym: sym, sel: sel})
+	}
+	ph.MOut = func(sym string, sel string) error {
+		return add(lintOp{op: vm.MOUT, sym: sym, sel: sel})
+	}
+	ph.MNext = func(sym string, sel string) error {
+		return add(lintOp{op: vm.MNEXT, sym: sym, sel: sel})
+	}
+	ph.MPrev = func(sym string, sel string) error {
+		return add(lintOp{op: vm.MPREV, sym: sym, sel: sel})
+	}
+	_, err := ph.ParseAll(b)
+	return ops, err
+}
+
+// add a diagnostic for the instruction at the offset in the node.
+func (li *Linter) report(ctx context.Context, sym string, offset int, check string, format string, args ...any) {
+	d := Diagnostic{
+		Node:    sym,
+		Offset:  offset,
+		Check:   check,
+		Message: fmt.Sprintf(format, args...),
+	}
+	if li.sm != nil {
+		sm, err := li.sm(ctx, sym)
+		if err == nil {
+			loc, ok := sm.Locate(offset)
+			if ok {
+				d.File = loc.File
+				d.Line = loc.Line
+				d.Column = loc.Column
+			}
+		}
+	}
+	li.result = append(li.result, d)
+}
+
+func isRelative(sym string) bool {
+	return sym == "<" || sym == ">" || sym == "^" || sym == "_" || sym == "."
+}
+
+// run all checks on the instructions of a node.
+func (li *Linter) node(ctx context.Context, sym string, ops []lintOp) {
+	menu := make(map[string]int)
+	input := make(map[string]int)
+	matched := make(map[string]bool)
+	wildcard := -1
+	anyWildcard := false
+	haveList := false
+	var menuOps []lintOp
+
+	for _, o := range ops {
+		switch o.op {
+		case vm.HALT:
+			menu = make(map[string]int)
+			input = make(map[string]int)
+			wildcard = -1
+		case vm.MOUT, vm.MNEXT, vm.MPREV:
+			at, ok := menu[o.sel]
+			if ok {
+				li.report(ctx, sym, o.offset, LINT_DUPLICATE, "menu selector %s is already used at offset %d", o.sel, at)
+			} else {
+				menu[o.sel] = o.offset
+			}
+			menuOps = append(menuOps, o)
+		case vm.INCMP:
+			if wildcard > -1 {
+				li.report(ctx, sym, o.offset, LINT_UNREACHABLE, "INCMP %s %s can never match, wildcard at offset %d matches first", o.sym, o.sel, wildcard)
+			} else if at, ok := input[o.sel]; ok {
+				li.report(ctx, sym, o.offset, LINT_DUPLICATE, "input selector %s is already matched at offset %d", o.sel, at)
+			} else {
+				input[o.sel] = o.offset
+			}
+			if o.sel == "*" {
+				if wildcard == -1 {
+					wildcard = o.offset
+				}
+				anyWildcard = true
+			}
+			matched[o.sel] = true
+			li.checkNode(ctx, sym, o)
+		case vm.INLIST:
+			haveList = true
+			li.checkNode(ctx, sym, o)
+		case vm.MOVE, vm.CATCH:
+			li.checkNode(ctx, sym, o)
+		case vm.LOAD:
+			li.checkLoad(ctx, sym, o)
+		}
+		if o.op == vm.CATCH || o.op == vm.CROAK {
+			li.used[o.flag] = true
+		}
+	}
+
+	if anyWildcard || haveList {
+		return
+	}
+	for _, o := range menuOps {
+		if !matched[o.sel] {
+			li.report(ctx, sym, o.offset, LINT_UNMATCHED, "menu selector %s has no matching INCMP", o.sel)
+		}
+	}
+}
+
+// check that the target node of a move exists.
+func (li *Linter) checkNode(ctx context.Context, sym string, o lintOp) {
+	if isRelative(o.sym) || li.have[o.sym] {
+		return
+	}
+	_, err := li.rs.GetCode(ctx, o.sym)
+	if err == nil {
+		li.have[o.sym] = true
+		return
+	}
+	li.report(ctx, sym, o.offset, LINT_MISSINGNODE, "target node %s has no bytecode", o.sym)
+}
+
+// check that the static content for a symbol fits its LOAD size.
+func (li *Linter) checkLoad(ctx context.Context, sym string, o lintOp) {
+	if o.size == 0 {
+		return
+	}
+	sg, ok := li.rs.(staticGetter)
+	if !ok {
+		return
+	}
+	ctxs := []context.Context{ctx}
+	if len(li.langs) > 0 {
+		ctxs = []context.Context{}
+		for _, ln := range li.langs {
+			ctxs = append(ctxs, context.WithValue(ctx, "Language", ln))
+		}
+	}
+	var l int
+	var code string
+	for _, ctx := range ctxs {
+		b, err := sg.DbGetStatic(ctx, o.sym)
+		if err != nil {
+			continue
+		}
+		if len(b) > l {
+			l = len(b)
+			code = ""
+			ln, ok := lang.LanguageFromContext(ctx)
+			if ok {
+				code = " (" + ln.Code + ")"
+			}
+		}
+	}
+	if l > int(o.size) {
+		li.report(ctx, sym, o.offset, LINT_LOADSIZE, "LOAD %s size %d is smaller than its static content%s of %d bytes", o.sym, o.size, code, l)
+	}
+}
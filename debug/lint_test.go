@@ -0,0 +1,93 @@
+package debug
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/grassrootseconomics/go-vise/asm"
+	"github.com/grassrootseconomics/go-vise/db"
+	"github.com/grassrootseconomics/go-vise/internal/resourcetest"
+	"github.com/grassrootseconomics/go-vise/resource"
+)
+
+func addCode(ctx context.Context, t *testing.T, rs *resourcetest.TestResource, sym string, s string) {
+	b := bytes.NewBuffer(nil)
+	_, err := asm.Parse(s, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = rs.AddBytecode(ctx, sym, b.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLint(t *testing.T) {
+	ctx := context.Background()
+	rs := resourcetest.NewTestResource()
+	addCode(ctx, t, rs, "root", `LOAD foo 4
+LOAD bar 1
+MOUT one 1
+MOUT two 2
+MOUT three 2
+MOUT four 4
+HALT
+INCMP second 1
+INCMP second 1
+INCMP nothere 2
+INCMP root *
+INCMP second 3
+`)
+	addCode(ctx, t, rs, "second", `CATCH root 8 1
+MOUT back 0
+HALT
+INCMP _ 1
+`)
+	rs.With(db.DATATYPE_STATICLOAD)
+	err := rs.AddStatic(ctx, "foo.txt", "hello world")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// entry functions are not called.
+	rs.AddFunc(ctx, "bar", func(ctx context.Context, sym string, input []byte) (resource.Result, error) {
+		t.Fatal("entry function called")
+		return resource.Result{}, nil
+	})
+	rs.Lock()
+
+	fp := path.Join(t.TempDir(), "flags.csv")
+	err = os.WriteFile(fp, []byte("flag,foo,8\nflag,bar,9\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pp := asm.NewFlagParser()
+	_, err = pp.Load(fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewLinter(rs).WithFlags(pp).Run(ctx, "root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []Diagnostic{
+		{Node: "root", Offset: 0, Check: LINT_LOADSIZE},
+		{Node: "root", Offset: 32, Check: LINT_DUPLICATE},
+		{Node: "root", Offset: 64, Check: LINT_DUPLICATE},
+		{Node: "root", Offset: 75, Check: LINT_MISSINGNODE},
+		{Node: "root", Offset: 96, Check: LINT_UNREACHABLE},
+		{Node: "second", Offset: 10, Check: LINT_UNMATCHED},
+		{Check: LINT_UNUSEDFLAG},
+	}
+	if len(r) != len(expect) {
+		t.Fatalf("expected %d diagnostics, got %d: %v", len(expect), len(r), r)
+	}
+	for i, v := range expect {
+		if r[i].Node != v.Node || r[i].Offset != v.Offset || r[i].Check != v.Check {
+			t.Fatalf("diagnostic %d: expected %s+%d %s, got %v", i, v.Node, v.Offset, v.Check, r[i])
+		}
+	}
+}
@@ -11,11 +11,12 @@ var (
 
 type NodeParseHandler struct {
 	*vm.ParseHandler
-	node            *Node
-	parentMOutFunc  func(string, string) error
-	parentMoveFunc  func(string) error
-	parentInCmpFunc func(string, string) error
-	parentCatchFunc func(string, uint32, bool) error
+	node             *Node
+	parentMOutFunc   func(string, string) error
+	parentMoveFunc   func(string) error
+	parentInCmpFunc  func(string, string) error
+	parentInListFunc func(string, string) error
+	parentCatchFunc  func(string, uint32, bool) error
 }
 
 func NewNodeParseHandler(node *Node) *NodeParseHandler {
@@ -26,10 +27,12 @@ func NewNodeParseHandler(node *Node) *NodeParseHandler {
 	np.node.Name = node.Name
 	np.parentMoveFunc = np.ParseHandler.Move
 	np.parentInCmpFunc = np.ParseHandler.InCmp
+	np.parentInListFunc = np.ParseHandler.InList
 	np.parentCatchFunc = np.ParseHandler.Catch
 	np.parentMOutFunc = np.ParseHandler.MOut
 	np.Move = np.move
 	np.InCmp = np.incmp
+	np.InList = np.inlist
 	np.Catch = np.catch
 	np.MOut = np.mout
 	return np
@@ -69,6 +72,20 @@ func (np *NodeParseHandler) incmp(sym string, sel string) error {
 	return np.parentInCmpFunc(sym, sel)
 }
 
+func (np *NodeParseHandler) inlist(sym string, sel string) error {
+	var node Node
+
+	if sym == "<" || sym == ">" || sym == "^" || sym == "_" || sym == "." {
+		logg.Debugf("skip relative move")
+		return np.parentInListFunc(sym, sel)
+	}
+
+	node.Name = sym
+	np.node.Connect(node)
+	logg.Debugf("connect INLIST", "src", np.node.Name, "dst", node.Name)
+	return np.parentInListFunc(sym, sel)
+}
+
 func (np *NodeParseHandler) catch(sym string, flag uint32, inv bool) error {
 	var node Node
 
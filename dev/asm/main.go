@@ -1,122 +1,36 @@
 package main
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
-	"strconv"
-	"strings"
-
-	"github.com/alecthomas/participle/v2"
-	"github.com/alecthomas/participle/v2/lexer"
+	"path"
 
 	"github.com/grassrootseconomics/go-vise/asm"
+	"github.com/grassrootseconomics/go-vise/vm"
 )
 
-type arg struct {
-	One   *string `(@Sym | @NumFirst)`
-	Two   *string `((@Sym | @NumFirst) Whitespace?)?`
-	Three *string `((@Sym | @NumFirst) Whitespace?)?`
-}
-
-type instruction struct {
-	OpCode  string `@Ident`
-	OpArg   arg    `(Whitespace @@)?`
-	Comment string `Comment? EOL`
-}
-
-type asmAsm struct {
-	Instructions []*instruction `@@*`
-}
-
-type processor struct {
-	*asm.FlagParser
-}
-
-func newProcessor(fp string) (*processor, error) {
-	o := &processor{
-		asm.NewFlagParser(),
-	}
-	_, err := o.Load(fp)
-	return o, err
-}
-
-func (p *processor) processFlag(s []string, one *string, two *string) ([]string, error) {
-	_, err := strconv.Atoi(*one)
-	if err != nil {
-		r, err := p.GetAsString(*one)
-		if err != nil {
-			return nil, err
-		}
-		log.Printf("translated flag %s to %s", *one, r)
-		s = append(s, r)
-	} else {
-		s = append(s, *one)
-	}
-	return append(s, *two), nil
-}
-
-func (p *processor) pass(s []string, a arg) []string {
-	for _, r := range []*string{a.One, a.Two, a.Three} {
-		if r == nil {
-			break
-		}
-		s = append(s, *r)
-	}
-	return s
-}
-
-func (pp *processor) run(b []byte) ([]byte, error) {
-	asmLexer := lexer.MustSimple([]lexer.SimpleRule{
-		{"Comment", `(?:#)[^\n]*`},
-		{"Ident", `^[A-Z]+`},
-		{"NumFirst", `[0-9][a-zA-Z0-9]*`},
-		{"Sym", `[a-zA-Z_\*\.\^\<\>][a-zA-Z0-9_]*`},
-		{"Whitespace", `[ \t]+`},
-		{"EOL", `[\n\r]+`},
-		{"Quote", `["']`},
-	})
-	asmParser := participle.MustBuild[asmAsm](
-		participle.Lexer(asmLexer),
-		participle.Elide("Comment", "Whitespace"),
-	)
-	ast, err := asmParser.ParseString("preprocessor", string(b))
+func optimizeCode(b []byte, sm *vm.SourceMap) ([]byte, error) {
+	r, err := asm.NewOptimizer().WithSourceMap(sm).Optimize(b)
 	if err != nil {
 		return nil, err
 	}
-
-	b = []byte{}
-	for _, v := range ast.Instructions {
-		s := []string{v.OpCode}
-		if v.OpArg.One != nil {
-			switch v.OpCode {
-			case "CATCH":
-				s = append(s, *v.OpArg.One)
-				s, err = pp.processFlag(s, v.OpArg.Two, v.OpArg.Three)
-				if err != nil {
-					return nil, err
-				}
-			case "CROAK":
-				s, err = pp.processFlag(s, v.OpArg.One, v.OpArg.Two)
-				if err != nil {
-					return nil, err
-				}
-			default:
-				s = pp.pass(s, v.OpArg)
-			}
-		}
-		b = append(b, []byte(strings.Join(s, " "))...)
-		b = append(b, 0x0a)
-	}
-
-	return b, nil
+	log.Printf("optimized to %v bytes", len(r))
+	return r, nil
 }
 
 func main() {
 	var ppfp string
-	flag.StringVar(&ppfp, "f", "", "preprocessor data to load")
+	var smfp string
+	var outDir string
+	var optimize bool
+	flag.StringVar(&ppfp, "f", "", "symbol definitions to load")
+	flag.StringVar(&smfp, "m", "", "write source map to file, defaults to the bytecode file name with .map suffix for node definition files")
+	flag.StringVar(&outDir, "o", "", "resource dir to write the bytecode, templates and menus of a node definition file to, defaults to the dir of the node definition file")
+	flag.BoolVar(&optimize, "O", false, "remove redundant and unreachable instructions from the bytecode")
 	flag.Parse()
 	if len(flag.Args()) < 1 {
 		os.Exit(1)
@@ -128,25 +42,73 @@ func main() {
 		os.Exit(1)
 	}
 
+	st := asm.NewSymbolTable()
 	if len(ppfp) > 0 {
-		pp, err := newProcessor(ppfp)
+		_, err = st.Load(ppfp)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "preprocessor load error: %v\n", err)
+			fmt.Fprintf(os.Stderr, "symbol load error: %v\n", err)
 			os.Exit(1)
 		}
+		log.Printf("symbols loaded")
+	}
 
-		v, err = pp.run(v)
+	sm := vm.NewSourceMap(path.Base(fp))
+	as := asm.NewAssembler(fp).WithSourceMap(sm).WithSymbols(st)
+	if path.Ext(fp) == asm.NODE_EXT {
+		if outDir == "" {
+			outDir = path.Dir(fp)
+		}
+		nd, err := as.ParseNode(string(v))
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "preprocess error: %v\n", err)
+			fmt.Fprintf(os.Stderr, "parse error: %v\n", err)
 			os.Exit(1)
 		}
+		if optimize {
+			nd.Code, err = optimizeCode(nd.Code, sm)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "optimize error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		for _, e := range nd.Entries() {
+			err = ioutil.WriteFile(path.Join(outDir, e.FileName()), e.Value, 0644)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "write error: %v\n", err)
+				os.Exit(1)
+			}
+			log.Printf("wrote %s", e.FileName())
+		}
+		if smfp == "" {
+			smfp = path.Join(outDir, nd.Sym+vm.SOURCEMAP_EXT)
+		}
+	} else {
+		b := bytes.NewBuffer(nil)
+		n, err := as.Parse(string(v), b)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "parse error: %v\n", err)
+			os.Exit(1)
+		}
+		log.Printf("parsed total %v bytes", n)
+		code := b.Bytes()
+		if optimize {
+			code, err = optimizeCode(code, sm)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "optimize error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		os.Stdout.Write(code)
 	}
-	log.Printf("preprocessor done")
 
-	n, err := asm.Parse(string(v), os.Stdout)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "parse error: %v\n", err)
-		os.Exit(1)
+	if smfp != "" {
+		b, err := sm.MarshalText()
+		if err == nil {
+			err = ioutil.WriteFile(smfp, b, 0644)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "source map write error: %v\n", err)
+			os.Exit(1)
+		}
+		log.Printf("wrote %s", smfp)
 	}
-	log.Printf("parsed total %v bytes", n)
 }
@@ -0,0 +1,2 @@
+// Executable bundle builds a single-file application bundle from a resource directory, and verifies existing bundles.
+package main
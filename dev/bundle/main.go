@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/grassrootseconomics/go-vise/db/bundle"
+)
+
+// read a hex encoded key from file.
+func readKey(fp string, size int) ([]byte, error) {
+	v, err := os.ReadFile(fp)
+	if err != nil {
+		return nil, err
+	}
+	b, err := hex.DecodeString(strings.TrimSpace(string(v)))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) != size {
+		return nil, fmt.Errorf("invalid key length %d, expected %d", len(b), size)
+	}
+	return b, nil
+}
+
+// generate a new signing key, writing the seed to the key file and the public key to the key file with .pub extension.
+func genKey(fp string) error {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(fp, []byte(hex.EncodeToString(priv.Seed())+"\n"), 0600)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(fp+".pub", []byte(hex.EncodeToString(pub)+"\n"), 0644)
+}
+
+func build(dir string, outFile string, name string, version string, ppfp string, keyfp string) (*bundle.Manifest, error) {
+	bb := bundle.NewBuilder(name).WithVersion(version)
+	if ppfp != "" {
+		v, err := os.ReadFile(ppfp)
+		if err != nil {
+			return nil, err
+		}
+		bb = bb.WithFlags(v)
+	}
+	if keyfp != "" {
+		seed, err := readKey(keyfp, ed25519.SeedSize)
+		if err != nil {
+			return nil, err
+		}
+		bb = bb.WithSigningKey(ed25519.NewKeyFromSeed(seed))
+	}
+	err := bb.AddDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Create(outFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return bb.Write(f)
+}
+
+func verify(fp string, pubfp string) (*bundle.Manifest, error) {
+	ctx := context.Background()
+	store := bundle.NewBundleDb()
+	if pubfp != "" {
+		pub, err := readKey(pubfp, ed25519.PublicKeySize)
+		if err != nil {
+			return nil, err
+		}
+		store = store.WithPublicKey(pub)
+	}
+	err := store.Connect(ctx, fp)
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close(ctx)
+	mf := store.Manifest()
+	return &mf, nil
+}
+
+func main() {
+	var outFile string
+	var name string
+	var version string
+	var ppfp string
+	var keyfp string
+	var pubfp string
+	var doVerify bool
+	var doGenKey bool
+	flag.StringVar(&outFile, "o", "", "bundle file to write, defaults to the name with .zip extension")
+	flag.StringVar(&name, "name", "", "application name, defaults to the base name of the resource dir")
+	flag.StringVar(&version, "version", "", "application version")
+	flag.StringVar(&ppfp, "f", "", "flag definitions to include in the bundle")
+	flag.StringVar(&keyfp, "k", "", "file with hex encoded ed25519 seed to sign the bundle with")
+	flag.StringVar(&pubfp, "p", "", "file with hex encoded ed25519 public key to verify the bundle signature with")
+	flag.BoolVar(&doVerify, "verify", false, "verify the given bundle instead of building")
+	flag.BoolVar(&doGenKey, "genkey", false, "generate a new signing key in the file given with -k, and the public key in the same file with .pub extension")
+	flag.Parse()
+
+	if doGenKey {
+		if keyfp == "" {
+			fmt.Fprintf(os.Stderr, "-genkey requires key file path with -k\n")
+			os.Exit(1)
+		}
+		err := genKey(keyfp)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "key generation error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(flag.Args()) < 1 {
+		fmt.Fprintf(os.Stderr, "usage: %s [options] <resource dir | bundle file>\n", os.Args[0])
+		os.Exit(1)
+	}
+	fp := flag.Arg(0)
+
+	var mf *bundle.Manifest
+	var err error
+	if doVerify {
+		mf, err = verify(fp, pubfp)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "verify error: %v\n", err)
+			os.Exit(1)
+		}
+	} else {
+		if name == "" {
+			name = path.Base(path.Clean(fp))
+		}
+		if outFile == "" {
+			outFile = name + ".zip"
+		}
+		mf, err = build(fp, outFile, name, version, ppfp, keyfp)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "build error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	v, err := json.MarshalIndent(mf, "", "\t")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "manifest error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(string(v))
+}
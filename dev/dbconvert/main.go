@@ -13,8 +13,10 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/grassrootseconomics/go-vise/asm"
 	"github.com/grassrootseconomics/go-vise/db"
 	fsdb "github.com/grassrootseconomics/go-vise/db/fs"
+	"github.com/grassrootseconomics/go-vise/lang"
 	slogging "github.com/grassrootseconomics/go-vise/slog"
 )
 
@@ -36,12 +38,14 @@ var (
 type scanner struct {
 	ctx context.Context
 	db  db.Db
+	st  *asm.SymbolTable
 }
 
-func newScanner(ctx context.Context, db db.Db) (*scanner, error) {
+func newScanner(ctx context.Context, db db.Db, st *asm.SymbolTable) (*scanner, error) {
 	return &scanner{
 		ctx: ctx,
 		db:  db,
+		st:  st,
 	}, nil
 }
 
@@ -65,6 +69,9 @@ func (sc *scanner) Scan(fp string, d fs.DirEntry, err error) error {
 		return nil
 	}
 	sc.db.SetPrefix(db.DATATYPE_UNKNOWN)
+	if fx == asm.NODE_EXT {
+		return sc.scanNode(fp)
+	}
 	switch fx {
 	case binaryPrefix:
 		sc.db.SetPrefix(db.DATATYPE_BIN)
@@ -107,6 +114,37 @@ func (sc *scanner) Scan(fp string, d fs.DirEntry, err error) error {
 	return nil
 }
 
+// split a node definition file into bytecode, template and menu records.
+func (sc *scanner) scanNode(fp string) error {
+	v, err := os.ReadFile(fp)
+	if err != nil {
+		return err
+	}
+	nd, err := asm.NewAssembler(fp).WithSymbols(sc.st).ParseNode(string(v))
+	if err != nil {
+		return err
+	}
+	defer sc.db.SetLanguage(nil)
+	for _, e := range nd.Entries() {
+		var ln *lang.Language
+		if e.Language != "" {
+			l, err := lang.LanguageFromCode(e.Language)
+			if err != nil {
+				return err
+			}
+			ln = &l
+		}
+		logg.TraceCtxf(sc.ctx, "put node record", "node", nd.Sym, "typ", dbg[e.Type], "key", e.Key, "lang", e.Language)
+		sc.db.SetPrefix(e.Type)
+		sc.db.SetLanguage(ln)
+		err = sc.db.Put(sc.ctx, []byte(e.Key), e.Value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func main() {
 	var store db.Db
 	var err error
@@ -114,7 +152,9 @@ func main() {
 	var dbPath string
 	var dbFile string
 	var dbBackend string
+	var ppfp string
 	flag.StringVar(&dbPath, "d", "", "output directory")
+	flag.StringVar(&ppfp, "f", "", "symbol definitions to assemble node definition files with")
 	flag.StringVar(&dbBackend, "backend", "fs", "db backend. valid choices are: fs")
 	flag.Parse()
 
@@ -153,7 +193,16 @@ func main() {
 	store.SetLock(db.DATATYPE_MENU, false)
 	store.SetLock(db.DATATYPE_STATICLOAD, false)
 
-	o, err := newScanner(ctx, store)
+	st := asm.NewSymbolTable()
+	if ppfp != "" {
+		_, err = st.Load(ppfp)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "symbol load error: %v", err)
+			os.Exit(1)
+		}
+	}
+
+	o, err := newScanner(ctx, store, st)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "failed to open scanner")
 		os.Exit(1)
@@ -1,24 +1,75 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path"
 
+	"github.com/grassrootseconomics/go-vise/asm"
 	"github.com/grassrootseconomics/go-vise/vm"
 )
 
 func main() {
-	if len(os.Args) < 2 {
+	var smfp string
+	var ppfp string
+	var batch bool
+	flag.StringVar(&smfp, "m", "", "source map to annotate instructions with")
+	flag.BoolVar(&batch, "b", false, "restore menu batch instructions, generating code that assembles to the same bytecode")
+	flag.StringVar(&ppfp, "f", "", "flag definitions to restore flag names with, implies -b")
+	flag.Parse()
+	if len(flag.Args()) < 1 {
 		os.Exit(1)
 	}
-	fp := os.Args[1]
+	fp := flag.Arg(0)
 	v, err := ioutil.ReadFile(fp)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "read error: %v", err)
 		os.Exit(1)
 	}
+	if batch || ppfp != "" {
+		if smfp != "" {
+			fmt.Fprintf(os.Stderr, "source map cannot be used with -b or -f")
+			os.Exit(1)
+		}
+		da := asm.NewDisassembler()
+		if ppfp != "" {
+			pp := asm.NewFlagParser()
+			_, err = pp.Load(ppfp)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "flag definitions load error: %v", err)
+				os.Exit(1)
+			}
+			da = da.WithFlags(pp)
+		}
+		r, err := da.Disassemble(v)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "parse error: %v", err)
+			os.Exit(1)
+		}
+		fmt.Print(r)
+		return
+	}
 	ph := vm.NewParseHandler().WithDefaultHandlers()
+	if smfp != "" {
+		b, err := ioutil.ReadFile(smfp)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "source map read error: %v", err)
+			os.Exit(1)
+		}
+		sm := &vm.SourceMap{}
+		err = sm.UnmarshalText(b)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "source map parse error: %v", err)
+			os.Exit(1)
+		}
+		src, err := ioutil.ReadFile(path.Join(path.Dir(smfp), sm.File))
+		if err == nil {
+			sm = sm.WithSource(string(src))
+		}
+		ph = ph.WithSourceMap(sm)
+	}
 	r, err := ph.ToString(v)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "parse error: %v", err)
@@ -7,6 +7,7 @@ import (
 	"os"
 
 	"github.com/grassrootseconomics/go-vise/db"
+	"github.com/grassrootseconomics/go-vise/db/bundle"
 	fsdb "github.com/grassrootseconomics/go-vise/db/fs"
 	"github.com/grassrootseconomics/go-vise/engine"
 	"github.com/grassrootseconomics/go-vise/persist"
@@ -20,13 +21,18 @@ func main() {
 	var sessionId string
 	var persistDir string
 	var initial string
+	var bundleFile string
 	flag.StringVar(&dir, "d", ".", "resource dir to read from")
+	flag.StringVar(&bundleFile, "bundle", "", "application bundle to read resources from instead of resource dir")
 	flag.UintVar(&size, "s", 0, "max size of output")
 	flag.StringVar(&root, "root", "root", "entry point symbol")
 	flag.StringVar(&sessionId, "session-id", "default", "session id")
 	flag.StringVar(&persistDir, "p", "", "state persistence directory")
 	flag.StringVar(&initial, "initial", "", "initial input to pass to engine initialization")
 	flag.Parse()
+	if bundleFile != "" {
+		dir = bundleFile
+	}
 	fmt.Fprintf(os.Stderr, "starting session at symbol '%s' using resource dir: %s\n", root, dir)
 
 	ctx := context.Background()
@@ -35,7 +41,11 @@ func main() {
 		SessionId:  sessionId,
 	}
 
-	rsStore := fsdb.NewFsDb()
+	var rsStore db.Db
+	rsStore = fsdb.NewFsDb()
+	if bundleFile != "" {
+		rsStore = bundle.NewBundleDb()
+	}
 	err := rsStore.Connect(ctx, dir)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "resource db connect error: %v", err)
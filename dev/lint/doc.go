@@ -0,0 +1,2 @@
+// Executable lint reports likely mistakes in the bytecode of a resource directory that the assembler accepts.
+package main
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/grassrootseconomics/go-vise/asm"
+	"github.com/grassrootseconomics/go-vise/db"
+	fsdb "github.com/grassrootseconomics/go-vise/db/fs"
+	"github.com/grassrootseconomics/go-vise/debug"
+	"github.com/grassrootseconomics/go-vise/lang"
+	"github.com/grassrootseconomics/go-vise/resource"
+	"github.com/grassrootseconomics/go-vise/vm"
+)
+
+type langVar struct {
+	v []lang.Language
+}
+
+func (lv *langVar) Set(s string) error {
+	v, err := lang.LanguageFromCode(s)
+	if err != nil {
+		return err
+	}
+	lv.v = append(lv.v, v)
+	return err
+}
+
+func (lv *langVar) String() string {
+	var s []string
+	for _, v := range lv.v {
+		s = append(s, v.Code)
+	}
+	return strings.Join(s, ",")
+}
+
+func main() {
+	var dir string
+	var root string
+	var ppfp string
+	var langs langVar
+	var asJson bool
+	flag.StringVar(&dir, "d", ".", "resource dir to read from")
+	flag.StringVar(&root, "root", "root", "entry point symbol")
+	flag.StringVar(&ppfp, "f", "", "flag definitions to check for unused flags")
+	flag.Var(&langs, "l", "check static content for language")
+	flag.BoolVar(&asJson, "json", false, "output diagnostics as json, one per line")
+	flag.Parse()
+
+	ctx := context.Background()
+	rsStore := fsdb.NewFsDb()
+	err := rsStore.Connect(ctx, dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "resource db connect error: %v\n", err)
+		os.Exit(1)
+	}
+	defer rsStore.Close(ctx)
+	rs := resource.NewDbResource(rsStore).With(db.DATATYPE_STATICLOAD)
+
+	li := debug.NewLinter(rs).WithLanguages(langs.v...).WithSourceMaps(vm.NewDirSourceMapFunc(dir))
+	if ppfp != "" {
+		pp := asm.NewFlagParser()
+		_, err = pp.Load(ppfp)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "flag definitions load error: %v\n", err)
+			os.Exit(1)
+		}
+		li = li.WithFlags(pp)
+	}
+
+	r, err := li.Run(ctx, root)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "lint error: %v\n", err)
+		os.Exit(1)
+	}
+	enc := json.NewEncoder(os.Stdout)
+	for _, d := range r {
+		if d.Node == "" && d.File == "" {
+			d.File = ppfp
+		}
+		if asJson {
+			err = enc.Encode(d)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "output error: %v\n", err)
+				os.Exit(1)
+			}
+			continue
+		}
+		fmt.Println(d)
+	}
+	if len(r) > 0 {
+		fmt.Fprintf(os.Stderr, "%d problems found\n", len(r))
+		os.Exit(1)
+	}
+}
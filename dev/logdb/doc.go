@@ -0,0 +1,6 @@
+// Executable logdb lists, filters and exports the entries of a log database written by the db/log wrapper.
+//
+// State entries are decoded to show the flags changed since the previous state entry of the session. Sealed or encrypted state is decoded with the keys given by the -hmac-key and -aes-key flags. Without -hmac-key, state with a HMAC is decoded without verification.
+//
+// With the -prune flag, entries outside the given retention policy are removed instead.
+package main
@@ -0,0 +1,424 @@
+package main
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/grassrootseconomics/go-vise/asm"
+	"github.com/grassrootseconomics/go-vise/db"
+	fsdb "github.com/grassrootseconomics/go-vise/db/fs"
+	logdb "github.com/grassrootseconomics/go-vise/db/log"
+	"github.com/grassrootseconomics/go-vise/db/postgres"
+	"github.com/grassrootseconomics/go-vise/persist"
+	"github.com/grassrootseconomics/go-vise/state"
+)
+
+// record is the exported form of a single log entry.
+type record struct {
+	When       time.Time `json:"time"`
+	SessionId  string    `json:"session"`
+	Type       string    `json:"type"`
+	Key        string    `json:"key"`
+	Lang       string    `json:"lang,omitempty"`
+	Value      string    `json:"value,omitempty"`
+	Path       string    `json:"path,omitempty"`
+	FlagsSet   []string  `json:"flags_set,omitempty"`
+	FlagsReset []string  `json:"flags_reset,omitempty"`
+}
+
+var (
+	csvHeader = []string{"time", "session", "type", "key", "lang", "value", "path", "flags_set", "flags_reset"}
+)
+
+func (r record) csv() []string {
+	return []string{
+		r.When.Format(time.RFC3339Nano),
+		r.SessionId,
+		r.Type,
+		r.Key,
+		r.Lang,
+		r.Value,
+		r.Path,
+		strings.Join(r.FlagsSet, " "),
+		strings.Join(r.FlagsReset, " "),
+	}
+}
+
+func (r record) String() string {
+	s := fmt.Sprintf("%s %s %s %s", r.When.Format(time.RFC3339Nano), r.SessionId, r.Type, r.Key)
+	if r.Lang != "" {
+		s += " (" + r.Lang + ")"
+	}
+	if r.Path != "" {
+		s += " path: " + r.Path
+	}
+	if len(r.FlagsSet) > 0 {
+		s += " set: " + strings.Join(r.FlagsSet, ",")
+	}
+	if len(r.FlagsReset) > 0 {
+		s += " reset: " + strings.Join(r.FlagsReset, ",")
+	}
+	if r.Value != "" {
+		s += " value: " + strconv.Quote(r.Value)
+	}
+	return s
+}
+
+// writer outputs records in the chosen format.
+type writer interface {
+	Write(record) error
+	Flush() error
+}
+
+type textWriter struct {
+	w io.Writer
+}
+
+func (tw *textWriter) Write(r record) error {
+	_, err := fmt.Fprintln(tw.w, r)
+	return err
+}
+
+func (tw *textWriter) Flush() error {
+	return nil
+}
+
+type jsonWriter struct {
+	enc *json.Encoder
+}
+
+func (jw *jsonWriter) Write(r record) error {
+	return jw.enc.Encode(r)
+}
+
+func (jw *jsonWriter) Flush() error {
+	return nil
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (cw *csvWriter) Write(r record) error {
+	return cw.w.Write(r.csv())
+}
+
+func (cw *csvWriter) Flush() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+func newWriter(format string, w io.Writer) (writer, error) {
+	switch format {
+	case "text":
+		return &textWriter{w: w}, nil
+	case "jsonl":
+		return &jsonWriter{enc: json.NewEncoder(w)}, nil
+	case "csv":
+		cw := csv.NewWriter(w)
+		err := cw.Write(csvHeader)
+		if err != nil {
+			return nil, err
+		}
+		return &csvWriter{w: cw}, nil
+	}
+	return nil, fmt.Errorf("unknown format: %s", format)
+}
+
+// differ tracks the last decoded state of each session.
+type differ struct {
+	last    map[string]*state.State
+	hmacKey []byte
+	aead    cipher.AEAD
+}
+
+func newDiffer() *differ {
+	return &differ{
+		last: make(map[string]*state.State),
+	}
+}
+
+// withHmac sets the key to verify the hmac of persisted data with.
+func (df *differ) withHmac(k []byte) *differ {
+	df.hmacKey = k
+	return df
+}
+
+// withCipher sets the cipher to decrypt encrypted persisted data with.
+func (df *differ) withCipher(aead cipher.AEAD) *differ {
+	df.aead = aead
+	return df
+}
+
+// apply decodes a persisted state entry, and adds the changes since the previous state entry of the same session to the record.
+func (df *differ) apply(r *record, v []byte) error {
+	var bitSize uint32
+	pe := persist.NewPersister(nil)
+	if df.hmacKey != nil {
+		pe = pe.WithHmac(df.hmacKey)
+	} else {
+		pe = pe.WithUnverifiedHmac()
+	}
+	if df.aead != nil {
+		pe = pe.WithCipher(df.aead)
+	}
+	err := pe.Deserialize(v)
+	if err != nil {
+		return err
+	}
+	st := pe.GetState()
+	if st == nil {
+		return fmt.Errorf("no state in entry")
+	}
+	r.Path = strings.Join(st.ExecPath, "/")
+	prev, ok := df.last[r.SessionId]
+	if !ok {
+		prev = state.NewState(0)
+	}
+	bitSize = st.BitSize
+	if prev.BitSize > bitSize {
+		bitSize = prev.BitSize
+	}
+	for i := uint32(0); i < bitSize; i++ {
+		now := i < st.BitSize && st.GetFlag(i)
+		was := i < prev.BitSize && prev.GetFlag(i)
+		if now && !was {
+			r.FlagsSet = append(r.FlagsSet, flagName(i))
+		} else if was && !now {
+			r.FlagsReset = append(r.FlagsReset, flagName(i))
+		}
+	}
+	df.last[r.SessionId] = st
+	return nil
+}
+
+func flagName(idx uint32) string {
+	return fmt.Sprintf("%s(%d)", state.FlagDebugger.Name(idx), idx)
+}
+
+func parseTypes(s string) (uint8, error) {
+	var r uint8
+	if s == "" {
+		return 0, nil
+	}
+	for _, v := range strings.Split(s, ",") {
+		var ok bool
+		for k, name := range logdb.DatatypeName {
+			if name == v {
+				r |= k
+				ok = true
+			}
+		}
+		if !ok {
+			return 0, fmt.Errorf("unknown datatype: %s", v)
+		}
+	}
+	return r, nil
+}
+
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+func toRecord(e *logdb.LogEntry) record {
+	r := record{
+		When:      e.When,
+		SessionId: e.SessionId,
+		Type:      logdb.DatatypeName[e.Pfx],
+		Key:       string(e.Key),
+		Lang:      e.Lang,
+	}
+	if e.IsSummary() {
+		r.Type = "summary"
+	} else if r.Type == "" {
+		r.Type = strconv.Itoa(int(e.Pfx))
+	}
+	if e.Pfx != db.DATATYPE_STATE {
+		if utf8.Valid(e.Val) {
+			r.Value = string(e.Val)
+		} else {
+			r.Value = hex.EncodeToString(e.Val)
+		}
+	}
+	return r
+}
+
+func main() {
+	var store db.Db
+	var dbBackend string
+	var dbSchema string
+	var sessionId string
+	var typs string
+	var since string
+	var until string
+	var format string
+	var flagFile string
+	var raw bool
+	var hmacKey string
+	var aesKey string
+	var prune bool
+	var policy logdb.Retention
+	flag.StringVar(&dbBackend, "backend", "fs", "log db backend. valid choices are: fs, postgres")
+	flag.StringVar(&dbSchema, "schema", "public", "postgres schema of the log db")
+	flag.StringVar(&sessionId, "session", "", "only list entries for session")
+	flag.StringVar(&typs, "type", "", "only list entries for comma-separated datatypes (bin, menu, template, staticload, state, userdata)")
+	flag.StringVar(&since, "since", "", "only list entries at or after RFC3339 time")
+	flag.StringVar(&until, "until", "", "only list entries before RFC3339 time")
+	flag.StringVar(&format, "format", "text", "output format. valid choices are: text, jsonl, csv")
+	flag.StringVar(&flagFile, "f", "", "flag csv file used to name user flags")
+	flag.BoolVar(&raw, "raw", false, "do not decode state entries")
+	flag.StringVar(&hmacKey, "hmac-key", "", "hex encoded key to verify persisted state hmac with")
+	flag.StringVar(&aesKey, "aes-key", "", "hex encoded key to decrypt AES-GCM encrypted persisted state with")
+	flag.BoolVar(&prune, "prune", false, "remove entries outside the retention policy instead of listing")
+	flag.DurationVar(&policy.MaxAge, "max-age", 0, "prune entries older than duration")
+	flag.IntVar(&policy.MaxEntries, "max-entries", 0, "prune oldest entries exceeding count per session")
+	flag.IntVar(&policy.MaxSize, "max-size", 0, "prune oldest entries exceeding total byte size")
+	flag.BoolVar(&policy.Compact, "compact", false, "replace pruned entries with a summary record per session")
+	flag.Parse()
+
+	ctx := context.Background()
+	if flag.NArg() < 1 {
+		fmt.Fprintf(os.Stderr, "usage: %s [options] <connection>\n", os.Args[0])
+		os.Exit(1)
+	}
+	connStr := flag.Arg(0)
+
+	switch dbBackend {
+	case "fs":
+		store = fsdb.NewFsDb()
+	case "postgres":
+		store = postgres.NewPgDb().WithSchema(dbSchema)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown backend: %s\n", dbBackend)
+		os.Exit(1)
+	}
+
+	if flagFile != "" {
+		pp := asm.NewFlagParser().WithDebug()
+		_, err := pp.Load(flagFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "flag file load error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	pfx, err := parseTypes(typs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	filter := logdb.Filter{
+		SessionId: sessionId,
+		Pfx:       pfx,
+	}
+	filter.Since, err = parseTime(since)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid since time: %v\n", err)
+		os.Exit(1)
+	}
+	filter.Until, err = parseTime(until)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid until time: %v\n", err)
+		os.Exit(1)
+	}
+
+	w, err := newWriter(format, os.Stdout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	err = store.Connect(ctx, connStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to connect to log db: %v\n", err)
+		os.Exit(1)
+	}
+	defer store.Close(ctx)
+
+	if prune {
+		stats, err := logdb.Prune(ctx, store, policy, time.Now())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "prune error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "pruned %s\n", stats)
+		return
+	}
+
+	df := newDiffer()
+	if hmacKey != "" {
+		k, err := hex.DecodeString(hmacKey)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid hmac key: %v\n", err)
+			os.Exit(1)
+		}
+		df = df.withHmac(k)
+	}
+	if aesKey != "" {
+		k, err := hex.DecodeString(aesKey)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid aes key: %v\n", err)
+			os.Exit(1)
+		}
+		blk, err := aes.NewCipher(k)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid aes key: %v\n", err)
+			os.Exit(1)
+		}
+		aead, err := cipher.NewGCM(blk)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "cipher error: %v\n", err)
+			os.Exit(1)
+		}
+		df = df.withCipher(aead)
+	}
+	rd := logdb.NewReader(store).WithFilter(filter)
+	defer rd.Close()
+	for {
+		e, err := rd.Next(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "log read error: %v\n", err)
+			os.Exit(1)
+		}
+		if e == nil {
+			break
+		}
+		r := toRecord(e)
+		if e.Pfx == db.DATATYPE_STATE {
+			if raw {
+				r.Value = hex.EncodeToString(e.Val)
+			} else {
+				err = df.apply(&r, e.Val)
+				if err != nil {
+					r.Value = hex.EncodeToString(e.Val)
+					fmt.Fprintf(os.Stderr, "state decode failed for %s/%s: %v\n", r.SessionId, r.Key, err)
+				}
+			}
+		}
+		err = w.Write(r)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "write error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	err = w.Flush()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "write error: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,2 @@
+// Executable lsp is a language server for vise assembly code, communicating with the editor on standard input and output.
+package main
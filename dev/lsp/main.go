@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+func main() {
+	var dir string
+	var ppfp string
+	flag.StringVar(&dir, "d", "", "resource dir with assembly code. defaults to the workspace root of the editor")
+	flag.StringVar(&ppfp, "f", "", "symbol definitions to load, relative to the resource dir")
+	flag.Parse()
+
+	sv := newServer(os.Stdout, dir, ppfp)
+	r := bufio.NewReader(os.Stdin)
+	for {
+		req, err := readMessage(r)
+		if err != nil {
+			if err == io.EOF {
+				os.Exit(1)
+			}
+			fmt.Fprintf(os.Stderr, "read error: %v\n", err)
+			os.Exit(1)
+		}
+		if req.Method == "exit" {
+			if sv.shutdown {
+				os.Exit(0)
+			}
+			os.Exit(1)
+		}
+		result, rerr := sv.handle(req)
+		if req.Id == nil {
+			continue
+		}
+		res := response{
+			Jsonrpc: "2.0",
+			Id:      req.Id,
+			Error:   rerr,
+		}
+		if rerr == nil {
+			res.Result, err = json.Marshal(result)
+			if err != nil {
+				res.Error = &rpcError{Code: rpcInternalError, Message: err.Error()}
+			}
+		}
+		err = writeMessage(os.Stdout, res)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "write error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+}
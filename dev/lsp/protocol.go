@@ -0,0 +1,156 @@
+package main
+
+// Subset of the Language Server Protocol types used by the server.
+
+import (
+	"unicode/utf16"
+)
+
+const (
+	severityError   = 1
+	severityWarning = 2
+
+	completionFunction = 3
+	completionConstant = 21
+	completionFile     = 17
+	completionVariable = 6
+	completionKeyword  = 14
+
+	syncFull = 1
+)
+
+type position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// character of the byte offset in the line.
+//
+// Characters of positions are counted in UTF-16 code units.
+func toCharacter(line string, offset int) int {
+	if offset > len(line) {
+		offset = len(line)
+	}
+	if offset < 0 {
+		return 0
+	}
+	var c int
+	for _, r := range line[:offset] {
+		c += utf16.RuneLen(r)
+	}
+	return c
+}
+
+// byte offset in the line of the character.
+func fromCharacter(line string, c int) int {
+	var n int
+	for i, r := range line {
+		if n >= c {
+			return i
+		}
+		n += utf16.RuneLen(r)
+	}
+	return len(line)
+}
+
+type lspRange struct {
+	Start position `json:"start"`
+	End   position `json:"end"`
+}
+
+type location struct {
+	Uri   string   `json:"uri"`
+	Range lspRange `json:"range"`
+}
+
+type textDocumentIdentifier struct {
+	Uri string `json:"uri"`
+}
+
+type textDocumentItem struct {
+	Uri  string `json:"uri"`
+	Text string `json:"text"`
+}
+
+type textDocumentPositionParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+	Position     position               `json:"position"`
+}
+
+type initializeParams struct {
+	RootUri string `json:"rootUri"`
+}
+
+type didOpenParams struct {
+	TextDocument textDocumentItem `json:"textDocument"`
+}
+
+type didChangeParams struct {
+	TextDocument   textDocumentIdentifier `json:"textDocument"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+}
+
+type didCloseParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+}
+
+type renameParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+	Position     position               `json:"position"`
+	NewName      string                 `json:"newName"`
+}
+
+type diagnostic struct {
+	Range    lspRange `json:"range"`
+	Severity int      `json:"severity"`
+	Source   string   `json:"source"`
+	Message  string   `json:"message"`
+}
+
+type publishDiagnosticsParams struct {
+	Uri         string       `json:"uri"`
+	Diagnostics []diagnostic `json:"diagnostics"`
+}
+
+type completionItem struct {
+	Label  string `json:"label"`
+	Kind   int    `json:"kind"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type markupContent struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+type hover struct {
+	Contents markupContent `json:"contents"`
+	Range    lspRange      `json:"range"`
+}
+
+type textEdit struct {
+	Range   lspRange `json:"range"`
+	NewText string   `json:"newText"`
+}
+
+type versionedTextDocumentIdentifier struct {
+	Uri     string `json:"uri"`
+	Version *int   `json:"version"`
+}
+
+type textDocumentEdit struct {
+	TextDocument versionedTextDocumentIdentifier `json:"textDocument"`
+	Edits        []textEdit                      `json:"edits"`
+}
+
+type renameFile struct {
+	Kind   string `json:"kind"`
+	OldUri string `json:"oldUri"`
+	NewUri string `json:"newUri"`
+}
+
+type workspaceEdit struct {
+	DocumentChanges []any `json:"documentChanges"`
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestMessageFraming(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	err := writeMessage(b, notification{
+		Jsonrpc: "2.0",
+		Method:  "window/logMessage",
+		Params:  map[string]string{"message": "ĉapelo 😀"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = writeMessage(b, notification{
+		Jsonrpc: "2.0",
+		Method:  "exit",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := bufio.NewReader(b)
+	req, err := readMessage(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Method != "window/logMessage" {
+		t.Fatalf("expected 'window/logMessage', got '%s'", req.Method)
+	}
+	var p map[string]string
+	err = json.Unmarshal(req.Params, &p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p["message"] != "ĉapelo 😀" {
+		t.Fatalf("expected 'ĉapelo 😀', got '%s'", p["message"])
+	}
+	req, err = readMessage(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Method != "exit" {
+		t.Fatalf("expected 'exit', got '%s'", req.Method)
+	}
+	_, err = readMessage(r)
+	if err != io.EOF {
+		t.Fatalf("expected EOF, got %v", err)
+	}
+}
+
+func TestMessageFramingInvalid(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("Content-Length: foo\r\n\r\n{}"))
+	_, err := readMessage(r)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	r = bufio.NewReader(strings.NewReader("Content-Length: 42\r\n\r\n{}"))
+	_, err = readMessage(r)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,539 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/grassrootseconomics/go-vise/asm"
+	"github.com/grassrootseconomics/go-vise/vm"
+)
+
+var (
+	directives = []string{"INCLUDE", "MACRO", "ENDMACRO", "DEFINE"}
+	kindNames  = map[asm.ArgKind]string{
+		asm.ARG_NODE:     "node",
+		asm.ARG_SYMBOL:   "symbol",
+		asm.ARG_FLAG:     "flag",
+		asm.ARG_SIZE:     "size",
+		asm.ARG_SELECTOR: "selector",
+		asm.ARG_LABEL:    "label",
+		asm.ARG_MODE:     "mode",
+	}
+)
+
+type server struct {
+	root     string
+	flagFile string
+	docs     map[string]string
+	out      io.Writer
+	shutdown bool
+}
+
+func newServer(out io.Writer, root string, flagFile string) *server {
+	return &server{
+		root:     root,
+		flagFile: flagFile,
+		docs:     make(map[string]string),
+		out:      out,
+	}
+}
+
+func (sv *server) notify(method string, params any) error {
+	return writeMessage(sv.out, notification{
+		Jsonrpc: "2.0",
+		Method:  method,
+		Params:  params,
+	})
+}
+
+// handle a request, and return the result.
+func (sv *server) handle(req *request) (any, *rpcError) {
+	var err error
+	switch req.Method {
+	case "initialize":
+		var p initializeParams
+		err = json.Unmarshal(req.Params, &p)
+		if err != nil {
+			break
+		}
+		if p.RootUri != "" && sv.root == "" {
+			sv.root = uriToPath(p.RootUri)
+		}
+		return map[string]any{
+			"capabilities": map[string]any{
+				"textDocumentSync":   syncFull,
+				"completionProvider": map[string]any{},
+				"definitionProvider": true,
+				"hoverProvider":      true,
+				"renameProvider":     true,
+			},
+			"serverInfo": map[string]string{
+				"name": "vise-lsp",
+			},
+		}, nil
+	case "initialized":
+		return nil, nil
+	case "shutdown":
+		sv.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var p didOpenParams
+		err = json.Unmarshal(req.Params, &p)
+		if err != nil {
+			break
+		}
+		fp := uriToPath(p.TextDocument.Uri)
+		sv.docs[fp] = p.TextDocument.Text
+		err = sv.publish(fp)
+	case "textDocument/didChange":
+		var p didChangeParams
+		err = json.Unmarshal(req.Params, &p)
+		if err != nil {
+			break
+		}
+		fp := uriToPath(p.TextDocument.Uri)
+		for _, v := range p.ContentChanges {
+			sv.docs[fp] = v.Text
+		}
+		err = sv.publish(fp)
+	case "textDocument/didClose":
+		var p didCloseParams
+		err = json.Unmarshal(req.Params, &p)
+		if err != nil {
+			break
+		}
+		delete(sv.docs, uriToPath(p.TextDocument.Uri))
+	case "textDocument/completion":
+		var p textDocumentPositionParams
+		err = json.Unmarshal(req.Params, &p)
+		if err != nil {
+			break
+		}
+		return sv.completion(uriToPath(p.TextDocument.Uri), p.Position), nil
+	case "textDocument/definition":
+		var p textDocumentPositionParams
+		err = json.Unmarshal(req.Params, &p)
+		if err != nil {
+			break
+		}
+		return sv.definition(uriToPath(p.TextDocument.Uri), p.Position), nil
+	case "textDocument/hover":
+		var p textDocumentPositionParams
+		err = json.Unmarshal(req.Params, &p)
+		if err != nil {
+			break
+		}
+		r := sv.hover(uriToPath(p.TextDocument.Uri), p.Position)
+		if r == nil {
+			return nil, nil
+		}
+		return r, nil
+	case "textDocument/rename":
+		var p renameParams
+		err = json.Unmarshal(req.Params, &p)
+		if err != nil {
+			break
+		}
+		r, err := sv.rename(uriToPath(p.TextDocument.Uri), p.Position, p.NewName)
+		if err != nil {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+		}
+		return r, nil
+	default:
+		if strings.HasPrefix(req.Method, "$/") || req.Id == nil {
+			return nil, nil
+		}
+		return nil, &rpcError{Code: rpcMethodNotFound, Message: fmt.Sprintf("method not found: %s", req.Method)}
+	}
+	if err != nil {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+	}
+	return nil, nil
+}
+
+// range covering the token on the line with the given index.
+func tokenRange(i int, line string, t asm.Token) lspRange {
+	return lspRange{
+		Start: position{Line: i, Character: toCharacter(line, t.Column-1)},
+		End:   position{Line: i, Character: toCharacter(line, t.Column-1+len(t.Text))},
+	}
+}
+
+// tokens of the line at the position, and the index of the token under the position, or -1.
+func tokenAt(text string, pos position) ([]asm.Token, int) {
+	lines := strings.Split(text, "\n")
+	if pos.Line >= len(lines) {
+		return nil, -1
+	}
+	tk := asm.Tokenize(lines[pos.Line])
+	c := fromCharacter(lines[pos.Line], pos.Character)
+	for i, t := range tk {
+		if c >= t.Column-1 && c <= t.Column-1+len(t.Text) {
+			return tk, i
+		}
+	}
+	return tk, -1
+}
+
+// kind of the argument at the token index, or 0 if not an instruction argument.
+func kindAt(tk []asm.Token, idx int) asm.ArgKind {
+	if idx < 1 {
+		return 0
+	}
+	kinds, _ := asm.ArgKinds(tk[0].Text)
+	if idx-1 >= len(kinds) {
+		return 0
+	}
+	return kinds[idx-1]
+}
+
+// assemble the document, and return the symbol table with its definitions, and diagnostics.
+func (sv *server) analyze(fp string) (*asm.SymbolTable, []diagnostic) {
+	diags := []diagnostic{}
+	text, err := sv.read(fp)
+	if err != nil {
+		return asm.NewSymbolTable(), diags
+	}
+	st, err := sv.symbols()
+	if err != nil {
+		diags = append(diags, diagnostic{
+			Severity: severityError,
+			Source:   "vise",
+			Message:  fmt.Sprintf("flag definitions %s: %v", sv.flagPath(), err),
+		})
+	}
+	ex := asm.NewExpander().WithReader(sv.read)
+	_, err = asm.NewAssembler(fp).WithExpander(ex).WithSymbols(st).Parse(string(text), io.Discard)
+	if err != nil {
+		d := diagnostic{
+			Severity: severityError,
+			Source:   "vise",
+			Message:  err.Error(),
+		}
+		e, ok := err.(*asm.Error)
+		if ok && e.File == fp {
+			d.Message = e.Err.Error()
+			lines := strings.Split(string(text), "\n")
+			if e.Line > 0 && e.Line <= len(lines) {
+				c := toCharacter(lines[e.Line-1], e.Column-1)
+				d.Range.Start = position{Line: e.Line - 1, Character: c}
+				d.Range.End = position{Line: e.Line - 1, Character: c}
+			}
+		}
+		diags = append(diags, d)
+	}
+
+	for i, s := range strings.Split(string(text), "\n") {
+		tk := asm.Tokenize(s)
+		for j, t := range tk {
+			if kindAt(tk, j) != asm.ARG_NODE || isRelative(t.Text) || strings.HasPrefix(t.Text, "$") || sv.hasNode(t.Text) {
+				continue
+			}
+			diags = append(diags, diagnostic{
+				Range:    tokenRange(i, s, t),
+				Severity: severityWarning,
+				Source:   "vise",
+				Message:  fmt.Sprintf("node %s has no assembly code", t.Text),
+			})
+		}
+	}
+	return st, diags
+}
+
+// publish diagnostics for the document.
+func (sv *server) publish(fp string) error {
+	_, diags := sv.analyze(fp)
+	return sv.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+		Uri:         pathToUri(fp),
+		Diagnostics: diags,
+	})
+}
+
+func items(names []string, kind int, detail string) []completionItem {
+	var r []completionItem
+	for _, v := range names {
+		r = append(r, completionItem{
+			Label:  v,
+			Kind:   kind,
+			Detail: detail,
+		})
+	}
+	return r
+}
+
+// signature of the instruction, with the kinds of its arguments.
+func signature(op string) string {
+	kinds, ok := asm.ArgKinds(op)
+	if !ok {
+		return op
+	}
+	s := op
+	for _, v := range kinds {
+		s += " <" + kindNames[v] + ">"
+	}
+	return s
+}
+
+func (sv *server) completion(fp string, pos position) []completionItem {
+	r := []completionItem{}
+	b, err := sv.read(fp)
+	if err != nil {
+		return r
+	}
+	text := string(b)
+	lines := strings.Split(text, "\n")
+	if pos.Line >= len(lines) {
+		return r
+	}
+	line := lines[pos.Line]
+	line = line[:fromCharacter(line, pos.Character)]
+	if strings.Contains(line, "#") {
+		return r
+	}
+	tk := asm.Tokenize(line)
+	idx := len(tk)
+	if idx > 0 && !strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\t") {
+		idx -= 1
+	}
+
+	if idx == 0 {
+		for _, v := range asm.Instructions() {
+			r = append(r, completionItem{
+				Label:  v,
+				Kind:   completionFunction,
+				Detail: signature(v),
+			})
+		}
+		r = append(r, items(directives, completionKeyword, "directive")...)
+		return append(r, items(macros(text), completionFunction, "macro")...)
+	}
+
+	st, _ := sv.analyze(fp)
+	switch tk[0].Text {
+	case "INCLUDE":
+		return append(r, items(sv.glob(visExt), completionFile, "")...)
+	case "DEFINE":
+		if idx == 1 {
+			return append(r, items([]string{"flag", "size", "selector"}, completionKeyword, "")...)
+		}
+		return r
+	}
+	switch kindAt(tk, idx) {
+	case asm.ARG_NODE:
+		r = append(r, items(sv.nodes(), completionFile, "node")...)
+	case asm.ARG_SYMBOL:
+		r = append(r, items(sv.dataSymbols(), completionVariable, "static data")...)
+	case asm.ARG_FLAG:
+		for _, v := range st.Names() {
+			fl, _ := st.GetFlag(v)
+			r = append(r, completionItem{
+				Label:  v,
+				Kind:   completionConstant,
+				Detail: fmt.Sprintf("flag %d", fl),
+			})
+		}
+	case asm.ARG_SIZE:
+		for _, v := range st.SizeNames() {
+			n, _ := st.GetSize(v)
+			r = append(r, completionItem{
+				Label:  v,
+				Kind:   completionConstant,
+				Detail: fmt.Sprintf("size %d", n),
+			})
+		}
+	case asm.ARG_SELECTOR:
+		for _, v := range st.SelectorNames() {
+			s, _ := st.GetSelector(v)
+			r = append(r, completionItem{
+				Label:  v,
+				Kind:   completionConstant,
+				Detail: fmt.Sprintf("selector %s", s),
+			})
+		}
+	}
+	return r
+}
+
+// location of a named value defined in the document or in the flag definitions file.
+func (sv *server) definedAt(fp string, text string, kind string, name string) []location {
+	i := directiveLine(text, "DEFINE", name)
+	if i > -1 {
+		return []location{{Uri: pathToUri(fp), Range: lspRange{Start: position{Line: i}, End: position{Line: i}}}}
+	}
+	i = sv.definitionLine(kind, name)
+	if i > -1 {
+		return []location{{Uri: pathToUri(sv.flagPath()), Range: lspRange{Start: position{Line: i}, End: position{Line: i}}}}
+	}
+	return []location{}
+}
+
+func fileLocations(files []string) []location {
+	r := []location{}
+	for _, v := range files {
+		r = append(r, location{Uri: pathToUri(v)})
+	}
+	return r
+}
+
+func (sv *server) definition(fp string, pos position) []location {
+	b, err := sv.read(fp)
+	if err != nil {
+		return []location{}
+	}
+	text := string(b)
+	tk, idx := tokenAt(text, pos)
+	if idx < 0 {
+		return []location{}
+	}
+	sym := tk[idx].Text
+	if idx == 0 {
+		i := directiveLine(text, "MACRO", sym)
+		if i > -1 {
+			return []location{{Uri: pathToUri(fp), Range: lspRange{Start: position{Line: i}, End: position{Line: i}}}}
+		}
+		return []location{}
+	}
+	if tk[0].Text == "INCLUDE" {
+		return fileLocations([]string{path.Join(path.Dir(fp), sym)})
+	}
+	switch kindAt(tk, idx) {
+	case asm.ARG_NODE:
+		if isRelative(sym) {
+			break
+		}
+		return fileLocations(sv.nodeFiles(sym))
+	case asm.ARG_SYMBOL:
+		return fileLocations(sv.dataFiles(sym))
+	case asm.ARG_FLAG:
+		return sv.definedAt(fp, text, "flag", sym)
+	case asm.ARG_SIZE:
+		return sv.definedAt(fp, text, "size", sym)
+	case asm.ARG_SELECTOR:
+		return sv.definedAt(fp, text, "selector", sym)
+	}
+	return []location{}
+}
+
+func (sv *server) hover(fp string, pos position) *hover {
+	b, err := sv.read(fp)
+	if err != nil {
+		return nil
+	}
+	tk, idx := tokenAt(string(b), pos)
+	if idx < 0 {
+		return nil
+	}
+	sym := tk[idx].Text
+	var s string
+	if idx == 0 {
+		_, ok := asm.ArgKinds(sym)
+		if !ok {
+			return nil
+		}
+		s = "`" + signature(sym) + "`"
+	} else {
+		st, _ := sv.analyze(fp)
+		switch kindAt(tk, idx) {
+		case asm.ARG_NODE:
+			if isRelative(sym) {
+				return nil
+			}
+			s = fmt.Sprintf("node `%s`", sym)
+			tpl, err := os.ReadFile(path.Join(sv.root, sym))
+			if err == nil {
+				s += "\n\n```\n" + strings.TrimRight(string(tpl), "\n") + "\n```"
+			}
+		case asm.ARG_FLAG:
+			fl, err := st.GetFlag(sym)
+			if err != nil {
+				return nil
+			}
+			s = fmt.Sprintf("flag `%s` = %d", sym, fl)
+			desc, err := st.GetDescription(fl)
+			if err == nil {
+				s += "\n\n" + desc
+			}
+		case asm.ARG_SIZE:
+			n, err := st.GetSize(sym)
+			if err != nil {
+				return nil
+			}
+			s = fmt.Sprintf("size `%s` = %d", sym, n)
+		case asm.ARG_SELECTOR:
+			v, err := st.GetSelector(sym)
+			if err != nil {
+				return nil
+			}
+			s = fmt.Sprintf("selector `%s` = %s", sym, v)
+		default:
+			return nil
+		}
+	}
+	return &hover{
+		Contents: markupContent{
+			Kind:  "markdown",
+			Value: s,
+		},
+		Range: tokenRange(pos.Line, strings.Split(string(b), "\n")[pos.Line], tk[idx]),
+	}
+}
+
+// rename a node symbol in all assembly code in the workspace, and rename the files of the node.
+func (sv *server) rename(fp string, pos position, name string) (*workspaceEdit, error) {
+	b, err := sv.read(fp)
+	if err != nil {
+		return nil, err
+	}
+	tk, idx := tokenAt(string(b), pos)
+	if idx < 0 || kindAt(tk, idx) != asm.ARG_NODE || isRelative(tk[idx].Text) {
+		return nil, fmt.Errorf("only node symbols can be renamed")
+	}
+	sym := tk[idx].Text
+	err = vm.ValidSym([]byte(name))
+	if err != nil {
+		return nil, err
+	}
+	if sv.hasNode(name) {
+		return nil, fmt.Errorf("node %s already exists", name)
+	}
+
+	r := &workspaceEdit{}
+	for _, v := range sv.glob(visExt) {
+		vfp := path.Join(sv.root, v+visExt)
+		b, err := sv.read(vfp)
+		if err != nil {
+			return nil, err
+		}
+		var edits []textEdit
+		for i, s := range strings.Split(string(b), "\n") {
+			tk := asm.Tokenize(s)
+			for j, t := range tk {
+				if t.Text == sym && kindAt(tk, j) == asm.ARG_NODE {
+					edits = append(edits, textEdit{
+						Range:   tokenRange(i, s, t),
+						NewText: name,
+					})
+				}
+			}
+		}
+		if len(edits) > 0 {
+			r.DocumentChanges = append(r.DocumentChanges, textDocumentEdit{
+				TextDocument: versionedTextDocumentIdentifier{Uri: pathToUri(vfp)},
+				Edits:        edits,
+			})
+		}
+	}
+	for _, v := range sv.nodeFiles(sym) {
+		nfp := path.Join(path.Dir(v), name+strings.TrimPrefix(path.Base(v), sym))
+		r.DocumentChanges = append(r.DocumentChanges, renameFile{
+			Kind:   "rename",
+			OldUri: pathToUri(v),
+			NewUri: pathToUri(nfp),
+		})
+	}
+	return r, nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path"
+	"testing"
+)
+
+func newTestServer(t *testing.T) (*server, *bytes.Buffer) {
+	dir := t.TempDir()
+	err := os.WriteFile(path.Join(dir, "foo.vis"), []byte("HALT\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := bytes.NewBuffer(nil)
+	return newServer(b, dir, ""), b
+}
+
+func TestPositionCharacter(t *testing.T) {
+	line := "ĉ😀x"
+	for _, v := range [][2]int{{0, 0}, {2, 1}, {6, 3}, {7, 4}, {42, 4}, {-1, 0}} {
+		c := toCharacter(line, v[0])
+		if c != v[1] {
+			t.Fatalf("offset %d: expected character %d, got %d", v[0], v[1], c)
+		}
+	}
+	for _, v := range [][2]int{{0, 0}, {1, 2}, {3, 6}, {4, 7}, {42, 7}} {
+		i := fromCharacter(line, v[0])
+		if i != v[1] {
+			t.Fatalf("character %d: expected offset %d, got %d", v[0], v[1], i)
+		}
+	}
+}
+
+func TestServerTokenAt(t *testing.T) {
+	tk, idx := tokenAt("HALT\nINCMP nö😀 1\n", position{Line: 1, Character: 11})
+	if idx != 2 || tk[idx].Text != "1" {
+		t.Fatalf("expected selector token, got %d in %v", idx, tk)
+	}
+	tk, idx = tokenAt("HALT\nINCMP nö😀 1\n", position{Line: 1, Character: 9})
+	if idx != 1 || tk[idx].Text != "nö😀" {
+		t.Fatalf("expected node token, got %d in %v", idx, tk)
+	}
+}
+
+func TestServerCompletion(t *testing.T) {
+	sv, _ := newTestServer(t)
+	fp := path.Join(sv.root, "root.vis")
+	sv.docs[fp] = "INCMP 😀 1\n"
+
+	have := func(r []completionItem) bool {
+		for _, v := range r {
+			if v.Label == "foo" {
+				return true
+			}
+		}
+		return false
+	}
+	if !have(sv.completion(fp, position{Line: 0, Character: 8})) {
+		t.Fatal("expected node completion")
+	}
+	if have(sv.completion(fp, position{Line: 0, Character: 9})) {
+		t.Fatal("expected selector completion")
+	}
+}
+
+func TestServerDiagnostics(t *testing.T) {
+	sv, b := newTestServer(t)
+	fp := path.Join(sv.root, "root.vis")
+	params, err := json.Marshal(didOpenParams{
+		TextDocument: textDocumentItem{
+			Uri:  pathToUri(fp),
+			Text: "MOUT ĉapelo 0\nINCMP nö😀 1\nINCMP foo 2\nLOAD 😀 x\n",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, rerr := sv.handle(&request{Method: "textDocument/didOpen", Params: params})
+	if rerr != nil {
+		t.Fatal(rerr.Message)
+	}
+
+	req, err := readMessage(bufio.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Method != "textDocument/publishDiagnostics" {
+		t.Fatalf("expected 'textDocument/publishDiagnostics', got '%s'", req.Method)
+	}
+	var p publishDiagnosticsParams
+	err = json.Unmarshal(req.Params, &p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Uri != pathToUri(fp) {
+		t.Fatalf("expected uri '%s', got '%s'", pathToUri(fp), p.Uri)
+	}
+	expect := []diagnostic{
+		{
+			Range:    lspRange{Start: position{Line: 3, Character: 8}, End: position{Line: 3, Character: 8}},
+			Severity: severityError,
+		},
+		{
+			Range:    lspRange{Start: position{Line: 1, Character: 6}, End: position{Line: 1, Character: 10}},
+			Severity: severityWarning,
+		},
+	}
+	if len(p.Diagnostics) != len(expect) {
+		t.Fatalf("expected %d diagnostics, got %d: %v", len(expect), len(p.Diagnostics), p.Diagnostics)
+	}
+	for i, v := range expect {
+		d := p.Diagnostics[i]
+		if d.Range != v.Range || d.Severity != v.Severity {
+			t.Fatalf("diagnostic %d: expected %v severity %d, got %v", i, v.Range, v.Severity, d)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/grassrootseconomics/go-vise/asm"
+	"github.com/grassrootseconomics/go-vise/lang"
+)
+
+const (
+	visExt = ".vis"
+)
+
+// relative move targets, which are not node symbols.
+var relativeSyms = []string{"<", ">", "^", "_", "."}
+
+func isRelative(sym string) bool {
+	for _, v := range relativeSyms {
+		if sym == v {
+			return true
+		}
+	}
+	return false
+}
+
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return u.Path
+}
+
+func pathToUri(fp string) string {
+	u := url.URL{
+		Scheme: "file",
+		Path:   fp,
+	}
+	return u.String()
+}
+
+func exists(fp string) bool {
+	_, err := os.Stat(fp)
+	return err == nil
+}
+
+// basenames of files in the workspace with the given extension, without the extension.
+func (sv *server) glob(ext string) []string {
+	var r []string
+	seen := make(map[string]bool)
+	m, _ := filepath.Glob(path.Join(sv.root, "*"+ext))
+	for _, v := range m {
+		s := strings.TrimSuffix(path.Base(v), ext)
+		if !seen[s] {
+			seen[s] = true
+			r = append(r, s)
+		}
+	}
+	sort.Strings(r)
+	return r
+}
+
+// symbols of all nodes in the workspace, with assembly code or bytecode.
+func (sv *server) nodes() []string {
+	var r []string
+	seen := make(map[string]bool)
+	for _, ext := range []string{visExt, ".bin"} {
+		for _, v := range sv.glob(ext) {
+			if !seen[v] {
+				seen[v] = true
+				r = append(r, v)
+			}
+		}
+	}
+	sort.Strings(r)
+	return r
+}
+
+func (sv *server) hasNode(sym string) bool {
+	return exists(path.Join(sv.root, sym+visExt)) || exists(path.Join(sv.root, sym+".bin"))
+}
+
+// symbols of all static data in the workspace.
+func (sv *server) dataSymbols() []string {
+	r := sv.glob(".txt")
+	for _, v := range sv.glob(".txt.orig") {
+		i := sort.SearchStrings(r, v)
+		if i == len(r) || r[i] != v {
+			r = append(r, v)
+			sort.Strings(r)
+		}
+	}
+	return r
+}
+
+// files defining the node, its assembly code followed by its templates.
+func (sv *server) nodeFiles(sym string) []string {
+	var r []string
+	for _, v := range []string{sym + visExt, sym} {
+		fp := path.Join(sv.root, v)
+		if exists(fp) {
+			r = append(r, fp)
+		}
+	}
+	m, _ := filepath.Glob(path.Join(sv.root, sym+"_*"))
+	for _, v := range m {
+		code := strings.TrimPrefix(path.Base(v), sym+"_")
+		_, err := lang.LanguageFromCode(code)
+		if err == nil {
+			r = append(r, v)
+		}
+	}
+	return r
+}
+
+// files containing static content for the data symbol.
+func (sv *server) dataFiles(sym string) []string {
+	var r []string
+	for _, v := range []string{sym + ".txt", sym + ".txt.orig"} {
+		fp := path.Join(sv.root, v)
+		if exists(fp) {
+			r = append(r, fp)
+		}
+	}
+	return r
+}
+
+// contents of the file, from the open document if available.
+func (sv *server) read(fp string) ([]byte, error) {
+	s, ok := sv.docs[fp]
+	if ok {
+		return []byte(s), nil
+	}
+	return os.ReadFile(fp)
+}
+
+// path of the flag definitions file.
+func (sv *server) flagPath() string {
+	if sv.flagFile == "" || path.IsAbs(sv.flagFile) {
+		return sv.flagFile
+	}
+	return path.Join(sv.root, sv.flagFile)
+}
+
+// symbol table with the definitions in the flag definitions file.
+func (sv *server) symbols() (*asm.SymbolTable, error) {
+	st := asm.NewSymbolTable()
+	fp := sv.flagPath()
+	if fp == "" {
+		return st, nil
+	}
+	_, err := st.Load(fp)
+	return st, err
+}
+
+// line of a definition of the named value in the flag definitions file, or -1 if not found.
+func (sv *server) definitionLine(kind string, name string) int {
+	fp := sv.flagPath()
+	if fp == "" {
+		return -1
+	}
+	f, err := os.Open(fp)
+	if err != nil {
+		return -1
+	}
+	defer f.Close()
+	return findLine(f, func(s string) bool {
+		return strings.HasPrefix(s, kind+","+name+",")
+	})
+}
+
+// index of the first line matching the function, or -1.
+func findLine(r io.Reader, fn func(string) bool) int {
+	sc := bufio.NewScanner(r)
+	for i := 0; sc.Scan(); i++ {
+		if fn(sc.Text()) {
+			return i
+		}
+	}
+	return -1
+}
+
+// index of the line with a directive for the named value in the assembly code, or -1.
+func directiveLine(text string, directive string, name string) int {
+	return findLine(strings.NewReader(text), func(s string) bool {
+		tk := asm.Tokenize(s)
+		if len(tk) < 2 || tk[0].Text != directive {
+			return false
+		}
+		if directive == "DEFINE" {
+			return len(tk) > 2 && tk[2].Text == name
+		}
+		return tk[1].Text == name
+	})
+}
+
+// names of all macros defined in the assembly code.
+func macros(text string) []string {
+	var r []string
+	for _, s := range strings.Split(text, "\n") {
+		tk := asm.Tokenize(s)
+		if len(tk) > 1 && tk[0].Text == "MACRO" {
+			r = append(r, tk[1].Text)
+		}
+	}
+	return r
+}
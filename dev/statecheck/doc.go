@@ -0,0 +1,6 @@
+// Executable statecheck scans the persisted state entries of a database, and reports the sessions that would fail to load or migrate.
+//
+// No changes are written to the database.
+//
+// Migrations are registered in application code, so only version checks and decoding of the current format can be done without them. Applications may use persist.Check with their own persist.Migrator for a complete dry-run.
+package main
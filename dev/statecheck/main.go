@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/grassrootseconomics/go-vise/db"
+	fsdb "github.com/grassrootseconomics/go-vise/db/fs"
+	"github.com/grassrootseconomics/go-vise/db/postgres"
+	"github.com/grassrootseconomics/go-vise/persist"
+)
+
+func main() {
+	var store db.Db
+	var dbBackend string
+	var dbSchema string
+	var version uint
+	var all bool
+	var hmacKey string
+	var aesKey string
+	flag.StringVar(&dbBackend, "backend", "fs", "db backend. valid choices are: fs, postgres")
+	flag.StringVar(&dbSchema, "schema", "public", "postgres schema")
+	flag.UintVar(&version, "version", 0, "current data version of the application")
+	flag.BoolVar(&all, "a", false, "also list entries that load successfully")
+	flag.StringVar(&hmacKey, "hmac-key", "", "hex encoded key to verify persisted data hmac with")
+	flag.StringVar(&aesKey, "aes-key", "", "hex encoded key to decrypt AES-GCM encrypted persisted data with")
+	flag.Parse()
+
+	ctx := context.Background()
+	if flag.NArg() < 1 {
+		fmt.Fprintf(os.Stderr, "usage: %s [options] <connection>\n", os.Args[0])
+		os.Exit(1)
+	}
+	connStr := flag.Arg(0)
+
+	switch dbBackend {
+	case "fs":
+		store = fsdb.NewFsDb()
+	case "postgres":
+		store = postgres.NewPgDb().WithSchema(dbSchema)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown backend: %s\n", dbBackend)
+		os.Exit(1)
+	}
+
+	pe := persist.NewPersister(store).WithMigrator(persist.NewMigrator(uint32(version)))
+	if hmacKey != "" {
+		k, err := hex.DecodeString(hmacKey)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid hmac key: %v\n", err)
+			os.Exit(1)
+		}
+		pe = pe.WithHmac(k)
+	}
+	if aesKey != "" {
+		k, err := hex.DecodeString(aesKey)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid aes key: %v\n", err)
+			os.Exit(1)
+		}
+		blk, err := aes.NewCipher(k)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid aes key: %v\n", err)
+			os.Exit(1)
+		}
+		aead, err := cipher.NewGCM(blk)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "cipher error: %v\n", err)
+			os.Exit(1)
+		}
+		pe = pe.WithCipher(aead)
+	}
+
+	err := store.Connect(ctx, connStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to connect to db: %v\n", err)
+		os.Exit(1)
+	}
+	defer store.Close(ctx)
+
+	r, err := persist.Check(ctx, store, pe)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "check error: %v\n", err)
+		os.Exit(1)
+	}
+	var c int
+	for _, v := range r {
+		if v.Err == nil {
+			if all {
+				fmt.Printf("%s\tformat %d\tversion %d\tok\n", v.Key, v.Format, v.Version)
+			}
+			continue
+		}
+		c += 1
+		fmt.Printf("%s\tformat %d\tversion %d\tfail: %v\n", v.Key, v.Format, v.Version, v.Err)
+	}
+	fmt.Fprintf(os.Stderr, "%d of %d sessions would fail to load\n", c, len(r))
+	if c > 0 {
+		store.Close(ctx)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,2 @@
+// Executable visfmt rewrites festival assembly code files to canonical form.
+package main
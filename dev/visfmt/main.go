@@ -0,0 +1,90 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/grassrootseconomics/go-vise/asm"
+)
+
+// assembly code files in the path, recursively if it is a directory.
+func files(fp string) ([]string, error) {
+	var r []string
+	st, err := os.Stat(fp)
+	if err != nil {
+		return nil, err
+	}
+	if !st.IsDir() {
+		return []string{fp}, nil
+	}
+	err = filepath.WalkDir(fp, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.HasSuffix(p, ".vis") {
+			r = append(r, p)
+		}
+		return nil
+	})
+	return r, err
+}
+
+func main() {
+	var list bool
+	var write bool
+	var check bool
+	flag.BoolVar(&list, "l", false, "list files whose formatting differs from canonical form")
+	flag.BoolVar(&write, "w", false, "write result to source file instead of stdout")
+	flag.BoolVar(&check, "check", false, "list files whose formatting differs from canonical form, and fail if there are any")
+	flag.Parse()
+	if flag.NArg() < 1 {
+		fmt.Fprintf(os.Stderr, "usage: %s [options] <file_or_dir> [...]\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	var c int
+	for _, v := range flag.Args() {
+		fps, err := files(v)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "read error: %v\n", err)
+			os.Exit(1)
+		}
+		for _, fp := range fps {
+			b, err := os.ReadFile(fp)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "read error: %v\n", err)
+				os.Exit(1)
+			}
+			s, err := asm.Format(fp, string(b))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "format error: %v\n", err)
+				os.Exit(1)
+			}
+			if s != string(b) {
+				c += 1
+				if list || check {
+					fmt.Println(fp)
+				}
+			}
+			if write {
+				if s != string(b) {
+					err = os.WriteFile(fp, []byte(s), 0644)
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "write error: %v\n", err)
+						os.Exit(1)
+					}
+				}
+			} else if !list && !check {
+				fmt.Print(s)
+			}
+		}
+	}
+	if check && c > 0 {
+		fmt.Fprintf(os.Stderr, "%d files are not formatted\n", c)
+		os.Exit(1)
+	}
+}
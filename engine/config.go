@@ -2,12 +2,25 @@ package engine
 
 import (
 	"fmt"
+
+	"github.com/grassrootseconomics/go-vise/render"
+	"github.com/grassrootseconomics/go-vise/vm"
 )
 
 // Config globally defines behavior of all components driven by the engine.
 type Config struct {
 	// OutputSize sets the maximum size of output from a single rendered page. If set to 0, no size limit is imposed.
 	OutputSize uint32
+	// OutputSizeFunc sets the unit in which OutputSize is measured, e.g. render.Gsm7Size. If not set, output is measured in bytes.
+	OutputSizeFunc render.SizeFunc
+	// OutputSizeUcs2 sets the maximum size of output in UTF-16 code units, for pages that contain characters outside the GSM 03.38 alphabet. If set to 0, OutputSize applies to all pages.
+	OutputSizeUcs2 uint32
+	// OutputWordWrap breaks content items too large for a single page at word boundaries, instead of failing the render.
+	OutputWordWrap bool
+	// OutputWrapMarker is appended to every part of a broken content item except the last, e.g. "..." Only used if OutputWordWrap is set.
+	OutputWrapMarker string
+	// OutputRecords keeps records of content items on the same page where possible. Records are delimited by empty lines.
+	OutputRecords bool
 	// SessionId is used to segment the context of state and application data retrieval and storage.
 	SessionId string
 	// Root is the node name of the bytecode entry point.
@@ -22,6 +35,16 @@ type Config struct {
 	StateDebug bool
 	// EngineDebug activates the engine debug output
 	EngineDebug bool
+	// PageIndicator sets the format of the page position indicator for browsable content, e.g. "(%d/%d)". If not set, no indicator is shown.
+	PageIndicator string
+	// PageIndicatorPosition sets the placement of the page position indicator.
+	PageIndicatorPosition render.PageIndicatorPosition
+	// Renderer sets the output format of rendered pages, e.g. render.NewHtmlRenderer(). Size constraints apply to the visible text of the output. If not set, pages are rendered as plain text.
+	Renderer render.Renderer
+	// TemplateCache sets the cache of parsed templates shared by sessions. Call its Invalidate method when resources are reloaded. If not set, templates are parsed on every render.
+	TemplateCache *render.TemplateCache
+	// SourceMaps retrieves source maps for node bytecode, e.g. vm.NewDirSourceMapFunc. If set, execution errors include the assembly source location of the failing instruction.
+	SourceMaps vm.SourceMapFunc
 	// MenuSeparator sets the string to use for separating menu selectors and menu descriptors in the renderer
 	MenuSeparator string
 	// ResetOnEmptyInput purges cache and restart state execution at root on empty input
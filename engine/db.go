@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"text/template"
 
 	"github.com/grassrootseconomics/go-vise/cache"
 	"github.com/grassrootseconomics/go-vise/persist"
@@ -34,6 +35,7 @@ type DefaultEngine struct {
 	exiting    bool
 	execd      bool
 	regexCount int
+	funcs      template.FuncMap
 }
 
 // NewEngine instantiates the default Engine implementation.
@@ -134,6 +136,19 @@ func (en *DefaultEngine) WithFirst(fn resource.EntryFunc) *DefaultEngine {
 	return en
 }
 
+// WithTemplateFuncs is a chainable method that adds functions available to all templates rendered by the engine.
+//
+// See render.Page.WithFuncs for the builtin functions.
+func (en *DefaultEngine) WithTemplateFuncs(fm template.FuncMap) *DefaultEngine {
+	if en.funcs == nil {
+		en.funcs = make(template.FuncMap)
+	}
+	for k, v := range fm {
+		en.funcs[k] = v
+	}
+	return en
+}
+
 // AddValidInput defines a regular expressing string to match input against.
 //
 // The added regular expression will be evaluated after the builtin match (see
@@ -249,6 +264,9 @@ func (en *DefaultEngine) ensurePersist() error {
 	}
 	en.pe = en.pe.WithContent(st, cac)
 	err := en.pe.Load(en.cfg.SessionId)
+	if persist.IsIntegrity(err) {
+		logg.Warnf("persisted state failed integrity check, restarting session", "err", err, "session", en.cfg.SessionId)
+	}
 	if err != nil {
 		logg.Infof("persister load fail. trying save in case new session", "err", err, "session", en.cfg.SessionId)
 		err = en.pe.Save(en.cfg.SessionId)
@@ -269,12 +287,36 @@ func (en *DefaultEngine) ensurePersist() error {
 func (en *DefaultEngine) setupVm() {
 	var szr *render.Sizer
 	if en.cfg.OutputSize > 0 {
-		szr = render.NewSizer(en.cfg.OutputSize)
+		szr = render.NewSizer(en.cfg.OutputSize).WithSizeFunc(en.cfg.OutputSizeFunc)
+		if en.cfg.OutputSizeUcs2 > 0 {
+			szr = szr.WithUcs2Fallback(en.cfg.OutputSizeUcs2)
+		}
+		if en.cfg.OutputWordWrap {
+			szr = szr.WithWordWrap(en.cfg.OutputWrapMarker)
+		}
+		if en.cfg.OutputRecords {
+			szr = szr.WithRecords("")
+		}
 	}
 	en.vm = vm.NewVm(en.st, en.rs, en.ca, szr)
 	if en.cfg.MenuSeparator != "" {
 		en.vm = en.vm.WithMenuSeparator(en.cfg.MenuSeparator)
 	}
+	if en.cfg.PageIndicator != "" {
+		en.vm = en.vm.WithPageIndicator(en.cfg.PageIndicator, en.cfg.PageIndicatorPosition)
+	}
+	if en.cfg.TemplateCache != nil {
+		en.vm = en.vm.WithTemplateCache(en.cfg.TemplateCache)
+	}
+	if en.cfg.SourceMaps != nil {
+		en.vm = en.vm.WithSourceMaps(en.cfg.SourceMaps)
+	}
+	if en.cfg.Renderer != nil {
+		en.vm = en.vm.WithRenderer(en.cfg.Renderer)
+	}
+	if en.funcs != nil {
+		en.vm = en.vm.WithTemplateFuncs(en.funcs)
+	}
 }
 
 func (en *DefaultEngine) empty(ctx context.Context) error {
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/grassrootseconomics/go-vise/cache"
+	"github.com/grassrootseconomics/go-vise/db"
 	memdb "github.com/grassrootseconomics/go-vise/db/mem"
 	"github.com/grassrootseconomics/go-vise/persist"
 	"github.com/grassrootseconomics/go-vise/state"
@@ -138,3 +139,75 @@ func TestPersistSameAcrossEngine(t *testing.T) {
 		t.Errorf("expected location 'foo', got '%s", location)
 	}
 }
+
+func TestPersistIntegrityRestart(t *testing.T) {
+	var err error
+	var cfg Config
+	generateTestData(t)
+	st := state.NewState(1)
+	ca := cache.NewCache()
+	rs := newTestWrapper(dataDir, st)
+	ctx := context.Background()
+	store := memdb.NewMemDb()
+	store.Connect(ctx, "")
+	pe := persist.NewPersister(store).WithHmac([]byte("xyzzy"))
+	en := NewEngine(cfg, rs)
+	en = en.WithState(st)
+	en = en.WithMemory(ca)
+	en = en.WithPersister(pe)
+	_, err = en.Exec(ctx, []byte{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = en.Flush(ctx, bytes.NewBuffer(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = en.Exec(ctx, []byte("1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = en.Flush(ctx, bytes.NewBuffer(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = en.Finish(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store.SetPrefix(db.DATATYPE_STATE)
+	v, err := store.Get(ctx, []byte(cfg.SessionId))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v[len(v)-1] ^= 0x01
+	err = store.Put(ctx, []byte(cfg.SessionId), v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pe = persist.NewPersister(store).WithHmac([]byte("xyzzy"))
+	err = pe.Load(cfg.SessionId)
+	if !persist.IsIntegrity(err) {
+		t.Fatalf("expected integrity error, got %v", err)
+	}
+
+	cfg.FlagCount = 1
+	st = state.NewState(1)
+	rs = newTestWrapper(dataDir, st)
+	en = NewEngine(cfg, rs)
+	en = en.WithState(st)
+	en = en.WithPersister(pe)
+	cont, err := en.Exec(ctx, []byte{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cont {
+		t.Errorf("expected cont")
+	}
+	location, _ := st.Where()
+	if location != "root" {
+		t.Errorf("expected location 'root', got '%s'", location)
+	}
+}
@@ -34,12 +34,14 @@ func newTestResource(path string) *TestResource {
 		mem.SetLock(db.DATATYPE_TEMPLATE, false)
 		mem.SetLock(db.DATATYPE_BIN, false)
 		mem.SetLock(db.DATATYPE_MENU, false)
+		mem.SetLock(db.DATATYPE_STATICLOAD, false)
 		store = mem
 	} else {
 		fs := mem.NewMemDb()
 		fs.SetLock(db.DATATYPE_TEMPLATE, false)
 		fs.SetLock(db.DATATYPE_BIN, false)
 		fs.SetLock(db.DATATYPE_MENU, false)
+		fs.SetLock(db.DATATYPE_STATICLOAD, false)
 		store = fs
 	}
 
@@ -1,2 +1,2 @@
-// Package lang provides definitions to specify translation language for template selection and output rendering.
+// Package lang provides definitions to specify translation language for template selection and output rendering, the fallback chains of translation languages, and the locale formatting rules for numbers, currency and dates.
 package lang
@@ -0,0 +1,72 @@
+package lang
+
+import (
+	"context"
+)
+
+// MissingFunc receives the ISO639-3 code of a language in which no translation exists for the key, while resolving a fallback chain.
+type MissingFunc func(ctx context.Context, code string, key string)
+
+// Fallbacks defines the ordered languages to try when no translation exists for a language, and how missing translations are reported.
+//
+// Fallbacks must be fully set up before being passed to resources; it is not safe for modification while in use.
+type Fallbacks struct {
+	chains    map[string][]Language
+	missingFn MissingFunc
+}
+
+// NewFallbacks creates a new Fallbacks with no fallback chains.
+func NewFallbacks() *Fallbacks {
+	return &Fallbacks{
+		chains: make(map[string][]Language),
+	}
+}
+
+// Register sets the ordered languages to try when no translation exists for the language with the given ISO639-3 code, replacing any existing chain.
+//
+// If no translation exists in any language of the chain, the untranslated default is used. The chains of the fallback languages themselves are not followed.
+//
+// Will fail if any of the codes are invalid.
+func (f *Fallbacks) Register(code string, fallback ...string) error {
+	ln, err := LanguageFromCode(code)
+	if err != nil {
+		return err
+	}
+	var chain []Language
+	for _, v := range fallback {
+		fb, err := LanguageFromCode(v)
+		if err != nil {
+			return err
+		}
+		chain = append(chain, fb)
+	}
+	f.chains[ln.Code] = chain
+	return nil
+}
+
+// WithMissingFunc is a chainable function that sets the function to call when a translation is missing while resolving a fallback chain, e.g. to update metrics.
+func (f *Fallbacks) WithMissingFunc(fn MissingFunc) *Fallbacks {
+	f.missingFn = fn
+	return f
+}
+
+// Fallback returns the ordered languages to try when no translation exists for the language.
+func (f *Fallbacks) Fallback(ln Language) []Language {
+	if f == nil {
+		return nil
+	}
+	return f.chains[ln.Code]
+}
+
+// Chain returns the language followed by its fallback languages.
+func (f *Fallbacks) Chain(ln Language) []Language {
+	return append([]Language{ln}, f.Fallback(ln)...)
+}
+
+// ReportMissing passes a missing translation to the function set with WithMissingFunc, if any.
+func (f *Fallbacks) ReportMissing(ctx context.Context, code string, key string) {
+	if f == nil || f.missingFn == nil {
+		return
+	}
+	f.missingFn(ctx, code, key)
+}
@@ -1,7 +1,9 @@
 package lang
 
 import (
+	"context"
 	"testing"
+	"time"
 )
 
 func TestLang(t *testing.T) {
@@ -18,3 +20,95 @@ func TestLang(t *testing.T) {
 		t.Fatalf("expected 'eng', got '%s'", l.Code)
 	}
 }
+
+func TestLocale(t *testing.T) {
+	l, err := LanguageFromCode("deu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lc := l.Locale()
+	r := lc.FormatCurrency(1234.5, 2, "€")
+	if r != "1.234,50 €" {
+		t.Fatalf("expected '1.234,50 €', got '%s'", r)
+	}
+	r = lc.FormatDate(time.Date(2024, 3, 1, 13, 37, 0, 0, time.UTC))
+	if r != "01.03.2024" {
+		t.Fatalf("expected '01.03.2024', got '%s'", r)
+	}
+
+	l, err = LanguageFromCode("swa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.WithValue(context.Background(), "Language", l)
+	lc = LocaleFromContext(ctx)
+	r = lc.FormatCurrency(-1234.5, 2, "KSh")
+	if r != "KSh -1,234.50" {
+		t.Fatalf("expected 'KSh -1,234.50', got '%s'", r)
+	}
+
+	lc = LocaleFromContext(context.Background())
+	r = lc.FormatNumber(999, 0)
+	if r != "999" {
+		t.Fatalf("expected '999', got '%s'", r)
+	}
+
+	l, err = LanguageFromCode("xho")
+	if err != nil {
+		t.Fatal(err)
+	}
+	RegisterLocale("xho", Locale{Decimal: ",", Group: " ", Date: "2006/01/02"})
+	t.Cleanup(func() {
+		UnregisterLocale("xho")
+	})
+	r = l.Locale().FormatNumber(1234567.891, 1)
+	if r != "1 234 567,9" {
+		t.Fatalf("expected '1 234 567,9', got '%s'", r)
+	}
+}
+
+func TestUnregisterLocale(t *testing.T) {
+	l, err := LanguageFromCode("zul")
+	if err != nil {
+		t.Fatal(err)
+	}
+	RegisterLocale("zul", Locale{Decimal: ",", Group: " "})
+	UnregisterLocale("zul")
+	if l.Locale() != DefaultLocale {
+		t.Fatalf("expected default locale, got %v", l.Locale())
+	}
+}
+
+func TestFallback(t *testing.T) {
+	fb := NewFallbacks()
+	err := fb.Register("kik", "swa", "xyzzy")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	err = fb.Register("kik", "swa", "eng")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := LanguageFromCode("kik")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain := fb.Chain(l)
+	if len(chain) != 3 || chain[0].Code != "kik" || chain[1].Code != "swa" || chain[2].Code != "eng" {
+		t.Fatalf("unexpected chain: %v", chain)
+	}
+	if len(fb.Fallback(chain[1])) != 0 {
+		t.Fatalf("expected no fallback for swa, got %v", fb.Fallback(chain[1]))
+	}
+
+	// chains are not shared between instances.
+	if len(NewFallbacks().Fallback(l)) != 0 {
+		t.Fatal("expected no fallback in new instance")
+	}
+	var nofb *Fallbacks
+	chain = nofb.Chain(l)
+	if len(chain) != 1 || chain[0].Code != "kik" {
+		t.Fatalf("unexpected chain: %v", chain)
+	}
+	nofb.ReportMissing(context.Background(), "kik", "foo")
+}
@@ -0,0 +1,167 @@
+package lang
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Locale defines the formatting rules of numbers, currency amounts and dates for a language.
+type Locale struct {
+	// Decimal separator.
+	Decimal string
+	// Thousands grouping separator.
+	Group string
+	// If true, the currency symbol is placed before the amount.
+	CurrencyBefore bool
+	// If true, the currency symbol is separated from the amount with a space.
+	CurrencySpace bool
+	// Date layout, as used by time.Time.Format.
+	Date string
+	// Time of day layout, as used by time.Time.Format.
+	Time string
+}
+
+var (
+	// DefaultLocale is used for languages that have no registered locale.
+	DefaultLocale = Locale{
+		Decimal:        ".",
+		Group:          ",",
+		CurrencyBefore: true,
+		Date:           "2006-01-02",
+		Time:           "15:04",
+	}
+	locales = map[string]Locale{
+		"eng": {
+			Decimal:        ".",
+			Group:          ",",
+			CurrencyBefore: true,
+			Date:           "2 Jan 2006",
+			Time:           "15:04",
+		},
+		"swa": {
+			Decimal:        ".",
+			Group:          ",",
+			CurrencyBefore: true,
+			CurrencySpace:  true,
+			Date:           "02/01/2006",
+			Time:           "15:04",
+		},
+		"fra": {
+			Decimal:       ",",
+			Group:         " ",
+			CurrencySpace: true,
+			Date:          "02/01/2006",
+			Time:          "15:04",
+		},
+		"deu": {
+			Decimal:       ",",
+			Group:         ".",
+			CurrencySpace: true,
+			Date:          "02.01.2006",
+			Time:          "15:04",
+		},
+		"nor": {
+			Decimal:       ",",
+			Group:         " ",
+			CurrencySpace: true,
+			Date:          "02.01.2006",
+			Time:          "15:04",
+		},
+		"spa": {
+			Decimal:       ",",
+			Group:         ".",
+			CurrencySpace: true,
+			Date:          "02/01/2006",
+			Time:          "15:04",
+		},
+	}
+	localeMu sync.RWMutex
+)
+
+// RegisterLocale sets the formatting rules for the language with the given ISO639-3 code, replacing any existing rules.
+func RegisterLocale(code string, lc Locale) {
+	localeMu.Lock()
+	defer localeMu.Unlock()
+	locales[code] = lc
+}
+
+// UnregisterLocale removes the formatting rules for the language with the given ISO639-3 code, so that DefaultLocale is used.
+func UnregisterLocale(code string) {
+	localeMu.Lock()
+	defer localeMu.Unlock()
+	delete(locales, code)
+}
+
+// Locale returns the formatting rules for the language.
+//
+// If no rules are registered for the language, DefaultLocale is returned.
+func (l Language) Locale() Locale {
+	localeMu.RLock()
+	defer localeMu.RUnlock()
+	lc, ok := locales[l.Code]
+	if !ok {
+		return DefaultLocale
+	}
+	return lc
+}
+
+// LocaleFromContext returns the formatting rules for the language in the context.
+//
+// If no language is set in the context, DefaultLocale is returned.
+func LocaleFromContext(ctx context.Context) Locale {
+	ln, ok := LanguageFromContext(ctx)
+	if !ok {
+		return DefaultLocale
+	}
+	return ln.Locale()
+}
+
+// FormatNumber formats the number with the given number of decimals, grouping the integer part by thousands.
+func (lc Locale) FormatNumber(v float64, decimals int) string {
+	s := strconv.FormatFloat(v, 'f', decimals, 64)
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign = "-"
+		s = s[1:]
+	}
+	frac := ""
+	i := strings.Index(s, ".")
+	if i >= 0 {
+		frac = lc.Decimal + s[i+1:]
+		s = s[:i]
+	}
+	sb := strings.Builder{}
+	for i, c := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			sb.WriteString(lc.Group)
+		}
+		sb.WriteRune(c)
+	}
+	return sign + sb.String() + frac
+}
+
+// FormatCurrency formats the number like FormatNumber, and places the currency symbol according to the locale.
+func (lc Locale) FormatCurrency(v float64, decimals int, symbol string) string {
+	s := lc.FormatNumber(v, decimals)
+	sep := ""
+	if lc.CurrencySpace {
+		sep = " "
+	}
+	if lc.CurrencyBefore {
+		return symbol + sep + s
+	}
+	return s + sep + symbol
+}
+
+// FormatDate formats the date part of the time.
+func (lc Locale) FormatDate(t time.Time) string {
+	return t.Format(lc.Date)
+}
+
+// FormatDateTime formats the date and time of day of the time.
+func (lc Locale) FormatDateTime(t time.Time) string {
+	return t.Format(lc.Date + " " + lc.Time)
+}
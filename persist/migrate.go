@@ -0,0 +1,172 @@
+package persist
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fxamacker/cbor/v2"
+
+	"github.com/grassrootseconomics/go-vise/cache"
+	"github.com/grassrootseconomics/go-vise/db"
+	"github.com/grassrootseconomics/go-vise/state"
+)
+
+// Migration converts the payload of persisted data from one version to the next.
+type Migration func(ctx context.Context, b []byte) ([]byte, error)
+
+// Migrator holds the current data version of an application, and the migrations needed to bring older persisted data up to date.
+type Migrator struct {
+	version    uint32
+	migrations map[uint32]Migration
+}
+
+// NewMigrator creates a new Migrator for the given current data version.
+func NewMigrator(version uint32) *Migrator {
+	return &Migrator{
+		version:    version,
+		migrations: make(map[uint32]Migration),
+	}
+}
+
+// WithMigration is a chainable function that registers the migration from the version in the first argument to the next version.
+//
+// Panics if a migration is already registered for the version, or if the version is not older than the current version.
+func (mg *Migrator) WithMigration(from uint32, fn Migration) *Migrator {
+	if from >= mg.version {
+		panic(fmt.Sprintf("migration from version %d not older than current version %d", from, mg.version))
+	}
+	_, ok := mg.migrations[from]
+	if ok {
+		panic(fmt.Sprintf("migration from version %d already registered", from))
+	}
+	mg.migrations[from] = fn
+	return mg
+}
+
+// Version returns the current data version.
+func (mg *Migrator) Version() uint32 {
+	return mg.version
+}
+
+// Migrate applies all migrations needed to bring the payload from the given version to the current version.
+//
+// Errors with ErrVersion if the version is newer than the current version, or if a migration in the chain is missing.
+func (mg *Migrator) Migrate(ctx context.Context, version uint32, b []byte) ([]byte, error) {
+	var err error
+	if version > mg.version {
+		return nil, fmt.Errorf("%w: version %d newer than %d", ErrVersion, version, mg.version)
+	}
+	for v := version; v < mg.version; v++ {
+		fn, ok := mg.migrations[v]
+		if !ok {
+			return nil, fmt.Errorf("%w: no migration from version %d", ErrVersion, v)
+		}
+		b, err = fn(ctx, b)
+		if err != nil {
+			return nil, fmt.Errorf("migration from version %d failed: %v", v, err)
+		}
+		logg.DebugCtxf(ctx, "migrated persisted data", "from", v, "to", v+1)
+	}
+	return b, nil
+}
+
+// StateMigration creates a Migration that operates on the decoded state and cache.
+//
+// It may be used for changes that do not break the decoding of the payload itself, e.g. changing the number of user flags or renaming nodes in the execution path.
+func StateMigration(fn func(ctx context.Context, st *state.State, ca *cache.Cache) error) Migration {
+	return func(ctx context.Context, b []byte) ([]byte, error) {
+		var p Persister
+		err := cbor.Unmarshal(b, &p)
+		if err != nil {
+			return nil, err
+		}
+		err = fn(ctx, p.State, p.Memory)
+		if err != nil {
+			return nil, err
+		}
+		return cbor.Marshal(&p)
+	}
+}
+
+// CheckResult is the outcome of a dry-run load of a single persisted entry.
+type CheckResult struct {
+	// Key the entry is stored under.
+	Key []byte
+	// Envelope format version of the entry.
+	Format uint16
+	// Data version of the entry.
+	Version uint32
+	// Error encountered during migration or decoding. Nil if the entry can be loaded.
+	Err error
+}
+
+// Check attempts to migrate, verify and decode all persisted state entries in the store, without writing any changes.
+//
+// The migrator, integrity and cipher settings of the persister in the third argument are used for decoding, and its state and cache will be replaced. If nil, a persister with default settings is used.
+//
+// The session of the store will be reset.
+func Check(ctx context.Context, store db.Db, p *Persister) ([]CheckResult, error) {
+	var r []CheckResult
+	if p == nil {
+		p = NewPersister(store)
+	}
+	p = p.WithContext(ctx)
+	var raw bool
+	rd, ok := store.(db.RawDumper)
+	if ok {
+		raw = rd.RawDump()
+	}
+	store.SetPrefix(db.DATATYPE_STATE)
+	store.SetSession("")
+	dmp, err := store.Dump(ctx, []byte{})
+	if err != nil {
+		if db.IsNotFound(err) {
+			return r, nil
+		}
+		return r, err
+	}
+	defer dmp.Close()
+	for {
+		k, v := dmp.Next(ctx)
+		if k == nil {
+			break
+		}
+		k, ok := stateKey(k, raw)
+		if !ok {
+			continue
+		}
+		p.State = nil
+		p.Memory = nil
+		res := CheckResult{
+			Key: k,
+		}
+		e, err := DecodeEnvelope(v)
+		if err == nil {
+			res.Format = e.Format
+			res.Version = e.Version
+			err = p.Deserialize(v)
+		}
+		if err == nil && p.GetState() == nil {
+			err = fmt.Errorf("%w: no state in entry", ErrFormat)
+		}
+		res.Err = err
+		r = append(r, res)
+	}
+	return r, nil
+}
+
+// normalize dumped key, skipping entries of other datatypes.
+//
+// backends implementing db.RawDumper dump all storage keys including the datatype prefix, while the other backends dump decoded keys bounded to the datatype of the dump.
+func stateKey(k []byte, raw bool) ([]byte, bool) {
+	if len(k) == 0 {
+		return nil, false
+	}
+	if !raw {
+		return k, true
+	}
+	if k[0] != db.DATATYPE_STATE {
+		return nil, false
+	}
+	return k[1:], true
+}
@@ -0,0 +1,199 @@
+package persist
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	pgxmock "github.com/pashagolub/pgxmock/v4"
+
+	"github.com/grassrootseconomics/go-vise/cache"
+	"github.com/grassrootseconomics/go-vise/db"
+	"github.com/grassrootseconomics/go-vise/db/lru"
+	"github.com/grassrootseconomics/go-vise/db/mem"
+	"github.com/grassrootseconomics/go-vise/db/postgres"
+	"github.com/grassrootseconomics/go-vise/state"
+)
+
+func TestLoadLegacy(t *testing.T) {
+	ctx := context.Background()
+	st := state.NewState(0)
+	st.Down("foo")
+	ca := cache.NewCache()
+
+	store := mem.NewMemDb()
+	store.Connect(ctx, "")
+	pr := NewPersister(store).WithContent(st, ca)
+	b, err := cbor.Marshal(pr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.SetPrefix(db.DATATYPE_STATE)
+	err = store.Put(ctx, []byte("xyzzy"), b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prnew := NewPersister(store)
+	err = prnew.Load("xyzzy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prnew.GetState().ExecPath[0] != "foo" {
+		t.Fatalf("expected path 'foo', got %v", prnew.GetState().ExecPath)
+	}
+}
+
+func TestLoadMigrate(t *testing.T) {
+	ctx := context.Background()
+	st := state.NewState(0)
+	st.Down("foo")
+	ca := cache.NewCache()
+
+	store := mem.NewMemDb()
+	store.Connect(ctx, "")
+	pr := NewPersister(store).WithContent(st, ca).WithMigrator(NewMigrator(1))
+	err := pr.Save("xyzzy")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prnew := NewPersister(store)
+	err = prnew.Load("xyzzy")
+	if !errors.Is(err, ErrVersion) {
+		t.Fatalf("expected version error, got %v", err)
+	}
+
+	prnew = NewPersister(store).WithMigrator(NewMigrator(3))
+	err = prnew.Load("xyzzy")
+	if !errors.Is(err, ErrVersion) {
+		t.Fatalf("expected version error, got %v", err)
+	}
+
+	mg := NewMigrator(3)
+	mg = mg.WithMigration(1, StateMigration(func(ctx context.Context, st *state.State, ca *cache.Cache) error {
+		st.ExecPath[0] = "bar"
+		return nil
+	}))
+	mg = mg.WithMigration(2, StateMigration(func(ctx context.Context, st *state.State, ca *cache.Cache) error {
+		st.ExecPath = append(st.ExecPath, "baz")
+		return nil
+	}))
+	prnew = NewPersister(store).WithMigrator(mg)
+	err = prnew.Load("xyzzy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := prnew.GetState().ExecPath
+	if len(path) != 2 || path[0] != "bar" || path[1] != "baz" {
+		t.Fatalf("unexpected migrated path: %v", path)
+	}
+
+	b, err := prnew.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := DecodeEnvelope(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Format != FormatVersion || e.Version != 3 {
+		t.Fatalf("unexpected envelope versions %d/%d", e.Format, e.Version)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	ctx := context.Background()
+	store := mem.NewMemDb()
+	store.Connect(ctx, "")
+
+	pr := NewPersister(store).WithContent(state.NewState(0), cache.NewCache())
+	err := pr.Save("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pr = NewPersister(store).WithContent(state.NewState(0), cache.NewCache()).WithMigrator(NewMigrator(1))
+	err = pr.Save("bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.SetPrefix(db.DATATYPE_USERDATA)
+	err = store.Put(ctx, []byte("baz"), []byte("inky"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a wrapper must report the dump key format of the db it wraps.
+	for _, s := range []db.Db{store, lru.NewLruDb(store, nil)} {
+		r, err := Check(ctx, s, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(r) != 2 {
+			t.Fatalf("expected 2 results, got %d", len(r))
+		}
+		for _, v := range r {
+			switch string(v.Key) {
+			case "foo":
+				if v.Err != nil {
+					t.Fatalf("expected foo to load, got %v", v.Err)
+				}
+			case "bar":
+				if !errors.Is(v.Err, ErrVersion) || v.Version != 1 {
+					t.Fatalf("expected bar version error, got %v", v.Err)
+				}
+			default:
+				t.Fatalf("unexpected key: %s", v.Key)
+			}
+		}
+	}
+}
+
+// keys dumped by backends not implementing db.RawDumper are decoded, and must be bounded to the state datatype by the backend.
+func TestCheckPg(t *testing.T) {
+	ctx := context.Background()
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+	store := postgres.NewPgDb().WithConnection(mock).WithSchema("vvise")
+
+	b, err := NewPersister(nil).WithContent(state.NewState(0), cache.NewCache()).Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	typMap := pgtype.NewMap()
+	mockKfd := pgconn.FieldDescription{
+		Name:        "key",
+		DataTypeOID: pgtype.ByteaOID,
+		Format:      typMap.FormatCodeForOID(pgtype.ByteaOID),
+	}
+	mockVfd := pgconn.FieldDescription{
+		Name:        "value",
+		DataTypeOID: pgtype.ByteaOID,
+		Format:      typMap.FormatCodeForOID(pgtype.ByteaOID),
+	}
+	rows := pgxmock.NewRowsWithColumnDefinition(mockKfd, mockVfd)
+	rows = rows.AddRow(append([]byte{db.DATATYPE_STATE}, []byte("\x01bar")...), b)
+	rows = rows.AddRow(append([]byte{db.DATATYPE_STATE}, []byte("foo")...), b)
+	rows = rows.AddRow(append([]byte{db.DATATYPE_USERDATA}, []byte("foo.baz")...), []byte("inky"))
+	mock.ExpectQuery("SELECT key, value FROM vvise.kv_vise").WithArgs([]byte{db.DATATYPE_STATE}).WillReturnRows(rows)
+
+	r, err := Check(ctx, store, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r) != 2 {
+		t.Fatalf("expected 2 results, got %d: %v", len(r), r)
+	}
+	if string(r[0].Key) != "\x01bar" || r[0].Err != nil {
+		t.Fatalf("expected \\x01bar to load, got %x: %v", r[0].Key, r[0].Err)
+	}
+	if string(r[1].Key) != "foo" || r[1].Err != nil {
+		t.Fatalf("expected foo to load, got %s: %v", r[1].Key, r[1].Err)
+	}
+}
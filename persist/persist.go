@@ -2,6 +2,7 @@ package persist
 
 import (
 	"context"
+	"crypto/cipher"
 	"fmt"
 
 	"github.com/fxamacker/cbor/v2"
@@ -13,11 +14,17 @@ import (
 
 // Persister abstracts storage and retrieval of state and cache.
 type Persister struct {
-	State  *state.State
-	Memory *cache.Cache
-	ctx    context.Context
-	db     db.Db
-	flush  bool
+	State          *state.State
+	Memory         *cache.Cache
+	ctx            context.Context
+	db             db.Db
+	flush          bool
+	mg             *Migrator
+	compress       bool
+	hmacKey        []byte
+	aead           cipher.AEAD
+	maxPayload     int
+	unverifiedHmac bool
 }
 
 // NewPersister creates a new Persister instance.
@@ -56,6 +63,14 @@ func (p *Persister) WithFlush() *Persister {
 	return p
 }
 
+// WithMigrator is a chainable function that sets the data version of the persister, and the migrations to apply to older data on load.
+//
+// If not set, the data version is 0.
+func (p *Persister) WithMigrator(mg *Migrator) *Persister {
+	p.mg = mg
+	return p
+}
+
 // Invalid checks if the underlying state has been invalidated.
 //
 // An invalid state will cause Save to panic.
@@ -73,15 +88,49 @@ func (p *Persister) GetMemory() cache.Memory {
 	return p.Memory
 }
 
-// Serialize encodes the state and cache into byte form for storage.
+// Serialize encodes the state and cache into a versioned Envelope for storage.
 func (p *Persister) Serialize() ([]byte, error) {
-	return cbor.Marshal(p)
+	b, err := cbor.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	e := Envelope{
+		Format:  FormatVersion,
+		Payload: b,
+	}
+	if p.mg != nil {
+		e.Version = p.mg.Version()
+	}
+	err = p.seal(&e)
+	if err != nil {
+		return nil, err
+	}
+	return e.Bytes(), nil
 }
 
 // Deserialize decodes the state and cache from storage, and applies them to the persister.
+//
+// Data of an older version will be migrated using the Migrator set with WithMigrator. The migrated data is not written back to storage until the next Save.
+//
+// If the data fails verification, ErrIntegrity is returned and the state and cache of the persister are left untouched.
 func (p *Persister) Deserialize(b []byte) error {
-	err := cbor.Unmarshal(b, p)
-	return err
+	e, err := DecodeEnvelope(b)
+	if err != nil {
+		return err
+	}
+	err = p.open(&e)
+	if err != nil {
+		return err
+	}
+	mg := p.mg
+	if mg == nil {
+		mg = NewMigrator(0)
+	}
+	b, err = mg.Migrate(p.ctx, e.Version, e.Payload)
+	if err != nil {
+		return err
+	}
+	return cbor.Unmarshal(b, p)
 }
 
 // Save persists the state and cache to the db.Db backend.
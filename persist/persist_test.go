@@ -180,3 +180,29 @@ func TestFlush(t *testing.T) {
 		t.Fatalf("expected cache use size 0, got: %v", o.CacheUseSize)
 	}
 }
+
+func TestPersistItems(t *testing.T) {
+	ctx := context.Background()
+	store := mem.NewMemDb()
+	store.Connect(ctx, "")
+	ca := cache.NewCache()
+	ca.Add("foo", "1:inky\n2:pinky", 0)
+	ca.SetItems("foo", []string{"0xdead", "0xbeef"})
+	pr := NewPersister(store).WithContent(state.NewState(0), ca)
+	err := pr.Save("xyzzy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pr = NewPersister(store)
+	err = pr.Load("xyzzy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := pr.GetMemory().(cache.ItemMemory).GetItems("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r) != 2 || r[1] != "0xbeef" {
+		t.Fatalf("unexpected items: %v", r)
+	}
+}
@@ -0,0 +1,190 @@
+package persist
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	// Default limit of the size of a decompressed payload.
+	DEFAULT_MAX_PAYLOAD = 1 << 22
+
+	integrityPrefix = "persisted data failed integrity check: "
+)
+
+// ErrIntegrity is returned when persisted data is corrupted, has been tampered with, or lacks the protection required by the persister.
+type ErrIntegrity struct {
+	reason string
+}
+
+// NewErrIntegrity creates a new ErrIntegrity with the given reason.
+func NewErrIntegrity(reason string) error {
+	return ErrIntegrity{reason}
+}
+
+// Error implements Error.
+func (e ErrIntegrity) Error() string {
+	return integrityPrefix + e.reason
+}
+
+// IsIntegrity returns true if the error is or wraps an ErrIntegrity.
+func IsIntegrity(err error) bool {
+	var target ErrIntegrity
+	return errors.As(err, &target)
+}
+
+// WithCompression is a chainable function that enables compression of the payload on Save.
+//
+// Compressed payloads are always decompressed on Load, regardless of this setting.
+func (p *Persister) WithCompression() *Persister {
+	p.compress = true
+	return p
+}
+
+// WithMaxPayload is a chainable function that sets the limit of the size of a decompressed payload on Load.
+//
+// Load will fail with ErrIntegrity for payloads that decompress to more than the limit. If not set, DEFAULT_MAX_PAYLOAD is used.
+func (p *Persister) WithMaxPayload(size int) *Persister {
+	p.maxPayload = size
+	return p
+}
+
+// WithHmac is a chainable function that attaches a HMAC-SHA256 of the envelope on Save, using the given key.
+//
+// When set, Load will fail with ErrIntegrity for data without a valid HMAC.
+func (p *Persister) WithHmac(key []byte) *Persister {
+	p.hmacKey = key
+	return p
+}
+
+// WithUnverifiedHmac is a chainable function that allows Load of data with a HMAC when no key has been set with WithHmac, skipping the verification.
+//
+// By default such data fails with ErrIntegrity. This is intended for tools inspecting persisted data without access to the key.
+func (p *Persister) WithUnverifiedHmac() *Persister {
+	p.unverifiedHmac = true
+	return p
+}
+
+// WithCipher is a chainable function that encrypts the payload with the given authenticated cipher on Save, e.g. AES-GCM.
+//
+// The envelope header is authenticated as additional data, and a random nonce is prepended to the ciphertext.
+//
+// When set, Load will fail with ErrIntegrity for data that is not encrypted, or fails to decrypt.
+func (p *Persister) WithCipher(aead cipher.AEAD) *Persister {
+	p.aead = aead
+	return p
+}
+
+// apply the enabled payload transformations to the envelope.
+//
+// all flags are set before sealing, as the header is authenticated.
+func (p *Persister) seal(e *Envelope) error {
+	if p.compress {
+		e.Flags |= PAYLOAD_COMPRESSED
+	}
+	if p.aead != nil {
+		e.Flags |= PAYLOAD_ENCRYPTED
+	}
+	if p.hmacKey != nil {
+		e.Flags |= PAYLOAD_HMAC
+	}
+	if p.compress {
+		var buf bytes.Buffer
+		w := zlib.NewWriter(&buf)
+		_, err := w.Write(e.Payload)
+		if err != nil {
+			return err
+		}
+		err = w.Close()
+		if err != nil {
+			return err
+		}
+		e.Payload = buf.Bytes()
+	}
+	if p.aead != nil {
+		nonce := make([]byte, p.aead.NonceSize())
+		_, err := rand.Read(nonce)
+		if err != nil {
+			return err
+		}
+		e.Payload = p.aead.Seal(nonce, nonce, e.Payload, e.header())
+	}
+	if p.hmacKey != nil {
+		h := hmac.New(sha256.New, p.hmacKey)
+		h.Write(e.header())
+		h.Write(e.Payload)
+		e.Payload = h.Sum(e.Payload)
+	}
+	return nil
+}
+
+// verify and reverse the payload transformations of the envelope.
+func (p *Persister) open(e *Envelope) error {
+	if p.hmacKey != nil {
+		if e.Flags&PAYLOAD_HMAC == 0 {
+			return NewErrIntegrity("missing hmac")
+		}
+		if len(e.Payload) < sha256.Size {
+			return NewErrIntegrity("short hmac")
+		}
+		c := len(e.Payload) - sha256.Size
+		h := hmac.New(sha256.New, p.hmacKey)
+		h.Write(e.header())
+		h.Write(e.Payload[:c])
+		if !hmac.Equal(h.Sum(nil), e.Payload[c:]) {
+			return NewErrIntegrity("hmac mismatch")
+		}
+		e.Payload = e.Payload[:c]
+	} else if e.Flags&PAYLOAD_HMAC > 0 {
+		if !p.unverifiedHmac {
+			return NewErrIntegrity("hmac present but no key set")
+		}
+		if len(e.Payload) < sha256.Size {
+			return NewErrIntegrity("short hmac")
+		}
+		logg.Warnf("persisted data has hmac but no key set, skipping verification")
+		e.Payload = e.Payload[:len(e.Payload)-sha256.Size]
+	}
+	if p.aead != nil {
+		if e.Flags&PAYLOAD_ENCRYPTED == 0 {
+			return NewErrIntegrity("payload not encrypted")
+		}
+		c := p.aead.NonceSize()
+		if len(e.Payload) < c {
+			return NewErrIntegrity("short ciphertext")
+		}
+		v, err := p.aead.Open(nil, e.Payload[:c], e.Payload[c:], e.header())
+		if err != nil {
+			return NewErrIntegrity(fmt.Sprintf("decrypt failed: %v", err))
+		}
+		e.Payload = v
+	} else if e.Flags&PAYLOAD_ENCRYPTED > 0 {
+		return fmt.Errorf("%w: payload encrypted but no cipher set", ErrFormat)
+	}
+	if e.Flags&PAYLOAD_COMPRESSED > 0 {
+		r, err := zlib.NewReader(bytes.NewReader(e.Payload))
+		if err != nil {
+			return NewErrIntegrity(fmt.Sprintf("decompress failed: %v", err))
+		}
+		max := p.maxPayload
+		if max == 0 {
+			max = DEFAULT_MAX_PAYLOAD
+		}
+		v, err := io.ReadAll(io.LimitReader(r, int64(max)+1))
+		if err != nil {
+			return NewErrIntegrity(fmt.Sprintf("decompress failed: %v", err))
+		}
+		if len(v) > max {
+			return NewErrIntegrity(fmt.Sprintf("decompressed payload exceeds %d bytes", max))
+		}
+		e.Payload = v
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package persist
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/grassrootseconomics/go-vise/cache"
+	"github.com/grassrootseconomics/go-vise/db/mem"
+	"github.com/grassrootseconomics/go-vise/state"
+)
+
+func newTestContent(t *testing.T) (*state.State, *cache.Cache) {
+	st := state.NewState(0)
+	st.Down("foo")
+	ca := cache.NewCache()
+	err := ca.Add("inky", strings.Repeat("pinky", 100), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return st, ca
+}
+
+func TestSerializeCompress(t *testing.T) {
+	ctx := context.Background()
+	store := mem.NewMemDb()
+	store.Connect(ctx, "")
+	st, ca := newTestContent(t)
+	plain, err := NewPersister(store).WithContent(st, ca).Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewPersister(store).WithContent(st, ca).WithCompression().Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b) >= len(plain) {
+		t.Fatalf("expected compressed size less than %d, got %d", len(plain), len(b))
+	}
+	pr := NewPersister(store)
+	err = pr.Deserialize(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := pr.GetMemory().Get("inky")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != strings.Repeat("pinky", 100) {
+		t.Fatalf("unexpected value: %s", v)
+	}
+}
+
+func TestSerializeCompressLimit(t *testing.T) {
+	ctx := context.Background()
+	store := mem.NewMemDb()
+	store.Connect(ctx, "")
+	st, ca := newTestContent(t)
+	b, err := NewPersister(store).WithContent(st, ca).WithCompression().Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pr := NewPersister(store).WithMaxPayload(256)
+	err = pr.Deserialize(b)
+	if !IsIntegrity(err) {
+		t.Fatalf("expected integrity error, got %v", err)
+	}
+	pr = NewPersister(store).WithMaxPayload(4096)
+	err = pr.Deserialize(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSerializeHmac(t *testing.T) {
+	ctx := context.Background()
+	store := mem.NewMemDb()
+	store.Connect(ctx, "")
+	st, ca := newTestContent(t)
+	b, err := NewPersister(store).WithContent(st, ca).WithCompression().WithHmac([]byte("xyzzy")).Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pr := NewPersister(store).WithHmac([]byte("xyzzy"))
+	err = pr.Deserialize(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bb := bytes.Clone(b)
+	bb[len(bb)-40] ^= 0x01
+	pr = NewPersister(store).WithHmac([]byte("xyzzy"))
+	err = pr.Deserialize(bb)
+	if !IsIntegrity(err) {
+		t.Fatalf("expected integrity error, got %v", err)
+	}
+	if pr.GetState() != nil {
+		t.Fatal("expected state untouched on integrity error")
+	}
+
+	pr = NewPersister(store).WithHmac([]byte("plugh"))
+	err = pr.Deserialize(b)
+	if !IsIntegrity(err) {
+		t.Fatalf("expected integrity error, got %v", err)
+	}
+
+	pr = NewPersister(store)
+	err = pr.Deserialize(b)
+	if !IsIntegrity(err) {
+		t.Fatalf("expected integrity error without key, got %v", err)
+	}
+	pr = NewPersister(store).WithUnverifiedHmac()
+	err = pr.Deserialize(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr.GetState() == nil {
+		t.Fatal("expected state")
+	}
+
+	b, err = NewPersister(store).WithContent(st, ca).Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pr = NewPersister(store).WithHmac([]byte("xyzzy"))
+	err = pr.Deserialize(b)
+	if !IsIntegrity(err) {
+		t.Fatalf("expected integrity error, got %v", err)
+	}
+}
+
+func TestSerializeCipher(t *testing.T) {
+	ctx := context.Background()
+	store := mem.NewMemDb()
+	store.Connect(ctx, "")
+	st, ca := newTestContent(t)
+	blk, err := aes.NewCipher(bytes.Repeat([]byte{0x2a}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	aead, err := cipher.NewGCM(blk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewPersister(store).WithContent(st, ca).WithCompression().WithCipher(aead).Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(b, []byte("foo")) {
+		t.Fatal("expected payload to be encrypted")
+	}
+	pr := NewPersister(store).WithCipher(aead)
+	err = pr.Deserialize(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr.GetState().ExecPath[0] != "foo" {
+		t.Fatalf("unexpected path: %v", pr.GetState().ExecPath)
+	}
+
+	b[len(envelopeMagic)+2] ^= 0x01
+	pr = NewPersister(store).WithCipher(aead)
+	err = pr.Deserialize(b)
+	if !IsIntegrity(err) {
+		t.Fatalf("expected integrity error, got %v", err)
+	}
+
+	pr = NewPersister(store)
+	err = pr.Deserialize(b)
+	if !errors.Is(err, ErrFormat) {
+		t.Fatalf("expected format error, got %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package persist
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const (
+	// FormatVersion is the version of the envelope written by Serialize.
+	//
+	// Data stored before the envelope was introduced is read as format version 0.
+	FormatVersion = 2
+)
+
+const (
+	// Payload is compressed.
+	PAYLOAD_COMPRESSED = 1
+	// Payload is followed by a HMAC-SHA256 of the envelope.
+	PAYLOAD_HMAC = 2
+	// Payload is encrypted with an authenticated cipher.
+	PAYLOAD_ENCRYPTED = 4
+)
+
+var (
+	envelopeMagic = []byte("vise")
+	// ErrFormat is returned when persisted data cannot be decoded to an envelope.
+	ErrFormat = errors.New("invalid persisted data format")
+	// ErrVersion is returned when persisted data has a version that cannot be migrated to the version of the persister.
+	ErrVersion = errors.New("unsupported persisted data version")
+)
+
+// Envelope is the versioned container of persisted state and cache data.
+//
+// It is encoded as:
+//
+// `"vise" | Big-endian uint16 format version | Big-endian uint32 data version | payload flags | payload`
+//
+// The payload flags byte is not present in format version 1.
+type Envelope struct {
+	// Version of the envelope encoding.
+	Format uint16
+	// Application defined version of the payload.
+	Version uint32
+	// Bitmask of PAYLOAD_* transformations applied to the payload.
+	Flags uint8
+	// Encoded state and cache.
+	Payload []byte
+}
+
+// encode the envelope header.
+func (e Envelope) header() []byte {
+	b := make([]byte, len(envelopeMagic)+6)
+	copy(b, envelopeMagic)
+	binary.BigEndian.PutUint16(b[len(envelopeMagic):], e.Format)
+	binary.BigEndian.PutUint32(b[len(envelopeMagic)+2:], e.Version)
+	if e.Format > 1 {
+		b = append(b, e.Flags)
+	}
+	return b
+}
+
+// Bytes encodes the envelope.
+func (e Envelope) Bytes() []byte {
+	return append(e.header(), e.Payload...)
+}
+
+// DecodeEnvelope decodes persisted data to an envelope.
+//
+// Data without an envelope is returned as format version 0 and data version 0, with the data itself as payload.
+func DecodeEnvelope(b []byte) (Envelope, error) {
+	var e Envelope
+	if !bytes.HasPrefix(b, envelopeMagic) {
+		e.Payload = b
+		return e, nil
+	}
+	b = b[len(envelopeMagic):]
+	if len(b) < 6 {
+		return e, fmt.Errorf("%w: short envelope header", ErrFormat)
+	}
+	e.Format = binary.BigEndian.Uint16(b)
+	if e.Format == 0 || e.Format > FormatVersion {
+		return e, fmt.Errorf("%w: unknown envelope format %d", ErrFormat, e.Format)
+	}
+	e.Version = binary.BigEndian.Uint32(b[2:])
+	b = b[6:]
+	if e.Format > 1 {
+		if len(b) < 1 {
+			return e, fmt.Errorf("%w: short envelope header", ErrFormat)
+		}
+		e.Flags = b[0]
+		b = b[1:]
+	}
+	e.Payload = b
+	return e, nil
+}
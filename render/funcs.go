@@ -0,0 +1,180 @@
+package render
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/grassrootseconomics/go-vise/lang"
+)
+
+const (
+	ellipsis = "..."
+)
+
+// WithFuncs is a chainable function that adds functions available to all templates rendered by the page.
+//
+// The functions are added to the builtin functions, and replace any builtin function with the same name. Functions added by previous calls are kept.
+//
+// The builtin functions are:
+//
+//   - pad n s: pad s with spaces on the right to size n.
+//   - lpad n s: pad s with spaces on the left to size n.
+//   - trunc n s: truncate s to size n, ending with an ellipsis if truncated.
+//   - num d v: format the number v with d decimals and thousands separators.
+//   - money sym d v: format the number v like num, with the currency symbol sym.
+//   - date v: format the date of the time v.
+//   - datetime v: format the date and time of day of the time v.
+//   - plural one many n: choose one if n is 1, otherwise many.
+//
+// Sizes are measured with the SizeFunc of the Sizer of the page, or in bytes if no Sizer is set.
+//
+// Numbers and dates are formatted with the lang.Locale of the language in the render context. Times may be given as time.Time, RFC3339 string or unix timestamp.
+func (pg *Page) WithFuncs(fm template.FuncMap) *Page {
+	if pg.funcs == nil {
+		pg.funcs = make(template.FuncMap)
+	}
+	for k, v := range fm {
+		pg.funcs[k] = v
+	}
+	pg.funcKey = funcKey(pg.funcs)
+	return pg
+}
+
+// all functions available to the template.
+//
+// numbers and dates are formatted according to the locale of the language in the context.
+func (pg *Page) funcMap(ctx context.Context) template.FuncMap {
+	lc := lang.LocaleFromContext(ctx)
+	size := ByteSize
+	if pg.sizer != nil {
+		size = pg.sizer.Len
+	}
+	fm := template.FuncMap{
+		"pad": func(n int, s string) string {
+			return pad(size, n, s, false)
+		},
+		"lpad": func(n int, s string) string {
+			return pad(size, n, s, true)
+		},
+		"trunc": func(n int, s string) string {
+			return trunc(size, n, s)
+		},
+		"num": func(decimals int, v any) (string, error) {
+			n, err := toFloat(v)
+			if err != nil {
+				return "", err
+			}
+			return lc.FormatNumber(n, decimals), nil
+		},
+		"money": func(symbol string, decimals int, v any) (string, error) {
+			n, err := toFloat(v)
+			if err != nil {
+				return "", err
+			}
+			return lc.FormatCurrency(n, decimals, symbol), nil
+		},
+		"date": func(v any) (string, error) {
+			t, err := toTime(v)
+			if err != nil {
+				return "", err
+			}
+			return lc.FormatDate(t), nil
+		},
+		"datetime": func(v any) (string, error) {
+			t, err := toTime(v)
+			if err != nil {
+				return "", err
+			}
+			return lc.FormatDateTime(t), nil
+		},
+		"plural": plural,
+	}
+	for k, v := range pg.funcs {
+		fm[k] = v
+	}
+	return fm
+}
+
+func pad(size SizeFunc, n int, s string, left bool) string {
+	l := int(size(s))
+	if l >= n {
+		return s
+	}
+	p := strings.Repeat(" ", n-l)
+	if left {
+		return p + s
+	}
+	return s + p
+}
+
+func trunc(size SizeFunc, n int, s string) string {
+	if int(size(s)) <= n {
+		return s
+	}
+	sfx := ellipsis
+	if int(size(sfx)) > n {
+		sfx = ""
+	}
+	r := ""
+	for _, c := range s {
+		if int(size(r+string(c)+sfx)) > n {
+			break
+		}
+		r += string(c)
+	}
+	return r + sfx
+}
+
+// parse numeric template argument, which may be a string from the cache.
+func toFloat(v any) (float64, error) {
+	switch n := v.(type) {
+	case string:
+		return strconv.ParseFloat(strings.TrimSpace(n), 64)
+	case int:
+		return float64(n), nil
+	case int64:
+		return float64(n), nil
+	case uint32:
+		return float64(n), nil
+	case uint64:
+		return float64(n), nil
+	case float64:
+		return n, nil
+	}
+	return 0, fmt.Errorf("not a number: %v", v)
+}
+
+// parse time template argument, which may be a RFC3339 or unix timestamp string from the cache.
+func toTime(v any) (time.Time, error) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, nil
+	case string:
+		t = strings.TrimSpace(t)
+		n, err := strconv.ParseInt(t, 10, 64)
+		if err == nil {
+			return time.Unix(n, 0).UTC(), nil
+		}
+		return time.Parse(time.RFC3339, t)
+	}
+	n, err := toFloat(v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("not a time: %v", v)
+	}
+	return time.Unix(int64(n), 0).UTC(), nil
+}
+
+func plural(one string, many string, v any) (string, error) {
+	n, err := toFloat(v)
+	if err != nil {
+		return "", err
+	}
+	if n == 1 {
+		return one, nil
+	}
+	return many, nil
+}
@@ -0,0 +1,78 @@
+package render
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/grassrootseconomics/go-vise/cache"
+	"github.com/grassrootseconomics/go-vise/internal/resourcetest"
+	"github.com/grassrootseconomics/go-vise/lang"
+)
+
+func TestPageFuncs(t *testing.T) {
+	ctx := context.Background()
+	ca := cache.NewCache()
+	rs := resourcetest.NewTestResource()
+	rs.AddTemplate(ctx, "foo", "{{.name | trunc 10}}|{{.name | pad 20}}|{{.amount | num 2}} {{.count | plural \"ghost\" \"ghosts\"}} {{.name | shout}}")
+	rs.Lock()
+	pg := NewPage(ca, rs).WithFuncs(template.FuncMap{
+		"shout": strings.ToUpper,
+	})
+	ca.Push()
+	ca.Add("name", "inky pinky blinky", 0)
+	ca.Add("amount", "-1234567.891", 20)
+	ca.Add("count", "2", 1)
+	pg.Map("name")
+	pg.Map("amount")
+	pg.Map("count")
+
+	r, err := pg.Render(ctx, "foo", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := "inky pi...|inky pinky blinky   |-1,234,567.89 ghosts INKY PINKY BLINKY"
+	if r != expect {
+		t.Fatalf("expected:\n\t%s\ngot:\n\t%s\n", expect, r)
+	}
+}
+
+func TestFuncsSizeAware(t *testing.T) {
+	if trunc(Gsm7Size, 6, "{{{{") != "{..." {
+		t.Fatalf("unexpected gsm7 truncate: %s", trunc(Gsm7Size, 6, "{{{{"))
+	}
+	if trunc(RuneSize, 2, "ሰላም") != "ሰላ" {
+		t.Fatalf("unexpected rune truncate: %s", trunc(RuneSize, 2, "ሰላም"))
+	}
+	if pad(RuneSize, 4, "ሰላ", true) != "  ሰላ" {
+		t.Fatalf("unexpected rune pad: '%s'", pad(RuneSize, 4, "ሰላ", true))
+	}
+}
+
+func TestPageFuncsLocale(t *testing.T) {
+	ln, err := lang.LanguageFromCode("deu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.WithValue(context.Background(), "Language", ln)
+	ca := cache.NewCache()
+	rs := resourcetest.NewTestResource()
+	rs.AddTemplate(ctx, "foo", "{{.amount | num 1}} {{.amount | money \"€\" 2}} {{.when | date}} {{.when | datetime}}")
+	rs.Lock()
+	pg := NewPage(ca, rs)
+	ca.Push()
+	ca.Add("amount", "4321.5", 20)
+	ca.Add("when", "1709300220", 10)
+	pg.Map("amount")
+	pg.Map("when")
+
+	r, err := pg.Render(ctx, "foo", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := "4.321,5 4.321,50 € 01.03.2024 01.03.2024 13:37"
+	if r != expect {
+		t.Fatalf("expected:\n\t%s\ngot:\n\t%s\n", expect, r)
+	}
+}
@@ -0,0 +1,88 @@
+package render
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	tagRegex = regexp.MustCompile(`<[^>]*>`)
+)
+
+// HtmlRenderer formats pages as HTML fragments for web clients.
+//
+// Content is escaped, with line breaks preserved. Menu entries are rendered as a list of form buttons, or as links if WithLinks is used. Selectors are not displayed.
+type HtmlRenderer struct {
+	inputName  string
+	linkFormat string
+}
+
+// NewHtmlRenderer creates a new HtmlRenderer.
+//
+// By default, menu buttons submit the selector in the form field "input".
+func NewHtmlRenderer() *HtmlRenderer {
+	return &HtmlRenderer{
+		inputName: "input",
+	}
+}
+
+// WithInputName is a chainable function that sets the name of the form field submitted by menu buttons.
+func (rdr *HtmlRenderer) WithInputName(name string) *HtmlRenderer {
+	rdr.inputName = name
+	return rdr
+}
+
+// WithLinks is a chainable function that renders menu entries as links instead of buttons.
+//
+// The format receives the query escaped selector, e.g. "?input=%s".
+func (rdr *HtmlRenderer) WithLinks(format string) *HtmlRenderer {
+	rdr.linkFormat = format
+	return rdr
+}
+
+// Content implements the Renderer interface.
+func (rdr *HtmlRenderer) Content(s string) string {
+	var lines []string
+	for _, v := range strings.Split(s, "\n") {
+		lines = append(lines, html.EscapeString(v))
+	}
+	return `<p class="vise-content">` + strings.Join(lines, "<br/>\n") + "</p>"
+}
+
+// Menu implements the Renderer interface.
+func (rdr *HtmlRenderer) Menu(entries [][2]string, sep string) string {
+	if len(entries) == 0 {
+		return ""
+	}
+	var items []string
+	for _, v := range entries {
+		title := html.EscapeString(v[1])
+		if v[0] == "" {
+			items = append(items, `<li class="vise-info">`+title+"</li>")
+		} else if rdr.linkFormat != "" {
+			href := html.EscapeString(fmt.Sprintf(rdr.linkFormat, url.QueryEscape(v[0])))
+			items = append(items, fmt.Sprintf(`<li><a href="%s">%s</a></li>`, href, title))
+		} else {
+			items = append(items, fmt.Sprintf(`<li><button type="submit" name="%s" value="%s">%s</button></li>`, html.EscapeString(rdr.inputName), html.EscapeString(v[0]), title))
+		}
+	}
+	return `<ul class="vise-menu">` + strings.Join(items, "\n") + "</ul>"
+}
+
+// Join implements the Renderer interface.
+func (rdr *HtmlRenderer) Join(content string, menu string) string {
+	if menu == "" {
+		return content
+	}
+	return content + "\n" + menu
+}
+
+// Text implements the Renderer interface.
+//
+// Markup is not counted towards the size constraint.
+func (rdr *HtmlRenderer) Text(s string) string {
+	return html.UnescapeString(tagRegex.ReplaceAllString(s, ""))
+}
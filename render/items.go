@@ -0,0 +1,24 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grassrootseconomics/go-vise/resource"
+)
+
+// Items renders list items as menu entries numbered from 1, one per line, with the given separator between number and title.
+//
+// If the separator is empty, the default menu separator is used.
+//
+// Numbering is continuous when the list is paginated as sink content, so the number shown is the selector of the item on any page.
+func Items(items []resource.Item, sep string) string {
+	if sep == "" {
+		sep = defaultSeparator
+	}
+	var lines []string
+	for i, v := range items {
+		lines = append(lines, fmt.Sprintf("%d%s%s", i+1, sep, v.Title))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,80 @@
+package render
+
+import (
+	"strings"
+)
+
+const (
+	markdownSpecial = "\\*_`[]"
+)
+
+// MarkdownRenderer formats pages as Markdown for chat platforms.
+//
+// Content is escaped, with line breaks preserved. Menu entries are rendered one per line with the selector in bold, separated from the title by a space.
+type MarkdownRenderer struct {
+}
+
+// NewMarkdownRenderer creates a new MarkdownRenderer.
+func NewMarkdownRenderer() *MarkdownRenderer {
+	return &MarkdownRenderer{}
+}
+
+func markdownEscape(s string) string {
+	var sb strings.Builder
+	for _, c := range s {
+		if strings.ContainsRune(markdownSpecial, c) {
+			sb.WriteRune('\\')
+		}
+		sb.WriteRune(c)
+	}
+	return sb.String()
+}
+
+// Content implements the Renderer interface.
+func (rdr *MarkdownRenderer) Content(s string) string {
+	return markdownEscape(s)
+}
+
+// Menu implements the Renderer interface.
+func (rdr *MarkdownRenderer) Menu(entries [][2]string, sep string) string {
+	var lines []string
+	for _, v := range entries {
+		if v[0] == "" {
+			lines = append(lines, "_"+markdownEscape(v[1])+"_")
+			continue
+		}
+		lines = append(lines, "**"+markdownEscape(v[0])+"** "+markdownEscape(v[1]))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Join implements the Renderer interface.
+func (rdr *MarkdownRenderer) Join(content string, menu string) string {
+	if menu == "" {
+		return content
+	}
+	return content + "\n" + menu
+}
+
+// Text implements the Renderer interface.
+//
+// Escapes and emphasis markers are not counted towards the size constraint, as chat platforms limit the message length after markup is parsed.
+func (rdr *MarkdownRenderer) Text(s string) string {
+	var sb strings.Builder
+	var escaped bool
+	for _, c := range s {
+		if escaped {
+			sb.WriteRune(c)
+			escaped = false
+			continue
+		}
+		switch c {
+		case '\\':
+			escaped = true
+		case '*', '_':
+		default:
+			sb.WriteRune(c)
+		}
+	}
+	return sb.String()
+}
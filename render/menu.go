@@ -20,6 +20,25 @@ func (err *BrowseError) Error() string {
 	return fmt.Sprintf("index is out of bounds: %v", err.Idx)
 }
 
+// PageIndicatorPosition defines where the page position indicator is placed in the rendered output.
+type PageIndicatorPosition uint8
+
+const (
+	// Indicator is rendered as the first line of the menu.
+	PAGE_INDICATOR_BEFORE_MENU PageIndicatorPosition = iota
+	// Indicator is rendered as the last line of the menu.
+	PAGE_INDICATOR_AFTER_MENU
+	// Indicator is only available to the template as the PageIndicatorSymbol variable.
+	PAGE_INDICATOR_TEMPLATE
+)
+
+const (
+	// PageIndicatorSymbol is the template variable holding the page position indicator.
+	//
+	// It is always defined when rendering a page with a menu, and is empty if the content is not browsable.
+	PageIndicatorSymbol = "_page"
+)
+
 // BrowseConfig defines the availability and display parameters for page browsing.
 type BrowseConfig struct {
 	// Set if a consecutive page is available for lateral navigation.
@@ -34,6 +53,10 @@ type BrowseConfig struct {
 	PreviousSelector string
 	// Menu title used to label selector for previous page.
 	PreviousTitle string
+	// Format of the page position indicator, e.g. "(%d/%d)", given the current page number and the page count. It is translated like the menu titles. If empty, no indicator is shown.
+	PageIndicator string
+	// Placement of the page position indicator.
+	PageIndicatorPosition PageIndicatorPosition
 }
 
 // Create a BrowseConfig with default values.
@@ -48,6 +71,10 @@ func DefaultBrowseConfig() BrowseConfig {
 	}
 }
 
+const (
+	defaultSeparator = ":"
+)
+
 // Menu renders menus.
 //
 // May be included in a Page object to render menus for pages.
@@ -75,7 +102,7 @@ func (m Menu) String() string {
 func NewMenu() *Menu {
 	return &Menu{
 		keep: true,
-		sep:  ":",
+		sep:  defaultSeparator,
 	}
 }
 
@@ -164,30 +191,75 @@ func (m *Menu) Put(selector string, title string) error {
 //  2. prevsize
 //  3. nextsize
 //  4. nextsize + prevsize
+//
+// If a page indicator is defined, its size is included in the next size, as it is shown on all pages of browsable content.
+//
+// Sizes are measured in bytes.
 func (m *Menu) Sizes(ctx context.Context) ([4]uint32, error) {
+	return m.sizes(ctx, ByteSize, 2, defaultRenderer)
+}
+
+// sizes of the visible text of the menu formatted by the renderer, measured with the given size function.
+//
+// the page indicator is measured as the last of the given number of pages.
+func (m *Menu) sizes(ctx context.Context, fn SizeFunc, pages uint16, rdr Renderer) ([4]uint32, error) {
 	var menuSizes [4]uint32
 	cfg := m.GetBrowseConfig()
+	ind := cfg.PageIndicator
+	cfg.PageIndicator = ""
 	tmpm := NewMenu().WithBrowseConfig(cfg)
-	v, err := tmpm.Render(ctx, 0)
+	v, err := tmpm.renderWith(ctx, 0, rdr)
 	if err != nil {
 		return menuSizes, err
 	}
-	menuSizes[0] = uint32(len(v))
+	menuSizes[0] = fn(rdr.Text(v))
 	tmpm = tmpm.WithPageCount(2)
-	v, err = tmpm.Render(ctx, 0)
+	v, err = tmpm.renderWith(ctx, 0, rdr)
 	if err != nil {
 		return menuSizes, err
 	}
-	menuSizes[1] = uint32(len(v)) - menuSizes[0]
-	v, err = tmpm.Render(ctx, 1)
+	menuSizes[1] = fn(rdr.Text(v)) - menuSizes[0]
+	v, err = tmpm.renderWith(ctx, 1, rdr)
 	if err != nil {
 		return menuSizes, err
 	}
-	menuSizes[2] = uint32(len(v)) - menuSizes[0]
+	menuSizes[2] = fn(rdr.Text(v)) - menuSizes[0]
+	if ind != "" {
+		if pages < 2 {
+			pages = 2
+		}
+		v, err = m.indicatorFor(ctx, ind, pages-1, pages)
+		if err != nil {
+			return menuSizes, err
+		}
+		menuSizes[1] += fn(v)
+		if cfg.PageIndicatorPosition != PAGE_INDICATOR_TEMPLATE {
+			menuSizes[1] += 1
+		}
+	}
 	menuSizes[3] = menuSizes[1] + menuSizes[2]
 	return menuSizes, nil
 }
 
+// page position indicator for the page index, in the given format.
+func (m *Menu) indicatorFor(ctx context.Context, format string, idx uint16, pageCount uint16) (string, error) {
+	format, err := m.titleFor(ctx, format)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(format, idx+1, pageCount), nil
+}
+
+// Indicator returns the page position indicator for the page index.
+//
+// Returns an empty string if no indicator is defined, or if the content is not browsable.
+func (m *Menu) Indicator(ctx context.Context, idx uint16) (string, error) {
+	if m.browse.PageIndicator == "" || m.pageCount < 2 {
+		return "", nil
+	}
+	return m.indicatorFor(ctx, m.browse.PageIndicator, idx, m.pageCount)
+}
+
 // title corresponding to the menu symbol.
 func (m *Menu) titleFor(ctx context.Context, title string) (string, error) {
 	if m.rs == nil {
@@ -204,6 +276,22 @@ func (m *Menu) titleFor(ctx context.Context, title string) (string, error) {
 //
 // After this has been executed, the state of the menu will be empty.
 func (m *Menu) Render(ctx context.Context, idx uint16) (string, error) {
+	return m.renderWith(ctx, idx, defaultRenderer)
+}
+
+// render the menu entries with the given renderer.
+func (m *Menu) renderWith(ctx context.Context, idx uint16, rdr Renderer) (string, error) {
+	entries, err := m.entries(ctx, idx)
+	if err != nil {
+		return "", err
+	}
+	return rdr.Menu(entries, m.sep), nil
+}
+
+// selector and translated title of all menu entries for the page index, including browse options and the page indicator.
+//
+// the page indicator has an empty selector.
+func (m *Menu) entries(ctx context.Context, idx uint16) ([][2]string, error) {
 	var menuCopy [][2]string
 	if m.keep {
 		for _, v := range m.menu {
@@ -213,24 +301,31 @@ func (m *Menu) Render(ctx context.Context, idx uint16) (string, error) {
 
 	err := m.applyPage(idx)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	r := ""
+	var r [][2]string
 	for true {
-		l := len(r)
 		choice, title, err := m.shiftMenu()
 		if err != nil {
 			break
 		}
-		if l > 0 {
-			r += "\n"
-		}
 		title, err = m.titleFor(ctx, title)
 		if err != nil {
-			return "", err
+			return nil, err
+		}
+		r = append(r, [2]string{choice, title})
+	}
+	if m.browse.PageIndicatorPosition != PAGE_INDICATOR_TEMPLATE {
+		ind, err := m.Indicator(ctx, idx)
+		if err != nil {
+			return nil, err
+		}
+		if ind != "" && m.browse.PageIndicatorPosition == PAGE_INDICATOR_BEFORE_MENU {
+			r = append([][2]string{{"", ind}}, r...)
+		} else if ind != "" {
+			r = append(r, [2]string{"", ind})
 		}
-		r += fmt.Sprintf("%s%s%s", choice, m.sep, title)
 	}
 	if m.keep {
 		m.menu = menuCopy
@@ -3,6 +3,8 @@ package render
 import (
 	"context"
 	"testing"
+
+	"github.com/grassrootseconomics/go-vise/internal/resourcetest"
 )
 
 func TestMenuInit(t *testing.T) {
@@ -109,3 +111,67 @@ func TestMenuSep(t *testing.T) {
 		t.Fatalf("expected:\n\t%s\ngot:\n\t%s\n", expect, r)
 	}
 }
+
+func TestMenuPageIndicator(t *testing.T) {
+	ctx := context.Background()
+	rs := resourcetest.NewTestResource()
+	rs.AddMenu(ctx, "(%d/%d)_menu", "[%d av %d]")
+	rs.Lock()
+	cfg := DefaultBrowseConfig()
+	cfg.PageIndicator = "(%d/%d)"
+	m := NewMenu().WithPageCount(3).WithBrowseConfig(cfg)
+	m.Put("1", "foo")
+
+	r, err := m.Render(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `(2/3)
+1:foo
+11:next
+22:previous`
+	if r != expect {
+		t.Fatalf("expected:\n\t%s\ngot:\n\t%s\n", expect, r)
+	}
+
+	cfg.PageIndicatorPosition = PAGE_INDICATOR_AFTER_MENU
+	m = m.WithBrowseConfig(cfg).WithResource(rs)
+	r, err = m.Render(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect = `1:foo
+22:previous
+[3 av 3]`
+	if r != expect {
+		t.Fatalf("expected:\n\t%s\ngot:\n\t%s\n", expect, r)
+	}
+
+	cfg.PageIndicatorPosition = PAGE_INDICATOR_TEMPLATE
+	m = m.WithBrowseConfig(cfg)
+	r, err = m.Render(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect = `1:foo
+11:next`
+	if r != expect {
+		t.Fatalf("expected:\n\t%s\ngot:\n\t%s\n", expect, r)
+	}
+	r, err = m.Indicator(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r != "[1 av 3]" {
+		t.Fatalf("expected '[1 av 3]', got '%s'", r)
+	}
+
+	m = NewMenu().WithBrowseConfig(cfg)
+	r, err = m.Indicator(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r != "" {
+		t.Fatalf("expected no indicator for single page, got '%s'", r)
+	}
+}
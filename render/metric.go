@@ -0,0 +1,76 @@
+package render
+
+import (
+	"unicode/utf8"
+)
+
+// SizeFunc measures the size of rendered output in the units of the transport channel.
+type SizeFunc func(s string) uint32
+
+var (
+	// GSM 03.38 basic character set.
+	gsm7Basic = map[rune]bool{}
+	// GSM 03.38 extension table characters, which are sent as an escape sequence of two septets.
+	gsm7Extension = map[rune]bool{}
+)
+
+func init() {
+	for _, c := range "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà" {
+		gsm7Basic[c] = true
+	}
+	for _, c := range "\f^{}\\[~]|€" {
+		gsm7Extension[c] = true
+	}
+}
+
+// ByteSize measures the string in bytes.
+//
+// This is the default SizeFunc of the Sizer.
+func ByteSize(s string) uint32 {
+	return uint32(len(s))
+}
+
+// RuneSize measures the string in unicode code points.
+func RuneSize(s string) uint32 {
+	return uint32(utf8.RuneCountInString(s))
+}
+
+// Gsm7Size measures the string in GSM 03.38 septets.
+//
+// Characters of the extension table count as two septets. Characters outside the GSM 03.38 alphabet also count as two septets, but cannot be encoded; see IsGsm7 and Sizer.WithUcs2Fallback.
+func Gsm7Size(s string) uint32 {
+	var l uint32
+	for _, c := range s {
+		if gsm7Basic[c] {
+			l += 1
+		} else {
+			l += 2
+		}
+	}
+	return l
+}
+
+// Ucs2Size measures the string in UTF-16 code units.
+//
+// Characters outside the basic multilingual plane count as two code units.
+func Ucs2Size(s string) uint32 {
+	var l uint32
+	for _, c := range s {
+		if c > 0xffff {
+			l += 2
+		} else {
+			l += 1
+		}
+	}
+	return l
+}
+
+// IsGsm7 returns true if all characters of the string can be encoded in the GSM 03.38 alphabet.
+func IsGsm7(s string) bool {
+	for _, c := range s {
+		if !gsm7Basic[c] && !gsm7Extension[c] {
+			return false
+		}
+	}
+	return true
+}
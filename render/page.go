@@ -21,6 +21,11 @@ type Page struct {
 	sizer    *Sizer            // Process size constraints.
 	err      error             // Error state to prepend to output.
 	extra    string            // Extra content to append to received template
+	funcs    template.FuncMap  // Custom template functions.
+	renderer Renderer          // Output format.
+	tplCache *TemplateCache    // Parsed templates.
+	funcKey  string            // Names of custom template functions, part of the template cache key.
+	smsReply string            // Format of reply keyword footer lines in SMS output.
 }
 
 // NewPage creates a new Page object.
@@ -50,6 +55,41 @@ func (pg *Page) WithSizer(sizer *Sizer) *Page {
 	return pg
 }
 
+// WithRenderer sets the output format of the page.
+//
+// If not set, the page is rendered as plain text.
+func (pg *Page) WithRenderer(rdr Renderer) *Page {
+	pg.renderer = rdr
+	return pg
+}
+
+// WithTemplateCache sets the cache of parsed templates used by the page.
+//
+// If not set, templates are parsed on every render.
+func (pg *Page) WithTemplateCache(tc *TemplateCache) *Page {
+	pg.tplCache = tc
+	return pg
+}
+
+// parsed template for the symbol with the template functions for the context.
+//
+// templates with an error prepended are not cached, as the error message varies.
+func (pg *Page) template(ctx context.Context, sym string, tpl string) (*template.Template, error) {
+	fm := pg.funcMap(ctx)
+	if pg.tplCache == nil || pg.err != nil {
+		return parseTemplate(tpl, fm)
+	}
+	return pg.tplCache.get(templateKey(ctx, pg.funcKey, sym+pg.extra), tpl, fm)
+}
+
+// output format of the page.
+func (pg *Page) rdr() Renderer {
+	if pg.renderer == nil {
+		return defaultRenderer
+	}
+	return pg.renderer
+}
+
 // WithError adds an error to prepend to the page output.
 func (pg *Page) WithError(err error) *Page {
 	pg.err = err
@@ -150,6 +190,27 @@ func (pg *Page) Sizes() (map[string]uint16, error) {
 
 // RenderTemplate is an adapter to implement the builtin golang text template renderer as resource.RenderTemplate.
 func (pg *Page) RenderTemplate(ctx context.Context, sym string, values map[string]string, idx uint16) (string, error) {
+	var err error
+	if pg.sizer != nil {
+		values, err = pg.sizer.GetAt(values, idx)
+		if err != nil {
+			return "", err
+		}
+	} else if idx > 0 {
+		return "", fmt.Errorf("sizer needed for indexed render")
+	}
+	if pg.menu != nil {
+		values, err = pg.withIndicator(ctx, values, idx)
+		if err != nil {
+			return "", err
+		}
+	}
+	logg.Debugf("render for", "index", idx)
+	return pg.execTemplate(ctx, sym, values)
+}
+
+// render the template of the symbol with the given values, with error and extra content applied.
+func (pg *Page) execTemplate(ctx context.Context, sym string, values map[string]string) (string, error) {
 	tpl, err := pg.resource.GetTemplate(ctx, sym)
 	if err != nil {
 		return "", err
@@ -164,17 +225,8 @@ func (pg *Page) RenderTemplate(ctx context.Context, sym string, values map[strin
 			tpl = fmt.Sprintf("%s\n%s", derr, tpl)
 		}
 	}
-	if pg.sizer != nil {
-		values, err = pg.sizer.GetAt(values, idx)
-		if err != nil {
-			return "", err
-		}
-	} else if idx > 0 {
-		return "", fmt.Errorf("sizer needed for indexed render")
-	}
-	logg.Debugf("render for", "index", idx)
 
-	tp, err := template.New("tester").Option("missingkey=error").Parse(tpl)
+	tp, err := pg.template(ctx, sym, tpl)
 	if err != nil {
 		return "", err
 	}
@@ -187,6 +239,23 @@ func (pg *Page) RenderTemplate(ctx context.Context, sym string, values map[strin
 	return b.String(), err
 }
 
+// copy of values with the page indicator for the page index added.
+func (pg *Page) withIndicator(ctx context.Context, values map[string]string, idx uint16) (map[string]string, error) {
+	var err error
+	outValues := make(map[string]string)
+	for k, v := range values {
+		outValues[k] = v
+	}
+	outValues[PageIndicatorSymbol] = ""
+	if pg.menu.browse.PageIndicatorPosition == PAGE_INDICATOR_TEMPLATE {
+		outValues[PageIndicatorSymbol], err = pg.menu.Indicator(ctx, idx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return outValues, nil
+}
+
 // Render renders the current mapped content and menu state against the template associated with the symbol.
 func (pg *Page) Render(ctx context.Context, sym string, idx uint16) (string, error) {
 	var err error
@@ -248,8 +317,10 @@ func (pg *Page) split(sym string, values map[string]string) (map[string]string,
 // newlines (within the same page) render are defined by NUL (0x00).
 //
 // pages are separated by LF (0x0a).
+//
+// records are kept on the same page if they fit, and items too large for a page are broken at word boundaries if enabled in the sizer.
 func (pg *Page) joinSink(sinkValues []string, remaining uint32, menuSizes [4]uint32) (string, uint16, error) {
-	l := 0
+	var l uint32
 	var count uint16
 	tb := strings.Builder{}
 	rb := strings.Builder{}
@@ -258,33 +329,74 @@ func (pg *Page) joinSink(sinkValues []string, remaining uint32, menuSizes [4]uin
 	netRemaining := remaining - 1
 
 	// BUG: this reserves the previous browse before we know we need it
-	if len(sinkValues) > 1 {
+	if len(sinkValues) > 1 || (len(sinkValues) == 1 && pg.sinkLen(sinkValues[0]) > netRemaining-1) {
 		netRemaining -= (menuSizes[1] + 1)
 	}
 
-	for i, v := range sinkValues {
-		l += len(v)
-		logg.Tracef("processing sink", "idx", i, "value", v, "netremaining", netRemaining, "l", l)
-		if uint32(l) > netRemaining-1 {
-			if tb.Len() == 0 {
-				return "", 0, fmt.Errorf("capacity insufficient for sink field %v", i)
-			}
-			rb.WriteString(tb.String())
-			rb.WriteRune('\n')
-			c := uint32(rb.Len())
-			pg.sizer.AddCursor(c)
-			tb.Reset()
-			l = len(v)
-			if count == 0 {
-				netRemaining -= (menuSizes[2] + 1)
-			}
-			count += 1
-		}
+	fits := func(sz uint32) bool {
+		return l+sz <= netRemaining-1
+	}
+	add := func(v string) {
 		if tb.Len() > 0 {
 			tb.WriteByte(byte(0x00))
 			l += 1
 		}
 		tb.WriteString(v)
+		l += pg.sinkLen(v)
+	}
+	flush := func() {
+		rb.WriteString(tb.String())
+		rb.WriteRune('\n')
+		c := uint32(rb.Len())
+		pg.sizer.AddCursor(c)
+		tb.Reset()
+		l = 0
+		if count == 0 {
+			netRemaining -= (menuSizes[2] + 1)
+		}
+		count += 1
+	}
+
+	var i int
+	for _, rec := range records(sinkValues, pg.sizer.records, pg.sizer.recordSep) {
+		sz := recordSize(rec, pg.sinkLen)
+		logg.Tracef("processing sink record", "idx", i, "items", len(rec), "size", sz, "netremaining", netRemaining, "l", l)
+		if !fits(sz) && tb.Len() > 0 {
+			flush()
+		}
+		if fits(sz) {
+			for _, v := range rec {
+				add(v)
+			}
+			i += len(rec)
+			continue
+		}
+
+		// record too large for a single page, paginate its items individually.
+		for _, v := range rec {
+			if !fits(pg.sinkLen(v)) && tb.Len() > 0 {
+				flush()
+			}
+			for !fits(pg.sinkLen(v)) {
+				if !pg.sizer.wrap {
+					// only the first page is known to be exceeded, the render size check catches the rest.
+					if rb.Len() == 0 {
+						return "", 0, fmt.Errorf("capacity insufficient for sink field %v", i)
+					}
+					break
+				}
+				head, tail, err := breakWords(v, netRemaining-1, pg.sizer.wrapMarker, pg.sinkLen)
+				if err != nil {
+					return "", 0, fmt.Errorf("capacity insufficient for sink field %v: %v", i, err)
+				}
+				logg.Tracef("broke sink field", "idx", i, "head", head, "tail", tail)
+				add(head)
+				flush()
+				v = tail
+			}
+			add(v)
+			i += 1
+		}
 	}
 
 	if tb.Len() > 0 {
@@ -297,6 +409,12 @@ func (pg *Page) joinSink(sinkValues []string, remaining uint32, menuSizes [4]uin
 	return r, count, nil
 }
 
+// size of a sink value in the visible text of the renderer output, which is what the render size check measures.
+func (pg *Page) sinkLen(v string) uint32 {
+	rdr := pg.rdr()
+	return pg.sizer.Len(rdr.Text(rdr.Content(v)))
+}
+
 func (pg *Page) applyMenuSink(ctx context.Context) ([]string, error) {
 	s, err := pg.menu.WithDispose().WithPages().Render(ctx, 0)
 	if err != nil {
@@ -336,6 +454,9 @@ func (pg *Page) prepare(ctx context.Context, sym string, values map[string]strin
 		}
 	}
 
+	// measure all content in the same unit, even if only the sink is outside the gsm7 alphabet
+	pg.sizer.detect(sinkValues...)
+
 	// pre-render template without sink
 	// this includes the menu before any browsing options have been added
 	pg.sizer.AddCursor(0)
@@ -345,25 +466,40 @@ func (pg *Page) prepare(ctx context.Context, sym string, values map[string]strin
 	}
 
 	// this is the available bytes left for sink content and browse menu
-	remaining, ok := pg.sizer.Check(s)
+	//
+	// both the pre-rendered page and the sink values are measured on the visible text of the renderer output, like the size check of the final render.
+	remaining, ok := pg.sizer.Check(pg.rdr().Text(s))
 	if !ok {
 		return nil, fmt.Errorf("capacity exceeded")
 	}
 
-	// pre-calculate the menu sizes for all browse conditions
-	var menuSizes [4]uint32
-	if pg.menu != nil {
-		menuSizes, err = pg.menu.Sizes(ctx)
+	// pre-calculate the menu sizes for all browse conditions, and process sink values array into newline-separated string.
+	//
+	// the page count is not known in advance, and the page indicator is initially sized by the number of sink values. if pagination ends up needing more pages than that, it is repeated with the larger count.
+	var sinkString string
+	var count uint16
+	pages := uint16(len(sinkValues))
+	crsrs := len(pg.sizer.crsrs)
+	for {
+		var menuSizes [4]uint32
+		if pg.menu != nil {
+			menuSizes, err = pg.menu.sizes(ctx, pg.sizer.Len, pages, pg.rdr())
+			if err != nil {
+				return nil, err
+			}
+		}
+		logg.Debugf("calculated pre-navigation allocation", "bytes", remaining, "menusizes", menuSizes)
+
+		sinkString, count, err = pg.joinSink(sinkValues, remaining, menuSizes)
 		if err != nil {
 			return nil, err
 		}
-	}
-	logg.Debugf("calculated pre-navigation allocation", "bytes", remaining, "menusizes", menuSizes)
-
-	// process sink values array into newline-separated string
-	sinkString, count, err := pg.joinSink(sinkValues, remaining, menuSizes)
-	if err != nil {
-		return nil, err
+		if pg.menu == nil || pg.menu.browse.PageIndicator == "" || len(fmt.Sprint(count)) <= len(fmt.Sprint(pages)) {
+			break
+		}
+		logg.Debugf("page count exceeds indicator estimate, repaginating", "estimate", pages, "count", count)
+		pg.sizer.crsrs = pg.sizer.crsrs[:crsrs]
+		pages = count
 	}
 	noSinkValues[sink] = sinkString
 
@@ -383,28 +519,26 @@ func (pg *Page) prepare(ctx context.Context, sym string, values map[string]strin
 // render template, menu (if it exists), and audit size constraint (if it exists).
 func (pg *Page) render(ctx context.Context, sym string, values map[string]string, idx uint16) (string, error) {
 	var ok bool
-	r := ""
+	rdr := pg.rdr()
 	s, err := pg.RenderTemplate(ctx, sym, values, idx)
 	if err != nil {
 		return "", err
 	}
 	logg.Debugf("rendered template", "bytes", len(s))
-	r += s
+	content := rdr.Content(s)
 
+	menu := ""
 	if pg.menu != nil {
-		s, err = pg.menu.Render(ctx, idx)
+		menu, err = pg.menu.renderWith(ctx, idx, rdr)
 		if err != nil {
 			return "", err
 		}
-		l := len(s)
-		logg.Debugf("rendered menu", "bytes", l)
-		if l > 0 {
-			r += "\n" + s
-		}
+		logg.Debugf("rendered menu", "bytes", len(menu))
 	}
+	r := rdr.Join(content, menu)
 
 	if pg.sizer != nil {
-		_, ok = pg.sizer.Check(r)
+		_, ok = pg.sizer.Check(rdr.Text(r))
 		if !ok {
 			return "", fmt.Errorf("limit exceeded: %v", pg.sizer)
 		}
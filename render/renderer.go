@@ -0,0 +1,67 @@
+package render
+
+import (
+	"strings"
+)
+
+var (
+	defaultRenderer = NewTextRenderer()
+)
+
+// Renderer formats rendered pages for an output channel.
+//
+// Pagination and size constraints are applied to the visible text of the formatted output, as returned by Text.
+type Renderer interface {
+	// Content formats the output of a template.
+	Content(s string) string
+	// Menu formats the menu entries, given as selector and title pairs, using the menu separator for channels that display selectors.
+	//
+	// Entries with an empty selector are informational, e.g. the page indicator, and cannot be selected.
+	Menu(entries [][2]string, sep string) string
+	// Join combines the formatted content and menu into a page. The menu may be empty.
+	Join(content string, menu string) string
+	// Text returns the visible text of the formatted output, which is measured against the size constraint.
+	Text(s string) string
+}
+
+// TextRenderer formats pages as plain text, with one "selector:title" menu entry per line.
+//
+// This is the default renderer.
+type TextRenderer struct {
+}
+
+// NewTextRenderer creates a new TextRenderer.
+func NewTextRenderer() *TextRenderer {
+	return &TextRenderer{}
+}
+
+// Content implements the Renderer interface.
+func (rdr *TextRenderer) Content(s string) string {
+	return s
+}
+
+// Menu implements the Renderer interface.
+func (rdr *TextRenderer) Menu(entries [][2]string, sep string) string {
+	var lines []string
+	for _, v := range entries {
+		if v[0] == "" {
+			lines = append(lines, v[1])
+			continue
+		}
+		lines = append(lines, v[0]+sep+v[1])
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Join implements the Renderer interface.
+func (rdr *TextRenderer) Join(content string, menu string) string {
+	if menu == "" {
+		return content
+	}
+	return content + "\n" + menu
+}
+
+// Text implements the Renderer interface.
+func (rdr *TextRenderer) Text(s string) string {
+	return s
+}
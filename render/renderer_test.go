@@ -0,0 +1,128 @@
+package render
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/grassrootseconomics/go-vise/cache"
+	"github.com/grassrootseconomics/go-vise/internal/resourcetest"
+)
+
+func renderWith(t *testing.T, rdr Renderer, szr *Sizer) string {
+	ctx := context.Background()
+	rs := resourcetest.NewTestResource()
+	rs.AddTemplate(ctx, "foo", "a & <b>\n*c*_d")
+	rs.Lock()
+	ca := cache.NewCache()
+	mn := NewMenu()
+	mn.Put("1", "one")
+	mn.Put("2", "two")
+	pg := NewPage(ca, rs).WithMenu(mn).WithRenderer(rdr)
+	if szr != nil {
+		pg = pg.WithSizer(szr)
+	}
+	r, err := pg.Render(ctx, "foo", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestRendererText(t *testing.T) {
+	r := renderWith(t, NewTextRenderer(), nil)
+	expect := "a & <b>\n*c*_d\n1:one\n2:two"
+	if r != expect {
+		t.Fatalf("expected:\n\t%s\ngot:\n\t%s", expect, r)
+	}
+}
+
+func TestRendererHtml(t *testing.T) {
+	rdr := NewHtmlRenderer()
+	r := renderWith(t, rdr, nil)
+	expect := `<p class="vise-content">a &amp; &lt;b&gt;<br/>
+*c*_d</p>
+<ul class="vise-menu"><li><button type="submit" name="input" value="1">one</button></li>
+<li><button type="submit" name="input" value="2">two</button></li></ul>`
+	if r != expect {
+		t.Fatalf("expected:\n\t%s\ngot:\n\t%s", expect, r)
+	}
+	if rdr.Text(r) != "a & <b>\n*c*_d\none\ntwo" {
+		t.Fatalf("unexpected text: %s", rdr.Text(r))
+	}
+
+	r = renderWith(t, NewHtmlRenderer().WithLinks("/ussd?input=%s&s=x"), nil)
+	if !strings.Contains(r, `<li><a href="/ussd?input=2&amp;s=x">two</a></li>`) {
+		t.Fatalf("no link in output: %s", r)
+	}
+}
+
+func TestRendererMarkdown(t *testing.T) {
+	rdr := NewMarkdownRenderer()
+	r := renderWith(t, rdr, nil)
+	expect := "a & <b>\n\\*c\\*\\_d\n**1** one\n**2** two"
+	if r != expect {
+		t.Fatalf("expected:\n\t%s\ngot:\n\t%s", expect, r)
+	}
+	if rdr.Text(r) != "a & <b>\n*c*_d\n1 one\n2 two" {
+		t.Fatalf("unexpected text: %s", rdr.Text(r))
+	}
+}
+
+func TestRendererSsml(t *testing.T) {
+	rdr := NewSsmlRenderer()
+	r := renderWith(t, rdr, nil)
+	expect := `<speak><p><s>a &amp; &lt;b&gt;</s>
+<s>*c*_d</s></p>
+<p><s><say-as interpret-as="characters">1</say-as><break time="300ms"/>one</s>
+<s><say-as interpret-as="characters">2</say-as><break time="300ms"/>two</s></p></speak>`
+	if r != expect {
+		t.Fatalf("expected:\n\t%s\ngot:\n\t%s", expect, r)
+	}
+}
+
+func TestRendererSize(t *testing.T) {
+	r := renderWith(t, NewHtmlRenderer(), NewSizer(24))
+	if len(r) <= 24 {
+		t.Fatalf("expected markup to exceed size, got %d", len(r))
+	}
+	ctx := context.Background()
+	rs := resourcetest.NewTestResource()
+	rs.AddTemplate(ctx, "foo", "a & <b>\n*c*_d")
+	rs.Lock()
+	mn := NewMenu()
+	mn.Put("1", "one")
+	mn.Put("2", "two")
+	pg := NewPage(cache.NewCache(), rs).WithMenu(mn).WithRenderer(NewHtmlRenderer()).WithSizer(NewSizer(20))
+	_, err := pg.Render(ctx, "foo", 0)
+	if err == nil {
+		t.Fatal("expected size error")
+	}
+}
+
+func TestRendererSizeSink(t *testing.T) {
+	ctx := context.Background()
+	rs := resourcetest.NewTestResource()
+	rs.AddTemplate(ctx, "foo", "{{.bar}}")
+	rs.Lock()
+	ca := cache.NewCache()
+	ca.Push()
+	err := ca.Add("bar", "inky\n            \npinky", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rdr := NewSsmlRenderer()
+	pg := NewPage(ca, rs).WithRenderer(rdr).WithSizer(NewSizer(14))
+	err = pg.Map("bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := pg.Render(ctx, "foo", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the blank line is not spoken, and must not push content to another page.
+	if rdr.Text(r) != "inky\npinky" {
+		t.Fatalf("unexpected text: %q", rdr.Text(r))
+	}
+}
@@ -14,16 +14,98 @@ type Sizer struct {
 	totalMemberSize uint32            // total byte size of all content to be rendered by template (sum of memberSizes)
 	crsrs           []uint32          // byte offsets in the sink content for browseable pages indices.
 	sink            string            // sink symbol.
+	sizeFunc        SizeFunc          // measures output in units of the output size constraint.
+	ucs2Size        uint32            // output size constraint for content that cannot be encoded in GSM 03.38.
+	ucs2            bool              // true if current content cannot be encoded in GSM 03.38.
+	segmentHeader   [2]uint32         // size reserved in each part by Segments, for gsm7 and ucs2 content.
+	wrap            bool              // break sink items too large for a single page at word boundaries.
+	wrapMarker      string            // appended to every part of a broken sink item except the last.
+	records         bool              // keep records of sink items on the same page.
+	recordSep       string            // sink item delimiting records.
 }
 
 // NewSizer creates a new Sizer object with the given output size constraint.
+//
+// By default, output is measured in bytes.
 func NewSizer(outputSize uint32) *Sizer {
 	return &Sizer{
 		outputSize:  outputSize,
 		memberSizes: make(map[string]uint16),
+		sizeFunc:    ByteSize,
+	}
+}
+
+// WithSizeFunc is a chainable function that sets the unit in which the output size constraint is measured.
+//
+// It applies to the size check of rendered pages, as well as to the browse menu and sink pagination calculations.
+func (szr *Sizer) WithSizeFunc(fn SizeFunc) *Sizer {
+	if fn == nil {
+		fn = ByteSize
+	}
+	szr.sizeFunc = fn
+	return szr
+}
+
+// WithUcs2Fallback is a chainable function that sets an alternative output size constraint for content that cannot be encoded in the GSM 03.38 alphabet.
+//
+// When any character of the rendered content is outside the alphabet, output will instead be measured with Ucs2Size against this constraint, as SMS and USSD channels do. E.g. for SMS, 160 septets and 70 code units.
+//
+// The choice is kept until Reset is called.
+func (szr *Sizer) WithUcs2Fallback(outputSize uint32) *Sizer {
+	szr.ucs2Size = outputSize
+	return szr
+}
+
+// WithWordWrap is a chainable function that enables breaking of sink items that are too large to fit on a page of their own.
+//
+// Items are broken at the last word boundary that fits, or at the last character that fits if the word itself is too large. The marker is appended to every part except the last, e.g. "..." or " >".
+//
+// Without word wrap, rendering fails if a single sink item exceeds the page capacity.
+func (szr *Sizer) WithWordWrap(marker string) *Sizer {
+	szr.wrap = true
+	szr.wrapMarker = marker
+	return szr
+}
+
+// WithRecords is a chainable function that groups consecutive sink items into records, delimited by items equal to the separator, e.g. an empty line.
+//
+// A record is kept on the same page if it fits on a page of its own. Otherwise its items are paginated individually. The separator items are not rendered.
+func (szr *Sizer) WithRecords(sep string) *Sizer {
+	szr.records = true
+	szr.recordSep = sep
+	return szr
+}
+
+// switch to ucs2 measurement if fallback is defined and any of the strings cannot be encoded in gsm7.
+func (szr *Sizer) detect(s ...string) {
+	if szr.ucs2Size == 0 || szr.ucs2 {
+		return
+	}
+	for _, v := range s {
+		if !IsGsm7(v) {
+			logg.Debugf("content not gsm7, using ucs2 size", "size", szr.ucs2Size)
+			szr.ucs2 = true
+			return
+		}
 	}
 }
 
+// active output size constraint.
+func (szr *Sizer) limit() uint32 {
+	if szr.ucs2 {
+		return szr.ucs2Size
+	}
+	return szr.outputSize
+}
+
+// Len returns the size of the string in the unit of the currently active output size constraint.
+func (szr *Sizer) Len(s string) uint32 {
+	if szr.ucs2 {
+		return Ucs2Size(s)
+	}
+	return szr.sizeFunc(s)
+}
+
 // WithMenuSize sets the size of the menu being used in the rendering context.
 //func(szr *Sizer) WithMenuSize(menuSize uint16) *Sizer {
 //	szr.menuSize = menuSize
@@ -41,15 +123,19 @@ func (szr *Sizer) Set(key string, size uint16) error {
 }
 
 // Check audits whether the rendered string is within the output size constraint of the sizer.
+//
+// If within the constraint, the remaining size is returned, measured with the SizeFunc of the sizer.
 func (szr *Sizer) Check(s string) (uint32, bool) {
-	l := uint32(len(s))
-	if szr.outputSize > 0 {
-		if l > szr.outputSize {
+	szr.detect(s)
+	l := szr.Len(s)
+	outputSize := szr.limit()
+	if outputSize > 0 {
+		if l > outputSize {
 			logg.Infof("sized check fails", "length", l, "sizer", szr)
 			logg.Tracef("", "sizer contents", s)
 			return 0, false
 		}
-		l = szr.outputSize - l
+		l = outputSize - l
 	}
 	return l, true
 }
@@ -63,7 +149,7 @@ func (szr *Sizer) String() string {
 	//		diff = szr.outputSize - szr.totalMemberSize - uint32(szr.menuSize)
 	//	}
 	//	return fmt.Sprintf("output: %v, member: %v, menu: %v, diff: %v", szr.outputSize, szr.totalMemberSize, szr.menuSize, diff)
-	return fmt.Sprintf("output: %v, member: %v", szr.outputSize, szr.totalMemberSize)
+	return fmt.Sprintf("output: %v, member: %v", szr.limit(), szr.totalMemberSize)
 }
 
 // Size gives the byte size of content for a single symbol.
@@ -119,4 +205,5 @@ func (szr *Sizer) GetAt(values map[string]string, idx uint16) (map[string]string
 // Reset flushes all size measurements, making the sizer available for reuse.
 func (szr *Sizer) Reset() {
 	szr.crsrs = []uint32{}
+	szr.ucs2 = false
 }
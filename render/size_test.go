@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"strings"
 	"testing"
 
 	"github.com/grassrootseconomics/go-vise/cache"
@@ -399,3 +400,214 @@ func TestMiddlePage(t *testing.T) {
 	}
 	fmt.Printf("%s\n", r)
 }
+
+func TestSizeFuncs(t *testing.T) {
+	s := "Habari {yako}"
+	if ByteSize(s) != 13 || RuneSize(s) != 13 {
+		t.Fatalf("unexpected byte/rune size %d/%d", ByteSize(s), RuneSize(s))
+	}
+	if Gsm7Size(s) != 15 {
+		t.Fatalf("expected 15 septets, got %d", Gsm7Size(s))
+	}
+	if !IsGsm7(s) {
+		t.Fatalf("expected '%s' to be gsm7", s)
+	}
+	s = "ሰላም 😀"
+	if IsGsm7(s) {
+		t.Fatalf("expected '%s' not to be gsm7", s)
+	}
+	if RuneSize(s) != 5 {
+		t.Fatalf("expected 5 runes, got %d", RuneSize(s))
+	}
+	if Ucs2Size(s) != 6 {
+		t.Fatalf("expected 6 code units, got %d", Ucs2Size(s))
+	}
+}
+
+func TestSizeUcs2Fallback(t *testing.T) {
+	szr := NewSizer(16).WithSizeFunc(Gsm7Size).WithUcs2Fallback(7)
+	l, ok := szr.Check("äöü€")
+	if !ok {
+		t.Fatalf("expected ok")
+	}
+	if l != 11 {
+		t.Fatalf("expected 11, got %v", l)
+	}
+	l, ok = szr.Check("ሰላም")
+	if !ok {
+		t.Fatalf("expected ok")
+	}
+	if l != 4 {
+		t.Fatalf("expected 4, got %v", l)
+	}
+	_, ok = szr.Check("äöü€äöü€")
+	if ok {
+		t.Fatalf("expected ucs2 size to be kept until reset")
+	}
+	szr.Reset()
+	_, ok = szr.Check("äöü€äöü€")
+	if !ok {
+		t.Fatalf("expected ok after reset")
+	}
+}
+
+func TestSizePagesUcs2(t *testing.T) {
+	ctx := context.Background()
+	ca := cache.NewCache()
+	rs := resourcetest.NewTestResource()
+	rs.AddTemplate(ctx, "foo", "{{.bar}}")
+	rs.Lock()
+	szr := NewSizer(160).WithSizeFunc(Gsm7Size).WithUcs2Fallback(20)
+	mn := NewMenu().WithBrowseConfig(DefaultBrowseConfig())
+	pg := NewPage(ca, rs).WithSizer(szr).WithMenu(mn)
+	ca.Push()
+	ca.Add("bar", "ሰላም\nእንደምን\nአደርክ\nደህና", 0)
+	pg.Map("bar")
+
+	r, err := pg.Render(ctx, "foo", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `ሰላም
+እንደምን
+11:next`
+	if r != expect {
+		t.Fatalf("expected:\n\t%s\ngot:\n\t%s\n", expect, r)
+	}
+	if Ucs2Size(r) > 20 {
+		t.Fatalf("page size %d exceeds 20", Ucs2Size(r))
+	}
+}
+
+func renderSinkPages(t *testing.T, szr func() *Sizer, content string, size int) []string {
+	var pages []string
+	ctx := context.Background()
+	rs := resourcetest.NewTestResource()
+	rs.AddTemplate(ctx, "foo", "{{.bar}}")
+	rs.Lock()
+	for i := uint16(0); ; i++ {
+		ca := cache.NewCache()
+		mn := NewMenu().WithBrowseConfig(DefaultBrowseConfig())
+		pg := NewPage(ca, rs).WithSizer(szr()).WithMenu(mn)
+		ca.Push()
+		ca.Add("bar", content, 0)
+		pg.Map("bar")
+		r, err := pg.Render(ctx, "foo", i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(r) > size {
+			t.Fatalf("page %d size %d exceeds %d: %s", i, len(r), size, r)
+		}
+		pages = append(pages, r)
+		if !strings.Contains(r, "11:next") {
+			break
+		}
+	}
+	return pages
+}
+
+func TestSizeWordWrap(t *testing.T) {
+	content := "inky\nthe quick brown fox jumps over the lazy dog\npinky"
+	ctx := context.Background()
+	rs := resourcetest.NewTestResource()
+	rs.AddTemplate(ctx, "foo", "{{.bar}}")
+	rs.Lock()
+	ca := cache.NewCache()
+	pg := NewPage(ca, rs).WithSizer(NewSizer(38)).WithMenu(NewMenu().WithBrowseConfig(DefaultBrowseConfig()))
+	ca.Push()
+	ca.Add("bar", "the quick brown fox jumps over the lazy dog", 0)
+	pg.Map("bar")
+	_, err := pg.Render(ctx, "foo", 0)
+	if err == nil {
+		t.Fatalf("expected error without word wrap")
+	}
+
+	pages := renderSinkPages(t, func() *Sizer {
+		return NewSizer(38).WithWordWrap("..")
+	}, content, 38)
+	expect := []string{
+		"inky\n11:next",
+		"the quick..\n11:next\n22:previous",
+		"brown fox..\n11:next\n22:previous",
+		"jumps over the..\n11:next\n22:previous",
+		"lazy dog\npinky\n22:previous",
+	}
+	if len(pages) != len(expect) {
+		t.Fatalf("expected %d pages, got %d: %q", len(expect), len(pages), pages)
+	}
+	for i, v := range expect {
+		if pages[i] != v {
+			t.Fatalf("page %d expected:\n\t%q\ngot:\n\t%q\n", i, v, pages[i])
+		}
+	}
+}
+
+func TestSizeRecords(t *testing.T) {
+	pages := renderSinkPages(t, func() *Sizer {
+		return NewSizer(38).WithRecords("")
+	}, "1. inky\nfoo 42\n\n2. pinky\nbar 13\n\n3. blinky\nbaz 7", 38)
+	expect := []string{
+		"1. inky\nfoo 42\n11:next",
+		"2. pinky\nbar 13\n11:next\n22:previous",
+		"3. blinky\nbaz 7\n22:previous",
+	}
+	if len(pages) != len(expect) {
+		t.Fatalf("expected %d pages, got %d: %q", len(expect), len(pages), pages)
+	}
+	for i, v := range expect {
+		if pages[i] != v {
+			t.Fatalf("page %d expected:\n\t%q\ngot:\n\t%q\n", i, v, pages[i])
+		}
+	}
+}
+
+func TestSizePageIndicator(t *testing.T) {
+	ctx := context.Background()
+	rs := resourcetest.NewTestResource()
+	rs.AddTemplate(ctx, "foo", "{{.bar}}")
+	rs.AddTemplate(ctx, "baz", "{{._page}} {{.bar}}")
+	rs.Lock()
+	var items []string
+	for i := 0; i < 12; i++ {
+		items = append(items, fmt.Sprintf("item %d", i))
+	}
+	content := strings.Join(items, "\n")
+
+	for _, pos := range []PageIndicatorPosition{PAGE_INDICATOR_BEFORE_MENU, PAGE_INDICATOR_AFTER_MENU, PAGE_INDICATOR_TEMPLATE} {
+		sym := "foo"
+		if pos == PAGE_INDICATOR_TEMPLATE {
+			sym = "baz"
+		}
+		var count int
+		for i := uint16(0); ; i++ {
+			cfg := DefaultBrowseConfig()
+			cfg.PageIndicator = "(%d/%d)"
+			cfg.PageIndicatorPosition = pos
+			ca := cache.NewCache()
+			mn := NewMenu().WithBrowseConfig(cfg)
+			pg := NewPage(ca, rs).WithSizer(NewSizer(40)).WithMenu(mn)
+			ca.Push()
+			ca.Add("bar", content, 0)
+			pg.Map("bar")
+			r, err := pg.Render(ctx, sym, i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(r) > 40 {
+				t.Fatalf("page %d size %d exceeds 40: %s", i, len(r), r)
+			}
+			count = int(mn.pageCount)
+			ind := fmt.Sprintf("(%d/%d)", i+1, count)
+			if !strings.Contains(r, ind) {
+				t.Fatalf("expected indicator %s in page %d: %s", ind, i, r)
+			}
+			if int(i) == count-1 {
+				break
+			}
+		}
+		if count < 10 {
+			t.Fatalf("expected two-digit page count, got %d", count)
+		}
+	}
+}
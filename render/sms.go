@@ -0,0 +1,140 @@
+package render
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const (
+	// SMS_SIZE is the size of a single SMS message in GSM 03.38 septets.
+	SMS_SIZE = 160
+	// SMS_SIZE_UCS2 is the size of a single SMS message in UTF-16 code units.
+	SMS_SIZE_UCS2 = 70
+	// concatenation header size in septets, giving 153 septet segments.
+	smsHeaderSize = 7
+	// concatenation header size in UTF-16 code units, giving 67 code unit segments.
+	smsHeaderSizeUcs2 = 3
+	// default format of a reply keyword footer line, receiving selector and title.
+	defaultSmsReply = "Reply %s for %s"
+)
+
+// NewSmsSizer creates a Sizer measuring output as a single SMS message, with fallback to UCS2 for content outside the GSM 03.38 alphabet.
+func NewSmsSizer() *Sizer {
+	return NewSizer(SMS_SIZE).WithSizeFunc(Gsm7Size).WithUcs2Fallback(SMS_SIZE_UCS2).WithSegmentHeader(smsHeaderSize, smsHeaderSizeUcs2)
+}
+
+// WithSegmentHeader is a chainable function that sets the size reserved for a header in each part when Segments splits content, e.g. the SMS concatenation header.
+//
+// The second argument is the size for content measured against the UCS2 fallback constraint.
+func (szr *Sizer) WithSegmentHeader(size uint32, ucs2Size uint32) *Sizer {
+	szr.segmentHeader = [2]uint32{size, ucs2Size}
+	return szr
+}
+
+// Segments splits the string into concatenated SMS message segments.
+//
+// If the string fits within the output size constraint, it is returned as a single segment. Otherwise it is split at word boundaries into segments that leave room for the header set with WithSegmentHeader, i.e. 153 septets or 67 UTF-16 code units for the SMS constraints of NewSmsSizer.
+//
+// If the string cannot be encoded in GSM 03.38, it is measured against the UCS2 fallback constraint, if defined. The encoding is decided by the string alone, and the state of the Sizer is not changed.
+func (szr *Sizer) Segments(s string) ([]string, error) {
+	size := szr.sizeFunc
+	limit := szr.outputSize
+	header := szr.segmentHeader[0]
+	if szr.ucs2Size > 0 && !IsGsm7(s) {
+		size = Ucs2Size
+		limit = szr.ucs2Size
+		header = szr.segmentHeader[1]
+	}
+	if limit == 0 || size(s) <= limit {
+		return []string{s}, nil
+	}
+	if limit <= header {
+		return nil, fmt.Errorf("output size %v too small for concatenated segments", limit)
+	}
+	limit -= header
+
+	var r []string
+	for size(s) > limit {
+		head, tail, err := breakWords(s, limit, "", size)
+		if err != nil {
+			return nil, err
+		}
+		// leading whitespace is broken off without content.
+		if head != "" {
+			r = append(r, head)
+		}
+		s = tail
+	}
+	if len(s) > 0 {
+		r = append(r, s)
+	}
+	logg.Debugf("split sms segments", "count", len(r))
+	return r, nil
+}
+
+// WithSmsReply is a chainable function that sets the format of the reply keyword footer lines of RenderSms, receiving the menu selector and title, e.g. "%s: %s".
+//
+// The format is translated like a menu title.
+func (pg *Page) WithSmsReply(format string) *Page {
+	pg.smsReply = format
+	return pg
+}
+
+// RenderSms renders the current mapped content against the template associated with the symbol, as an ordered list of SMS message segments.
+//
+// The content is not paginated, and the menu is replaced by a footer with one reply keyword line for each menu entry. Browse entries are not included. The output format of the page is not applied.
+//
+// Segments are split with the Sizer of the page, or with NewSmsSizer if the page has none.
+func (pg *Page) RenderSms(ctx context.Context, sym string) ([]string, error) {
+	values := make(map[string]string)
+	for k, v := range pg.cacheMap {
+		values[k] = v
+	}
+	values[PageIndicatorSymbol] = ""
+	if pg.menu != nil && pg.menu.IsSink() {
+		values["_menu"] = ""
+	}
+	s, err := pg.execTemplate(ctx, sym, values)
+	if err != nil {
+		return nil, err
+	}
+	if pg.menu != nil {
+		format := pg.smsReply
+		if format == "" {
+			format = defaultSmsReply
+		}
+		footer, err := pg.menu.replies(ctx, format)
+		if err != nil {
+			return nil, err
+		}
+		if len(footer) > 0 {
+			s = strings.TrimRight(s, "\n") + "\n" + strings.Join(footer, "\n")
+		}
+	}
+	szr := pg.sizer
+	if szr == nil {
+		szr = NewSmsSizer()
+	}
+	return szr.Segments(s)
+}
+
+// reply keyword lines for all menu entries with a selector.
+func (m *Menu) replies(ctx context.Context, format string) ([]string, error) {
+	var r []string
+	format, err := m.titleFor(ctx, format)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range m.menu {
+		if v[0] == "" {
+			continue
+		}
+		title, err := m.titleFor(ctx, v[1])
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, fmt.Sprintf(format, v[0], title))
+	}
+	return r, nil
+}
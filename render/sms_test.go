@@ -0,0 +1,136 @@
+package render
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/grassrootseconomics/go-vise/cache"
+	"github.com/grassrootseconomics/go-vise/internal/resourcetest"
+)
+
+func TestSmsSegments(t *testing.T) {
+	szr := NewSmsSizer()
+	r, err := szr.Segments("inky pinky")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r) != 1 || r[0] != "inky pinky" {
+		t.Fatalf("unexpected segments: %v", r)
+	}
+
+	s := strings.TrimSpace(strings.Repeat("blinky clyde ", 20))
+	r, err = szr.Segments(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r) != 2 {
+		t.Fatalf("expected 2 segments, got %d: %v", len(r), r)
+	}
+	for i, v := range r {
+		if Gsm7Size(v) > SMS_SIZE-smsHeaderSize {
+			t.Fatalf("segment %d size %d exceeds %d", i, Gsm7Size(v), SMS_SIZE-smsHeaderSize)
+		}
+	}
+	if strings.Join(r, " ") != s {
+		t.Fatalf("segments do not add up to content: %v", r)
+	}
+
+	// content outside gsm7 uses ucs2 segment size
+	s = strings.TrimSpace(strings.Repeat("ĉapelo ", 12))
+	r, err = szr.Segments(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r) != 2 {
+		t.Fatalf("expected 2 segments, got %d: %v", len(r), r)
+	}
+	for i, v := range r {
+		if Ucs2Size(v) > SMS_SIZE_UCS2-smsHeaderSizeUcs2 {
+			t.Fatalf("segment %d size %d exceeds %d", i, Ucs2Size(v), SMS_SIZE_UCS2-smsHeaderSizeUcs2)
+		}
+	}
+}
+
+func TestSmsSegmentsMixed(t *testing.T) {
+	// a single character outside gsm7 makes all content ucs2.
+	s := strings.Repeat("inky ", 15) + "ĉapelo"
+	szr := NewSmsSizer()
+	r, err := szr.Segments(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r) != 2 {
+		t.Fatalf("expected 2 segments, got %d: %v", len(r), r)
+	}
+
+	// a reused sizer decides the encoding for each string.
+	s = strings.TrimSpace(strings.Repeat("inky ", 40))
+	_, ok := szr.Check("ĉapelo")
+	if !ok {
+		t.Fatal("expected content to fit")
+	}
+	r, err = szr.Segments(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r) != 2 {
+		t.Fatalf("expected 2 segments, got %d: %v", len(r), r)
+	}
+	for i, v := range r {
+		if Gsm7Size(v) > SMS_SIZE-smsHeaderSize {
+			t.Fatalf("segment %d size %d exceeds %d", i, Gsm7Size(v), SMS_SIZE-smsHeaderSize)
+		}
+	}
+}
+
+func TestSmsSegmentsSizer(t *testing.T) {
+	// no header is reserved unless set.
+	szr := NewSizer(10)
+	r, err := szr.Segments("inky pinky blinky")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r) != 2 || r[0] != "inky pinky" || r[1] != "blinky" {
+		t.Fatalf("unexpected segments: %v", r)
+	}
+
+	// leading whitespace does not make an empty segment.
+	r, err = szr.WithSegmentHeader(2, 2).Segments("  inkypinkyblinky")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r) != 2 || r[0] != "inkypink" || r[1] != "yblinky" {
+		t.Fatalf("unexpected segments: %q", r)
+	}
+}
+
+func TestPageRenderSms(t *testing.T) {
+	ctx := context.Background()
+	rs := resourcetest.NewTestResource()
+	rs.AddTemplate(ctx, "foo", "{{.bar}}")
+	rs.AddMenu(ctx, "Reply %s for %s_menu", "Send %s to %s")
+	rs.Lock()
+	ca := cache.NewCache()
+	ca.Push()
+	content := strings.TrimSpace(strings.Repeat("inky pinky\n", 20))
+	ca.Add("bar", content, 0)
+	mn := NewMenu().WithBrowseConfig(DefaultBrowseConfig())
+	mn.Put("1", "buy")
+	mn.Put("2", "sell")
+	pg := NewPage(ca, rs).WithMenu(mn).WithSizer(NewSmsSizer())
+	pg.Map("bar")
+	r, err := pg.RenderSms(ctx, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r) != 2 {
+		t.Fatalf("expected 2 segments, got %d: %v", len(r), r)
+	}
+	if !strings.HasSuffix(r[1], "inky pinky\nSend 1 to buy\nSend 2 to sell") {
+		t.Fatalf("unexpected last segment: %s", r[1])
+	}
+	if strings.Contains(r[0]+r[1], "11") {
+		t.Fatalf("browse entry in output: %v", r)
+	}
+}
@@ -3,8 +3,83 @@ package render
 import (
 	"fmt"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
+// group sink values into records delimited by values equal to the separator.
+//
+// if grouping is not enabled, every value is a record of its own. empty records are omitted.
+func records(values []string, group bool, sep string) [][]string {
+	var r [][]string
+	var rec []string
+	for _, v := range values {
+		if !group {
+			r = append(r, []string{v})
+			continue
+		}
+		if v == sep {
+			if len(rec) > 0 {
+				r = append(r, rec)
+			}
+			rec = nil
+			continue
+		}
+		rec = append(rec, v)
+	}
+	if len(rec) > 0 {
+		r = append(r, rec)
+	}
+	return r
+}
+
+// size of record values rendered on the same page, including the separating newlines.
+func recordSize(rec []string, size SizeFunc) uint32 {
+	var l uint32
+	for i, v := range rec {
+		if i > 0 {
+			l += 1
+		}
+		l += size(v)
+	}
+	return l
+}
+
+// break string at the last word boundary where the head with the continuation marker appended fits within the capacity.
+//
+// if no word boundary fits, the string is broken at the last character that fits.
+//
+// returns the head including the marker, and the remainder with leading whitespace removed.
+func breakWords(s string, capacity uint32, marker string, size SizeFunc) (string, string, error) {
+	var fit int
+	var word int
+	for i, c := range s {
+		end := i + utf8.RuneLen(c)
+		if size(s[:end]+marker) > capacity {
+			break
+		}
+		if unicode.IsSpace(c) && i > 0 {
+			word = i
+		}
+		fit = end
+	}
+	if fit == 0 {
+		return "", "", fmt.Errorf("capacity %v too small for continuation", capacity)
+	}
+	if fit < len(s) {
+		c, _ := utf8.DecodeRuneInString(s[fit:])
+		if unicode.IsSpace(c) {
+			word = fit
+		}
+	}
+	if word > 0 {
+		fit = word
+	}
+	head := strings.TrimRightFunc(s[:fit], unicode.IsSpace)
+	tail := strings.TrimLeftFunc(s[fit:], unicode.IsSpace)
+	return head + marker, tail, nil
+}
+
 func bookmark(values []string) []uint32 {
 	var c int
 	var bookmarks []uint32 = []uint32{0}
@@ -107,3 +107,55 @@ func TestSplitPaginate(t *testing.T) {
 //		t.Fatalf("expected:\n\t%s\ngot:\n\t%x\n", expectBytes, s)
 //	}
 //}
+
+func TestSplitRecords(t *testing.T) {
+	vals := []string{"inky", "pinky", "--", "blinky", "--", "--", "clyde"}
+	r := records(vals, true, "--")
+	if len(r) != 3 {
+		t.Fatalf("expected 3 records, got %v", len(r))
+	}
+	if len(r[0]) != 2 || r[0][1] != "pinky" {
+		t.Fatalf("unexpected first record: %v", r[0])
+	}
+	if recordSize(r[0], ByteSize) != 10 {
+		t.Fatalf("expected record size 10, got %v", recordSize(r[0], ByteSize))
+	}
+	r = records(vals, false, "")
+	if len(r) != len(vals) {
+		t.Fatalf("expected %v records, got %v", len(vals), len(r))
+	}
+}
+
+func TestSplitBreakWords(t *testing.T) {
+	head, tail, err := breakWords("inky pinky blinky clyde", 16, ">", ByteSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head != "inky pinky>" {
+		t.Fatalf("expected 'inky pinky>', got '%s'", head)
+	}
+	if tail != "blinky clyde" {
+		t.Fatalf("expected 'blinky clyde', got '%s'", tail)
+	}
+
+	head, tail, err = breakWords("inky pinky blinky", 10, ">", ByteSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head != "inky>" || tail != "pinky blinky" {
+		t.Fatalf("unexpected break '%s' '%s'", head, tail)
+	}
+
+	head, tail, err = breakWords("inkypinkyblinky", 8, "..", ByteSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head != "inkypi.." || tail != "nkyblinky" {
+		t.Fatalf("unexpected hard break '%s' '%s'", head, tail)
+	}
+
+	_, _, err = breakWords("inky", 2, "...", ByteSize)
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+}
@@ -0,0 +1,78 @@
+package render
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// SsmlRenderer formats pages as SSML documents for voice channels.
+//
+// Every line of content and every menu entry is a sentence. Selectors are spelled out character by character, followed by a pause before the title.
+type SsmlRenderer struct {
+	pause string
+}
+
+// NewSsmlRenderer creates a new SsmlRenderer.
+//
+// By default, the pause between selector and title is 300ms.
+func NewSsmlRenderer() *SsmlRenderer {
+	return &SsmlRenderer{
+		pause: "300ms",
+	}
+}
+
+// WithPause is a chainable function that sets the duration of the pause between selector and title, e.g. "500ms".
+func (rdr *SsmlRenderer) WithPause(pause string) *SsmlRenderer {
+	rdr.pause = pause
+	return rdr
+}
+
+// Content implements the Renderer interface.
+//
+// Empty lines are omitted.
+func (rdr *SsmlRenderer) Content(s string) string {
+	var lines []string
+	for _, v := range strings.Split(s, "\n") {
+		if strings.TrimSpace(v) == "" {
+			continue
+		}
+		lines = append(lines, "<s>"+html.EscapeString(v)+"</s>")
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return "<p>" + strings.Join(lines, "\n") + "</p>"
+}
+
+// Menu implements the Renderer interface.
+func (rdr *SsmlRenderer) Menu(entries [][2]string, sep string) string {
+	if len(entries) == 0 {
+		return ""
+	}
+	var lines []string
+	for _, v := range entries {
+		title := html.EscapeString(v[1])
+		if v[0] == "" {
+			lines = append(lines, "<s>"+title+"</s>")
+			continue
+		}
+		lines = append(lines, fmt.Sprintf(`<s><say-as interpret-as="characters">%s</say-as><break time="%s"/>%s</s>`, html.EscapeString(v[0]), rdr.pause, title))
+	}
+	return "<p>" + strings.Join(lines, "\n") + "</p>"
+}
+
+// Join implements the Renderer interface.
+func (rdr *SsmlRenderer) Join(content string, menu string) string {
+	if content != "" && menu != "" {
+		menu = "\n" + menu
+	}
+	return "<speak>" + content + menu + "</speak>"
+}
+
+// Text implements the Renderer interface.
+//
+// Only the spoken text is counted towards the size constraint.
+func (rdr *SsmlRenderer) Text(s string) string {
+	return html.UnescapeString(tagRegex.ReplaceAllString(s, ""))
+}
@@ -0,0 +1,116 @@
+package render
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/grassrootseconomics/go-vise/lang"
+)
+
+type cachedTemplate struct {
+	src string
+	tpl *template.Template
+}
+
+// TemplateCache keeps parsed templates for reuse across renders and sessions.
+//
+// Templates are cached per symbol, language and set of template function names. A cached template is only used if its source is identical to the template retrieved from the resource, so a changed template is never rendered stale.
+//
+// Each render executes a copy of the cached template, as the template functions are bound to the language and size constraints of the render. The copy is cheaper than parsing, but the gain depends on the size of the templates, so caching is only enabled for pages given a TemplateCache.
+//
+// It is safe for concurrent use.
+type TemplateCache struct {
+	mu      sync.RWMutex
+	version uint64
+	tpls    map[string]cachedTemplate
+}
+
+// NewTemplateCache creates a new, empty TemplateCache.
+func NewTemplateCache() *TemplateCache {
+	return &TemplateCache{
+		tpls: make(map[string]cachedTemplate),
+	}
+}
+
+// Invalidate removes all parsed templates from the cache.
+//
+// It should be called when resources are reloaded, to release templates that will not be used again.
+func (tc *TemplateCache) Invalidate() {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.tpls = make(map[string]cachedTemplate)
+	tc.version += 1
+	logg.Debugf("template cache invalidated", "version", tc.version)
+}
+
+// Version returns the number of times the cache has been invalidated.
+func (tc *TemplateCache) Version() uint64 {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+	return tc.version
+}
+
+// Len returns the number of parsed templates in the cache.
+func (tc *TemplateCache) Len() int {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+	return len(tc.tpls)
+}
+
+// get returns a copy of the parsed template for the key, parsing and storing it if it is missing or its source has changed.
+//
+// The copy may be given template functions of its own without affecting other users of the cached template.
+func (tc *TemplateCache) get(key string, src string, fm template.FuncMap) (*template.Template, error) {
+	tc.mu.RLock()
+	v, ok := tc.tpls[key]
+	version := tc.version
+	tc.mu.RUnlock()
+	if !ok || v.src != src {
+		tp, err := parseTemplate(src, fm)
+		if err != nil {
+			return nil, err
+		}
+		v = cachedTemplate{
+			src: src,
+			tpl: tp,
+		}
+		tc.mu.Lock()
+		if tc.version == version {
+			tc.tpls[key] = v
+		}
+		tc.mu.Unlock()
+		logg.Tracef("template cache miss", "key", key)
+	}
+	tp, err := v.tpl.Clone()
+	if err != nil {
+		return nil, err
+	}
+	return tp.Funcs(fm), nil
+}
+
+func parseTemplate(src string, fm template.FuncMap) (*template.Template, error) {
+	return template.New("tester").Funcs(fm).Option("missingkey=error").Parse(src)
+}
+
+// cache key for the template of the symbol in the language of the context.
+func templateKey(ctx context.Context, funcKey string, sym string) string {
+	var code string
+	ln, ok := lang.LanguageFromContext(ctx)
+	if ok {
+		code = ln.Code
+	}
+	return funcKey + "\x00" + code + "\x00" + sym
+}
+
+// identifies the set of template function names, which are resolved when a template is parsed.
+func funcKey(fm template.FuncMap) string {
+	var names []string
+	for k := range fm {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,176 @@
+package render
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/grassrootseconomics/go-vise/cache"
+	"github.com/grassrootseconomics/go-vise/internal/resourcetest"
+	"github.com/grassrootseconomics/go-vise/lang"
+)
+
+func TestTemplateCache(t *testing.T) {
+	ctx := context.Background()
+	rs := resourcetest.NewTestResource()
+	rs.AddTemplate(ctx, "foo", "inky {{.bar}}")
+	rs.Lock()
+	tc := NewTemplateCache()
+	ca := cache.NewCache()
+	ca.Push()
+	ca.Add("bar", "pinky", 0)
+
+	for i := 0; i < 2; i++ {
+		pg := NewPage(ca, rs).WithTemplateCache(tc)
+		pg.Map("bar")
+		r, err := pg.Render(ctx, "foo", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r != "inky pinky" {
+			t.Fatalf("unexpected output: %s", r)
+		}
+	}
+	if tc.Len() != 1 {
+		t.Fatalf("expected 1 cached template, got %d", tc.Len())
+	}
+
+	// changed template source is never rendered stale.
+	rs = resourcetest.NewTestResource()
+	rs.AddTemplate(ctx, "foo", "blinky {{.bar}}")
+	rs.Lock()
+	pg := NewPage(ca, rs).WithTemplateCache(tc)
+	pg.Map("bar")
+	r, err := pg.Render(ctx, "foo", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r != "blinky pinky" {
+		t.Fatalf("unexpected output: %s", r)
+	}
+
+	ln, err := lang.LanguageFromCode("nor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx = context.WithValue(ctx, "Language", ln)
+	pg = NewPage(ca, rs).WithTemplateCache(tc)
+	pg.Map("bar")
+	_, err = pg.Render(ctx, "foo", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tc.Len() != 2 {
+		t.Fatalf("expected 2 cached templates, got %d", tc.Len())
+	}
+
+	tc.Invalidate()
+	if tc.Len() != 0 {
+		t.Fatalf("expected empty cache, got %d", tc.Len())
+	}
+	if tc.Version() != 1 {
+		t.Fatalf("expected version 1, got %d", tc.Version())
+	}
+}
+
+func TestTemplateCacheFuncs(t *testing.T) {
+	ctx := context.Background()
+	rs := resourcetest.NewTestResource()
+	rs.AddTemplate(ctx, "foo", "{{num 2 .bar}}")
+	rs.Lock()
+	tc := NewTemplateCache()
+	ca := cache.NewCache()
+	ca.Push()
+	ca.Add("bar", "1234.5", 0)
+
+	pg := NewPage(ca, rs).WithTemplateCache(tc)
+	pg.Map("bar")
+	r, err := pg.Render(ctx, "foo", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r != "1,234.50" {
+		t.Fatalf("unexpected output: %s", r)
+	}
+
+	// template functions follow the locale of the render, not of the parse.
+	ln, err := lang.LanguageFromCode("nor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pg = NewPage(ca, rs).WithTemplateCache(tc)
+	pg.Map("bar")
+	r, err = pg.Render(context.WithValue(ctx, "Language", ln), "foo", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r != "1 234,50" {
+		t.Fatalf("unexpected output: %s", r)
+	}
+}
+
+func TestTemplateCacheConcurrent(t *testing.T) {
+	ctx := context.Background()
+	tc := NewTemplateCache()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rs := resourcetest.NewTestResource()
+			rs.AddTemplate(ctx, "foo", "inky {{.bar}}")
+			rs.Lock()
+			for j := 0; j < 100; j++ {
+				ca := cache.NewCache()
+				ca.Push()
+				ca.Add("bar", "pinky", 0)
+				pg := NewPage(ca, rs).WithTemplateCache(tc)
+				pg.Map("bar")
+				r, err := pg.Render(ctx, "foo", 0)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if r != "inky pinky" {
+					t.Errorf("unexpected output: %s", r)
+					return
+				}
+				if j == 50 {
+					tc.Invalidate()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func benchmarkRender(b *testing.B, tc *TemplateCache) {
+	ctx := context.Background()
+	rs := resourcetest.NewTestResource()
+	rs.AddTemplate(ctx, "foo", "Balance: {{money \"KES\" 2 .bal}}\n{{trunc 20 .name}} {{plural \"item\" \"items\" .count}}\n{{pad 10 .name}}|")
+	rs.Lock()
+	ca := cache.NewCache()
+	ca.Push()
+	ca.Add("bal", "1234.5", 32)
+	ca.Add("name", "Inky Pinky Blinky Clyde", 32)
+	ca.Add("count", "3", 32)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pg := NewPage(ca, rs).WithTemplateCache(tc)
+		pg.Map("bal")
+		pg.Map("name")
+		pg.Map("count")
+		_, err := pg.Render(ctx, "foo", 0)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRenderCached(b *testing.B) {
+	benchmarkRender(b, NewTemplateCache())
+}
+
+func BenchmarkRenderUncached(b *testing.B) {
+	benchmarkRender(b, nil)
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 
 	"github.com/grassrootseconomics/go-vise/db"
+	"github.com/grassrootseconomics/go-vise/lang"
 )
 
 const (
@@ -18,8 +19,10 @@ const (
 // The DbResource can resolve any db.DATATYPE_* if instructed to do so.
 type DbResource struct {
 	*MenuResource
-	typs uint8
-	db   db.Db
+	typs            uint8
+	db              db.Db
+	fallbacks       *lang.Fallbacks
+	defaultLanguage *lang.Language
 }
 
 // NewDbResource instantiates a new DbResource
@@ -59,6 +62,20 @@ func (g *DbResource) WithOnly(typ uint8) *DbResource {
 	return g
 }
 
+// WithFallbacks is a chainable function that sets the fallback chains to follow when no translation exists for the language.
+func (g *DbResource) WithFallbacks(fallbacks *lang.Fallbacks) *DbResource {
+	g.fallbacks = fallbacks
+	return g
+}
+
+// WithDefaultLanguage is a chainable function that sets the language of the untranslated content.
+//
+// A fallback chain ends with the untranslated content at the default language, and the default language is never reported as missing.
+func (g *DbResource) WithDefaultLanguage(ln lang.Language) *DbResource {
+	g.defaultLanguage = &ln
+	return g
+}
+
 func (g *DbResource) mustSafe() {
 	if !g.db.Safe() {
 		panic("db unsafe for resource (db.Db.Safe() == false)")
@@ -71,9 +88,46 @@ func (g *DbResource) fn(ctx context.Context, sym string) ([]byte, error) {
 	return g.db.Get(ctx, []byte(sym))
 }
 
-// retrieve from underlying db using a string key.
+// retrieve only the translation of the language from underlying db, without falling back to the untranslated default.
+//
+// the translation is stored under the key with the language code suffix, as generated by db.ToDbKey.
+func (g *DbResource) lfn(ctx context.Context, sym string, ln lang.Language) ([]byte, error) {
+	return g.fn(context.WithValue(ctx, "Language", nil), sym+"_"+ln.Code)
+}
+
+// retrieve translated content from underlying db, following the fallback chain of the language in the context.
+func (g *DbResource) tfn(ctx context.Context, sym string) ([]byte, error) {
+	ln, ok := lang.LanguageFromContext(ctx)
+	if !ok {
+		return g.fn(ctx, sym)
+	}
+	chain := g.fallbacks.Fallback(ln)
+	if len(chain) == 0 {
+		return g.fn(ctx, sym)
+	}
+	for i, l := range append([]lang.Language{ln}, chain...) {
+		if g.defaultLanguage != nil && l.Code == g.defaultLanguage.Code {
+			break
+		}
+		v, err := g.lfn(ctx, sym, l)
+		if err == nil {
+			if i > 0 {
+				logg.TraceCtxf(ctx, "using fallback translation", "sym", sym, "lang", l.Code)
+			}
+			return v, nil
+		}
+		if !db.IsNotFound(err) {
+			return nil, err
+		}
+		logg.DebugCtxf(ctx, "translation missing", "sym", sym, "lang", l.Code)
+		g.fallbacks.ReportMissing(ctx, l.Code, sym)
+	}
+	return g.fn(context.WithValue(ctx, "Language", nil), sym)
+}
+
+// retrieve translated content from underlying db using a string key.
 func (g *DbResource) sfn(ctx context.Context, sym string) (string, error) {
-	b, err := g.fn(ctx, sym)
+	b, err := g.tfn(ctx, sym)
 	if err != nil {
 		return "", err
 	}
@@ -122,6 +176,27 @@ func (g *DbResource) DbGetCode(ctx context.Context, sym string) ([]byte, error)
 	return g.fn(ctx, sym)
 }
 
+// Will fail if support for db.DATATYPE_STATICLOAD has been disabled.
+//
+// The static content is stored under the symbol, or under the symbol with a ".txt" suffix.
+func (g *DbResource) DbGetStatic(ctx context.Context, sym string) ([]byte, error) {
+	if g.typs&db.DATATYPE_STATICLOAD == 0 {
+		return nil, errors.New("not a staticload getter")
+	}
+	g.db.SetPrefix(db.DATATYPE_STATICLOAD)
+	b, err := g.tfn(ctx, sym)
+	if err != nil {
+		if !db.IsNotFound(err) {
+			return nil, err
+		}
+		b, err = g.tfn(ctx, sym+".txt")
+		if err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
 // The method will first attempt to resolve using the function registered
 // with the MenuResource parent class.
 //
@@ -135,19 +210,9 @@ func (g *DbResource) DbFuncFor(ctx context.Context, sym string) (EntryFunc, erro
 		return fn, nil
 	}
 	logg.TraceCtxf(ctx, "function handler not registered", "sym", sym)
-	if g.typs&db.DATATYPE_STATICLOAD == 0 {
-		return nil, errors.New("not a staticload getter")
-	}
-	g.db.SetPrefix(db.DATATYPE_STATICLOAD)
-	b, err := g.fn(ctx, sym)
+	b, err := g.DbGetStatic(ctx, sym)
 	if err != nil {
-		if !db.IsNotFound(err) {
-			return nil, err
-		}
-		b, err = g.fn(ctx, sym+".txt")
-		if err != nil {
-			return nil, err
-		}
+		return nil, err
 	}
 	return func(ctx context.Context, nodeSym string, input []byte) (Result, error) {
 		return Result{
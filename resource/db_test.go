@@ -3,10 +3,12 @@ package resource
 import (
 	"bytes"
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/grassrootseconomics/go-vise/db"
 	"github.com/grassrootseconomics/go-vise/db/mem"
+	"github.com/grassrootseconomics/go-vise/lang"
 )
 
 func TestDb(t *testing.T) {
@@ -134,3 +136,82 @@ func TestDbGetterDirect(t *testing.T) {
 		t.Fatalf("expected 'foo', got '%s'", v)
 	}
 }
+
+func TestDbFallback(t *testing.T) {
+	ctx := context.Background()
+	store := mem.NewMemDb()
+	store.Connect(ctx, "")
+	store.SetPrefix(db.DATATYPE_TEMPLATE)
+	store.SetLock(db.DATATYPE_TEMPLATE, false)
+	store.Put(ctx, []byte("foo"), []byte("hello"))
+	store.Put(ctx, []byte("bar"), []byte("bye"))
+	store.Put(ctx, []byte("baz"), []byte("thanks"))
+	store.Put(ctx, []byte("qux"), []byte("ok"))
+	ln, err := lang.LanguageFromCode("swa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.SetLanguage(&ln)
+	store.Put(ctx, []byte("foo"), []byte("habari"))
+	store.Put(ctx, []byte("bar"), []byte("kwaheri"))
+	store.Put(ctx, []byte("qux"), []byte("sawa"))
+	ln, err = lang.LanguageFromCode("luo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.SetLanguage(&ln)
+	store.Put(ctx, []byte("foo"), []byte("misawa"))
+	// translation identical to the default is not missing.
+	store.Put(ctx, []byte("qux"), []byte("ok"))
+	store.SetLanguage(nil)
+	store.SetLock(db.DATATYPE_TEMPLATE, true)
+
+	fb := lang.NewFallbacks()
+	err = fb.Register("luo", "swa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var missing []string
+	fb = fb.WithMissingFunc(func(ctx context.Context, code string, key string) {
+		missing = append(missing, code+":"+key)
+	})
+
+	rs := NewDbResource(store).WithFallbacks(fb)
+	ctx = context.WithValue(ctx, "Language", ln)
+	for _, v := range [][2]string{{"foo", "misawa"}, {"bar", "kwaheri"}, {"baz", "thanks"}, {"qux", "ok"}} {
+		s, err := rs.GetTemplate(ctx, v[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s != v[1] {
+			t.Fatalf("expected '%s', got '%s'", v[1], s)
+		}
+	}
+	expect := "luo:bar,luo:baz,swa:baz"
+	if strings.Join(missing, ",") != expect {
+		t.Fatalf("expected missing %s, got %v", expect, missing)
+	}
+
+	// the untranslated default is not reported missing for the default language.
+	missing = []string{}
+	err = fb.Register("eng", "swa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln, err = lang.LanguageFromCode("eng")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs = rs.WithDefaultLanguage(ln)
+	ctx = context.WithValue(ctx, "Language", ln)
+	s, err := rs.GetTemplate(ctx, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s != "hello" {
+		t.Fatalf("expected 'hello', got '%s'", s)
+	}
+	if len(missing) > 0 {
+		t.Fatalf("expected no missing, got %v", missing)
+	}
+}
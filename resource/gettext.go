@@ -18,6 +18,7 @@ type PoResource struct {
 	*MenuResource
 	path            string
 	defaultLanguage lang.Language
+	fallbacks       *lang.Fallbacks
 	tr              map[string]*gotext.Locale
 }
 
@@ -42,8 +43,21 @@ func (p *PoResource) WithLanguage(ln lang.Language) *PoResource {
 	return p
 }
 
-// Disable vet when running tests: go test -v ./resource/ -vet=off
+// WithFallbacks is a chainable function that sets the fallback chains to follow when no translation exists for the language.
+func (p *PoResource) WithFallbacks(fallbacks *lang.Fallbacks) *PoResource {
+	p.fallbacks = fallbacks
+	return p
+}
+
+// translation of the string in the domain of the locale.
+//
+// The gotext getters take the string as a printf format, which vet rejects for non-constant strings. The string is passed with an explicitly empty argument list, with which gotext never formats it.
 // https://github.com/leonelquinteros/gotext/issues/117
+func translate(o *gotext.Locale, domain string, s string) string {
+	var noArgs []interface{}
+	return o.GetD(domain, s, noArgs...)
+}
+
 func (p *PoResource) get(ctx context.Context, sym string, domain string, menu bool) (string, error) {
 	s := sym
 	ln, ok := lang.LanguageFromContext(ctx)
@@ -56,10 +70,25 @@ func (p *PoResource) get(ctx context.Context, sym string, domain string, menu bo
 		if menu {
 			keyDomain = MenuKeyPoDomain
 		}
-		s = o.GetD(keyDomain, sym)
-		o, ok := p.tr[ln.Code]
-		if ok {
-			s = o.GetD(domain, s)
+		s = translate(o, keyDomain, sym)
+		chain := p.fallbacks.Chain(ln)
+		if len(chain) == 1 {
+			o, ok := p.tr[ln.Code]
+			if ok {
+				s = translate(o, domain, s)
+			}
+			return s, nil
+		}
+		for _, l := range chain {
+			o, ok := p.tr[l.Code]
+			// the plural form of 0 is not the singular form without a Plural-Forms header.
+			if ok && o.IsTranslatedND(domain, s, 1) {
+				return translate(o, domain, s), nil
+			}
+			if l.Code != p.defaultLanguage.Code {
+				logg.DebugCtxf(ctx, "translation missing", "sym", sym, "lang", l.Code)
+				p.fallbacks.ReportMissing(ctx, l.Code, sym)
+			}
 		}
 	}
 	return s, nil
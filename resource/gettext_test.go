@@ -2,6 +2,7 @@ package resource
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/grassrootseconomics/go-vise/lang"
@@ -99,3 +100,43 @@ func TestPoGet(t *testing.T) {
 		t.Fatalf("expected 'baz', got '%s'", s)
 	}
 }
+
+func TestPoFallback(t *testing.T) {
+	ln, err := lang.LanguageFromCode("eng")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lnn, err := lang.LanguageFromCode("nor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fb := lang.NewFallbacks()
+	err = fb.Register("dan", "nor", "eng")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var missing []string
+	fb = fb.WithMissingFunc(func(ctx context.Context, code string, key string) {
+		missing = append(missing, code+":"+key)
+	})
+	rs := NewPoResource(ln, testlocale.LocaleDir).WithLanguage(lnn).WithFallbacks(fb)
+
+	lnd, err := lang.LanguageFromCode("dan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.WithValue(context.Background(), "Language", lnd)
+	for _, v := range [][2]string{{"foo", "fu"}, {"inky", "pinky"}} {
+		s, err := rs.GetMenu(ctx, v[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s != v[1] {
+			t.Fatalf("expected '%s', got '%s'", v[1], s)
+		}
+	}
+	expect := "dan:foo,dan:inky,nor:inky"
+	if strings.Join(missing, ",") != expect {
+		t.Fatalf("expected missing %s, got %v", expect, missing)
+	}
+}
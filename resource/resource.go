@@ -5,10 +5,20 @@ import (
 	"fmt"
 )
 
+// Item is a single entry of a list returned by an external code operation.
+type Item struct {
+	// application defined identifier of the item, passed as input to the next node when the item is selected.
+	Id string
+	// display title of the item.
+	Title string
+}
+
 // Result contains the results of an external code operation.
 type Result struct {
 	// content value for symbol after execution.
 	Content string
+	// list items to render as numbered menu entries, selectable with INLIST. If set, content is generated from the items, and Content is ignored.
+	Items []Item
 	// application defined status code which can complement error returns
 	Status int
 	// request caller to set error flags at given indices.
@@ -39,6 +39,17 @@ func (fd *flagDebugger) Register(flag uint32, name string) error {
 	return nil
 }
 
+// Name returns the registered string value for the flag.
+//
+// If no string value has been registered, a placeholder is returned.
+func (fd *flagDebugger) Name(flag uint32) string {
+	v, ok := fd.flagStrings[flag]
+	if !ok {
+		return unknown_flag_description
+	}
+	return v
+}
+
 func (fd *flagDebugger) AsString(flags []byte, length uint32) string {
 	return strings.Join(fd.AsList(flags, length), ",")
 }
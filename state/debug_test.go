@@ -51,3 +51,22 @@ func TestDebugState(t *testing.T) {
 		t.Fatalf("expected '%s', got '%s'", expect, r)
 	}
 }
+
+func TestDebugFlagName(t *testing.T) {
+	err := FlagDebugger.Register(8, "FOO")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := FlagDebugger.Name(8)
+	if r != "FOO" {
+		t.Fatalf("expected 'FOO', got '%s'", r)
+	}
+	r = FlagDebugger.Name(FLAG_WAIT)
+	if r != "INTERNAL_WAIT" {
+		t.Fatalf("expected 'INTERNAL_WAIT', got '%s'", r)
+	}
+	r = FlagDebugger.Name(42)
+	if r != unknown_flag_description {
+		t.Fatalf("expected '%s', got '%s'", unknown_flag_description, r)
+	}
+}
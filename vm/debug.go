@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"strings"
 )
 
 type ParseHandler struct {
@@ -15,13 +16,16 @@ type ParseHandler struct {
 	Move   func(string) error
 	Halt   func() error
 	InCmp  func(string, string) error
+	InList func(string, string) error
 	MOut   func(string, string) error
 	MSink  func() error
 	MNext  func(string, string) error
 	MPrev  func(string, string) error
 	cur    string
 	n      int
+	at     int
 	w      io.Writer
+	sm     *SourceMap
 }
 
 func NewParseHandler() *ParseHandler {
@@ -32,6 +36,13 @@ func (ph *ParseHandler) Length() int {
 	return ph.n
 }
 
+// Offset returns the bytecode offset of the instruction being parsed.
+//
+// It is valid within the instruction handlers during ParseAll.
+func (ph *ParseHandler) Offset() int {
+	return ph.at
+}
+
 func (ph *ParseHandler) WithDefaultHandlers() *ParseHandler {
 	ph.Catch = ph.catch
 	ph.Croak = ph.croak
@@ -41,6 +52,7 @@ func (ph *ParseHandler) WithDefaultHandlers() *ParseHandler {
 	ph.Move = ph.move
 	ph.Halt = ph.halt
 	ph.InCmp = ph.incmp
+	ph.InList = ph.inlist
 	ph.MOut = ph.mout
 	ph.MSink = ph.msink
 	ph.MNext = ph.mnext
@@ -53,6 +65,28 @@ func (ph *ParseHandler) WithWriter(w io.Writer) *ParseHandler {
 	return ph
 }
 
+// WithSourceMap is a chainable function that annotates every written instruction with the assembly source location it was generated from.
+func (ph *ParseHandler) WithSourceMap(sm *SourceMap) *ParseHandler {
+	ph.sm = sm
+	return ph
+}
+
+// append source location of the instruction at the bytecode offset to the current output.
+func (ph *ParseHandler) annotate(offset int) {
+	if ph.sm == nil || ph.cur == "" {
+		return
+	}
+	loc, ok := ph.sm.Locate(offset)
+	if !ok {
+		return
+	}
+	s := strings.TrimRight(ph.cur, "\n") + "\t# " + loc.String()
+	if loc.Text != "" {
+		s += ": " + loc.Text
+	}
+	ph.cur = s + "\n"
+}
+
 // TODO: output op sym
 func (ph *ParseHandler) flush() error {
 	if ph.w != nil {
@@ -118,6 +152,12 @@ func (ph *ParseHandler) incmp(sym string, sel string) error {
 	return nil
 }
 
+func (ph *ParseHandler) inlist(sym string, sel string) error {
+	s := OpcodeString[INLIST]
+	ph.cur = fmt.Sprintf("%s %s %s\n", s, sym, sel)
+	return nil
+}
+
 func (ph *ParseHandler) halt() error {
 	s := OpcodeString[HALT]
 	ph.cur = fmt.Sprintf("%s\n", s)
@@ -169,8 +209,11 @@ func (ph *ParseHandler) ToString(b []byte) (string, error) {
 // It fails on any parse error encountered before the bytecode EOF is reached.
 func (ph *ParseHandler) ParseAll(b []byte) (int, error) {
 	var s string
+	total := len(b)
 	running := true
 	for running {
+		offset := total - len(b)
+		ph.at = offset
 		op, bb, err := opSplit(b)
 		b = bb
 		if err != nil {
@@ -224,6 +267,12 @@ func (ph *ParseHandler) ParseAll(b []byte) (int, error) {
 			if err == nil {
 				err = ph.InCmp(r, v)
 			}
+		case INLIST:
+			r, v, bb, err := ParseInList(b)
+			b = bb
+			if err == nil {
+				err = ph.InList(r, v)
+			}
 		case HALT:
 			b, err = ParseHalt(b)
 			if err == nil {
@@ -256,6 +305,7 @@ func (ph *ParseHandler) ParseAll(b []byte) (int, error) {
 		if err != nil {
 			return ph.Length(), err
 		}
+		ph.annotate(offset)
 		ph.flush()
 
 		//rs += "\n"
@@ -134,6 +134,16 @@ func TestToString(t *testing.T) {
 	if r != expect {
 		t.Fatalf("expected:\n\t%v\ngot:\n\t%v", expect, r)
 	}
+
+	b = NewLine(nil, INLIST, []string{"foo", "bar"}, nil, nil)
+	r, err = ph.ToString(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect = "INLIST foo bar\n"
+	if r != expect {
+		t.Fatalf("expected:\n\t%v\ngot:\n\t%v", expect, r)
+	}
 }
 
 func TestToStringMultiple(t *testing.T) {
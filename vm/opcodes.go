@@ -19,7 +19,8 @@ const (
 	MOUT   = 10
 	MNEXT  = 11
 	MPREV  = 12
-	_MAX   = 12
+	INLIST = 13
+	_MAX   = 13
 )
 
 var (
@@ -37,6 +38,7 @@ var (
 		MOUT:   "MOUT",
 		MNEXT:  "MNEXT",
 		MPREV:  "MPREV",
+		INLIST: "INLIST",
 	}
 
 	OpcodeIndex = map[string]Opcode{
@@ -53,5 +55,6 @@ var (
 		"MOUT":   MOUT,
 		"MNEXT":  MNEXT,
 		"MPREV":  MPREV,
+		"INLIST": INLIST,
 	}
 )
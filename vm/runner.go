@@ -1,9 +1,12 @@
 package vm
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
+	"text/template"
 
 	"github.com/grassrootseconomics/go-vise/cache"
 	"github.com/grassrootseconomics/go-vise/render"
@@ -40,14 +43,18 @@ func (e ExternalCodeError) Error() string {
 // Vm holds sub-components mutated by the vm execution.
 // TODO: Renderer should be passed to avoid proxy methods not strictly related to vm operation
 type Vm struct {
-	st            *state.State      // Navigation and error states.
-	rs            resource.Resource // Retrieves content, code, and templates for symbols.
-	ca            cache.Memory      // Loaded content.
-	mn            *render.Menu      // Menu component of page.
-	sizer         *render.Sizer     // Apply size constraints to output.
-	pg            *render.Page      // Render outputs with menues to size constraints
-	menuSeparator string            // Passed to Menu.WithSeparator if not empty
-	last          string            // Last failed LOAD/RELOAD attempt
+	st            *state.State                 // Navigation and error states.
+	rs            resource.Resource            // Retrieves content, code, and templates for symbols.
+	ca            cache.Memory                 // Loaded content.
+	mn            *render.Menu                 // Menu component of page.
+	sizer         *render.Sizer                // Apply size constraints to output.
+	pg            *render.Page                 // Render outputs with menues to size constraints
+	menuSeparator string                       // Passed to Menu.WithSeparator if not empty
+	indicator     string                       // Page indicator format passed to the menu browse config
+	indicatorPos  render.PageIndicatorPosition // Page indicator placement passed to the menu browse config
+	last          string                       // Last failed LOAD/RELOAD attempt
+	sourceMaps    SourceMapFunc                // Retrieves source maps for locating execution errors
+	items         map[string][]string          // Item ids of list symbols, if not stored by the cache
 }
 
 // NewVm creates a new Vm.
@@ -79,12 +86,63 @@ func (vmi *Vm) WithMenuSeparator(sep string) *Vm {
 	return vmi
 }
 
+// WithSourceMaps is a chainable function that sets the retrieval of source maps for node bytecode.
+//
+// When set, errors returned by Run are wrapped in a SourceError with the assembly source location of the failing instruction, if it can be determined.
+func (vmi *Vm) WithSourceMaps(fn SourceMapFunc) *Vm {
+	vmi.sourceMaps = fn
+	return vmi
+}
+
+// WithTemplateFuncs is a chainable function that adds functions available to all templates
+// in the page renderer.
+func (vmi *Vm) WithTemplateFuncs(fm template.FuncMap) *Vm {
+	vmi.pg = vmi.pg.WithFuncs(fm)
+	return vmi
+}
+
+// WithRenderer is a chainable function that sets the output format of the page renderer.
+//
+// If not set, pages are rendered as plain text.
+func (vmi *Vm) WithRenderer(rdr render.Renderer) *Vm {
+	vmi.pg = vmi.pg.WithRenderer(rdr)
+	return vmi
+}
+
+// WithTemplateCache is a chainable function that sets the cache of parsed templates used by the page renderer.
+//
+// See render.Page.WithTemplateCache.
+func (vmi *Vm) WithTemplateCache(tc *render.TemplateCache) *Vm {
+	vmi.pg = vmi.pg.WithTemplateCache(tc)
+	return vmi
+}
+
+// WithPageIndicator is a chainable function that sets the format and placement of the page position
+// indicator shown for browsable content.
+//
+// See render.BrowseConfig.
+func (vmi *Vm) WithPageIndicator(format string, pos render.PageIndicatorPosition) *Vm {
+	vmi.indicator = format
+	vmi.indicatorPos = pos
+	vmi.applyIndicator()
+	return vmi
+}
+
+// set page indicator in browse config of current menu.
+func (vmi *Vm) applyIndicator() {
+	cfg := vmi.mn.GetBrowseConfig()
+	cfg.PageIndicator = vmi.indicator
+	cfg.PageIndicatorPosition = vmi.indicatorPos
+	vmi.mn = vmi.mn.WithBrowseConfig(cfg)
+}
+
 // Reset re-initializes sub-components for output rendering.
 func (vmi *Vm) Reset() {
 	vmi.mn = render.NewMenu()
 	if vmi.menuSeparator != "" {
 		vmi.mn = vmi.mn.WithSeparator(vmi.menuSeparator)
 	}
+	vmi.applyIndicator()
 	vmi.pg.Reset()
 	vmi.pg = vmi.pg.WithMenu(vmi.mn)
 	if vmi.sizer != nil {
@@ -144,9 +202,11 @@ func (vm *Vm) Run(ctx context.Context, b []byte) ([]byte, error) {
 		}
 
 		_ = vm.st.SetFlag(state.FLAG_DIRTY)
+		at := b
+		atSym, _ := vm.st.Where()
 		op, bb, err := opSplit(b)
 		if err != nil {
-			return b, err
+			return b, vm.locate(ctx, atSym, at, err)
 		}
 		b = bb
 		logg.DebugCtxf(ctx, "execute code", "opcode", op, "op", OpcodeString[op], "code", b)
@@ -166,6 +226,8 @@ func (vm *Vm) Run(ctx context.Context, b []byte) ([]byte, error) {
 			b, err = vm.runMove(ctx, b)
 		case INCMP:
 			b, err = vm.runInCmp(ctx, b)
+		case INLIST:
+			b, err = vm.runInList(ctx, b)
 		case MSINK:
 			b, err = vm.runMSink(ctx, b)
 		case MOUT:
@@ -182,7 +244,7 @@ func (vm *Vm) Run(ctx context.Context, b []byte) ([]byte, error) {
 		}
 		b, err = vm.runErrCheck(ctx, b, err)
 		if err != nil {
-			return b, err
+			return b, vm.locate(ctx, atSym, at, err)
 		}
 		if len(b) == 0 {
 			b, err = vm.runDeadCheck(ctx, b)
@@ -197,6 +259,35 @@ func (vm *Vm) Run(ctx context.Context, b []byte) ([]byte, error) {
 	return b, nil
 }
 
+// wraps the error with the source location of the instruction at the start of the remaining code.
+//
+// the remaining code is located if it is a suffix of the bytecode of the node.
+func (vm *Vm) locate(ctx context.Context, sym string, b []byte, err error) error {
+	if vm.sourceMaps == nil || sym == "" || len(b) == 0 {
+		return err
+	}
+	code, cerr := vm.rs.GetCode(ctx, sym)
+	if cerr != nil || !bytes.HasSuffix(code, b) {
+		return err
+	}
+	sm, serr := vm.sourceMaps(ctx, sym)
+	if serr != nil {
+		logg.DebugCtxf(ctx, "no source map", "sym", sym, "err", serr)
+		return err
+	}
+	offset := len(code) - len(b)
+	loc, ok := sm.Locate(offset)
+	if !ok {
+		return err
+	}
+	return &SourceError{
+		Sym:      sym,
+		Offset:   offset,
+		Location: loc,
+		Err:      err,
+	}
+}
+
 // handles errors that should not be deferred to the client.
 func (vm *Vm) runErrCheck(ctx context.Context, b []byte, err error) ([]byte, error) {
 	if err == nil {
@@ -403,6 +494,46 @@ func (vm *Vm) runInCmp(ctx context.Context, b []byte) ([]byte, error) {
 		}
 		logg.InfoCtxf(ctx, "input match", "input", input, "next", sym)
 	}
+	return vm.moveInput(ctx, sym, b)
+}
+
+// executes the INLIST opcode
+func (vm *Vm) runInList(ctx context.Context, b []byte) ([]byte, error) {
+	sym, listSym, b, err := ParseInList(b)
+	if err != nil {
+		return b, err
+	}
+
+	// unlike INCMP, a list never matches after any earlier match, so that the first matching instruction in code order takes precedence.
+	have := vm.st.GetFlag(state.FLAG_INMATCH)
+	if have {
+		logg.DebugCtxf(ctx, "ignoring input - already have match", "input", sym)
+		return b, nil
+	}
+	vm.st.SetFlag(state.FLAG_READIN)
+	input, err := vm.st.GetInput()
+	if err != nil {
+		return b, err
+	}
+	ids, err := vm.getItems(listSym)
+	if err != nil {
+		logg.DebugCtxf(ctx, "no items for list", "list", listSym, "err", err)
+		return b, nil
+	}
+	n, err := strconv.Atoi(string(input))
+	if err != nil || n < 1 || n > len(ids) {
+		return b, nil
+	}
+	logg.InfoCtxf(ctx, "input list match", "input", input, "list", listSym, "item", ids[n-1], "next", sym)
+	err = vm.st.SetInput([]byte(ids[n-1]))
+	if err != nil {
+		return b, err
+	}
+	return vm.moveInput(ctx, sym, b)
+}
+
+// complete input match, moving to the target node.
+func (vm *Vm) moveInput(ctx context.Context, sym string, b []byte) ([]byte, error) {
 	vm.st.SetFlag(state.FLAG_INMATCH)
 	vm.st.ResetFlag(state.FLAG_READIN)
 
@@ -516,6 +647,21 @@ func (vm *Vm) Render(ctx context.Context) (string, error) {
 	return r, nil
 }
 
+// RenderSms returns the rendered output of the current node as SMS message segments, with the menu as a reply keyword footer.
+//
+// See render.Page.RenderSms.
+func (vm *Vm) RenderSms(ctx context.Context) ([]string, error) {
+	changed := vm.st.ResetFlag(state.FLAG_DIRTY)
+	if !changed {
+		return nil, nil
+	}
+	sym, _ := vm.st.Where()
+	if sym == "" {
+		return nil, nil
+	}
+	return vm.pg.RenderSms(ctx, sym)
+}
+
 // retrieve and cache data for key
 func (vm *Vm) refresh(key string, rs resource.Resource, ctx context.Context) (string, error) {
 	var err error
@@ -551,5 +697,45 @@ func (vm *Vm) refresh(key string, rs resource.Resource, ctx context.Context) (st
 		vm.st.SetLanguage(r.Content)
 	}
 
+	if len(r.Items) > 0 {
+		err = vm.setItems(key, r.Items)
+		if err != nil {
+			return "", err
+		}
+		return render.Items(r.Items, vm.menuSeparator), nil
+	}
+
 	return r.Content, err
 }
+
+// store the item ids of a list symbol for resolution by INLIST.
+//
+// the ids are stored in the cache if it supports it, so that they are persisted with the cached content of the symbol.
+func (vm *Vm) setItems(sym string, items []resource.Item) error {
+	var ids []string
+	for _, v := range items {
+		ids = append(ids, v.Id)
+	}
+	im, ok := vm.ca.(cache.ItemMemory)
+	if ok {
+		return im.SetItems(sym, ids)
+	}
+	if vm.items == nil {
+		vm.items = make(map[string][]string)
+	}
+	vm.items[sym] = ids
+	return nil
+}
+
+// retrieve the stored item ids of a list symbol.
+func (vm *Vm) getItems(sym string) ([]string, error) {
+	im, ok := vm.ca.(cache.ItemMemory)
+	if ok {
+		return im.GetItems(sym)
+	}
+	ids, ok := vm.items[sym]
+	if !ok {
+		return nil, fmt.Errorf("no items for symbol %s", sym)
+	}
+	return ids, nil
+}
@@ -50,6 +50,7 @@ func newTestResource(st *state.State) testResource {
 	rs.AddLocalFunc("setFlagOne", setFlag)
 	rs.AddLocalFunc("set_lang", set_lang)
 	rs.AddLocalFunc("aiee", uhOh)
+	rs.AddLocalFunc("items", getItems)
 
 	var b []byte
 	b = NewLine(nil, HALT, nil, nil, nil)
@@ -102,6 +103,16 @@ func uhOh(ctx context.Context, sym string, input []byte) (resource.Result, error
 	return resource.Result{}, fmt.Errorf("uh-oh spaghetti'ohs")
 }
 
+func getItems(ctx context.Context, sym string, input []byte) (resource.Result, error) {
+	return resource.Result{
+		Items: []resource.Item{
+			{Id: "0xdead", Title: "inky"},
+			{Id: "0xbeef", Title: "pinky"},
+			{Id: "0xf00d", Title: "blinky"},
+		},
+	}, nil
+}
+
 func setFlag(ctx context.Context, sym string, input []byte) (resource.Result, error) {
 	s := fmt.Sprintf("ping")
 	r := resource.Result{
@@ -148,6 +159,8 @@ func (r testResource) FuncFor(ctx context.Context, sym string) (resource.EntryFu
 		return set_lang, nil
 	case "aiee":
 		return uhOh, nil
+	case "items":
+		return getItems, nil
 	}
 	return nil, fmt.Errorf("invalid function: '%s'", sym)
 }
@@ -874,3 +887,120 @@ func TestErrorOut(t *testing.T) {
 		t.Fatalf("expected error")
 	}
 }
+
+// cache.Memory without the optional cache.ItemMemory methods.
+type plainMemory struct {
+	cache.Memory
+}
+
+func TestRunInList(t *testing.T) {
+	for _, v := range []string{"4", "0xbeef", "2", "2 plain"} {
+		st := state.NewState(5)
+		rs := newTestResource(st)
+		rs.AddTemplate(ctx, "list", "{{.items}}")
+		b := NewLine(nil, LOAD, []string{"echo"}, []byte{0x00}, nil)
+		b = NewLine(b, HALT, nil, nil, nil)
+		rs.AddBytecode(ctx, "pick", b)
+		rs.Lock()
+		var ca cache.Memory = cache.NewCache()
+		v, plain := strings.CutSuffix(v, " plain")
+		if plain {
+			ca = plainMemory{ca}
+		}
+		vm := NewVm(st, &rs, ca, nil)
+
+		st.Down("list")
+		b = NewLine(nil, LOAD, []string{"items"}, []byte{0x00}, nil)
+		b = NewLine(b, MAP, []string{"items"}, nil, nil)
+		b = NewLine(b, HALT, nil, nil, nil)
+		b = NewLine(b, INCMP, []string{"_", "0"}, nil, nil)
+		b = NewLine(b, INLIST, []string{"pick", "items"}, nil, nil)
+
+		st.SetInput([]byte{})
+		b, err := vm.Run(ctx, b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := vm.Render(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect := "1:inky\n2:pinky\n3:blinky"
+		if r != expect {
+			t.Fatalf("expected:\n\t%s\ngot:\n\t%s", expect, r)
+		}
+
+		st.SetInput([]byte(v))
+		_, err = vm.Run(ctx, b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		location, _ := st.Where()
+		if v != "2" {
+			if location != "_catch" {
+				t.Fatalf("expected '_catch' for input %s, got %s", v, location)
+			}
+			continue
+		}
+		if location != "pick" {
+			t.Fatalf("expected 'pick', got %s", location)
+		}
+		r, err = ca.Get("echo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r != "echo: 0xbeef" {
+			t.Fatalf("expected 'echo: 0xbeef', got %s", r)
+		}
+	}
+}
+
+func TestRunInListPrecedence(t *testing.T) {
+	for _, v := range []struct {
+		listFirst bool
+		input     string
+		expect    string
+	}{
+		{false, "2", "foo"},
+		{true, "2", "pick"},
+		{true, "4", "foo"},
+	} {
+		st := state.NewState(5)
+		rs := newTestResource(st)
+		rs.AddTemplate(ctx, "list", "{{.items}}")
+		b := NewLine(nil, HALT, nil, nil, nil)
+		rs.AddBytecode(ctx, "pick", b)
+		rs.AddBytecode(ctx, "foo", b)
+		rs.Lock()
+		ca := cache.NewCache()
+		vm := NewVm(st, &rs, ca, nil)
+
+		st.Down("list")
+		b = NewLine(nil, LOAD, []string{"items"}, []byte{0x00}, nil)
+		b = NewLine(b, MAP, []string{"items"}, nil, nil)
+		b = NewLine(b, HALT, nil, nil, nil)
+		if v.listFirst {
+			b = NewLine(b, INLIST, []string{"pick", "items"}, nil, nil)
+			b = NewLine(b, INCMP, []string{"foo", "2"}, nil, nil)
+			b = NewLine(b, INCMP, []string{"foo", "4"}, nil, nil)
+		} else {
+			b = NewLine(b, INCMP, []string{"foo", "2"}, nil, nil)
+			b = NewLine(b, INLIST, []string{"pick", "items"}, nil, nil)
+		}
+
+		st.SetInput([]byte{})
+		b, err := vm.Run(ctx, b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		st.SetInput([]byte(v.input))
+		_, err = vm.Run(ctx, b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		location, _ := st.Where()
+		if location != v.expect {
+			t.Fatalf("list first %v input %s: expected '%s', got '%s'", v.listFirst, v.input, v.expect, location)
+		}
+	}
+}
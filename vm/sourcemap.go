@@ -0,0 +1,208 @@
+package vm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// SOURCEMAP_EXT is the file extension of a source map emitted next to a bytecode file, e.g. "foo.bin.map".
+	SOURCEMAP_EXT = ".bin.map"
+)
+
+// SourceLocation is the position in assembly code that an instruction was generated from.
+type SourceLocation struct {
+	File   string
+	Line   int
+	Column int
+	// Text of the source line, if the source has been added to the map.
+	Text string
+}
+
+// String implements the String interface.
+func (sl SourceLocation) String() string {
+	return fmt.Sprintf("%s:%d:%d", sl.File, sl.Line, sl.Column)
+}
+
+type sourceEntry struct {
+	offset int
+	line   int
+	column int
+}
+
+// SourceMap maps the byte offsets of instructions in the bytecode of a node to the lines of assembly code they were generated from.
+type SourceMap struct {
+	File    string
+	entries []sourceEntry
+	lines   []string
+}
+
+// NewSourceMap creates a new, empty SourceMap for bytecode generated from the given file.
+func NewSourceMap(file string) *SourceMap {
+	return &SourceMap{
+		File: file,
+	}
+}
+
+// WithSource is a chainable function that adds the assembly source, so that locations include the text of the source line.
+func (sm *SourceMap) WithSource(src string) *SourceMap {
+	sm.lines = strings.Split(src, "\n")
+	return sm
+}
+
+// Add records the source position of the instruction at the bytecode offset.
+//
+// Offsets must be added in ascending order.
+func (sm *SourceMap) Add(offset int, line int, column int) {
+	l := len(sm.entries)
+	if l > 0 && sm.entries[l-1].offset == offset {
+		sm.entries[l-1] = sourceEntry{offset, line, column}
+		return
+	}
+	sm.entries = append(sm.entries, sourceEntry{offset, line, column})
+}
+
+// MapLines is a chainable function that translates all recorded source lines with the given function, e.g. when the assembly code was generated from the source by a preprocessor.
+func (sm *SourceMap) MapLines(fn func(int) int) *SourceMap {
+	for i, v := range sm.entries {
+		sm.entries[i].line = fn(v.line)
+	}
+	return sm
+}
+
+// MapOffsets is a chainable function that translates all recorded bytecode offsets with the given function, e.g. when instructions have been removed from the bytecode by an optimizer.
+//
+// Entries for which the function returns false are removed. The translated offsets must remain in ascending order.
+func (sm *SourceMap) MapOffsets(fn func(int) (int, bool)) *SourceMap {
+	var r []sourceEntry
+	for _, v := range sm.entries {
+		offset, ok := fn(v.offset)
+		if ok {
+			v.offset = offset
+			r = append(r, v)
+		}
+	}
+	sm.entries = r
+	return sm
+}
+
+// Len returns the number of instructions in the map.
+func (sm *SourceMap) Len() int {
+	return len(sm.entries)
+}
+
+// Locate returns the source location of the instruction containing the bytecode offset.
+//
+// Fails if the offset is before the first recorded instruction.
+func (sm *SourceMap) Locate(offset int) (SourceLocation, bool) {
+	i := sort.Search(len(sm.entries), func(i int) bool {
+		return sm.entries[i].offset > offset
+	})
+	if i == 0 {
+		return SourceLocation{}, false
+	}
+	v := sm.entries[i-1]
+	loc := SourceLocation{
+		File:   sm.File,
+		Line:   v.line,
+		Column: v.column,
+	}
+	if v.line > 0 && v.line <= len(sm.lines) {
+		loc.Text = strings.TrimSpace(sm.lines[v.line-1])
+	}
+	return loc, true
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+//
+// The first line is the source file name, followed by one line per instruction with the bytecode offset, source line and column separated by spaces.
+func (sm *SourceMap) MarshalText() ([]byte, error) {
+	b := bytes.NewBuffer(nil)
+	b.WriteString(sm.File + "\n")
+	for _, v := range sm.entries {
+		fmt.Fprintf(b, "%d %d %d\n", v.offset, v.line, v.column)
+	}
+	return b.Bytes(), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (sm *SourceMap) UnmarshalText(b []byte) error {
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	if !sc.Scan() {
+		return fmt.Errorf("source map missing file name")
+	}
+	sm.File = sc.Text()
+	sm.entries = []sourceEntry{}
+	for i := 2; sc.Scan(); i++ {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 3 {
+			return fmt.Errorf("source map line %d: expected 3 fields, got %d", i, len(fields))
+		}
+		var v [3]int
+		for j, s := range fields {
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				return fmt.Errorf("source map line %d: %v", i, err)
+			}
+			v[j] = n
+		}
+		sm.Add(v[0], v[1], v[2])
+	}
+	return sc.Err()
+}
+
+// SourceMapFunc retrieves the source map for the bytecode of a node.
+type SourceMapFunc func(ctx context.Context, sym string) (*SourceMap, error)
+
+// NewDirSourceMapFunc returns a SourceMapFunc that reads source maps emitted next to the bytecode files in the directory.
+//
+// If the source file named in the map can be read, relative to the directory, source line text is included in the locations.
+func NewDirSourceMapFunc(dir string) SourceMapFunc {
+	return func(ctx context.Context, sym string) (*SourceMap, error) {
+		b, err := os.ReadFile(path.Join(dir, sym+SOURCEMAP_EXT))
+		if err != nil {
+			return nil, err
+		}
+		sm := &SourceMap{}
+		err = sm.UnmarshalText(b)
+		if err != nil {
+			return nil, err
+		}
+		src, err := os.ReadFile(path.Join(dir, path.Base(sm.File)))
+		if err == nil {
+			sm = sm.WithSource(string(src))
+		}
+		return sm, nil
+	}
+}
+
+// SourceError is an error during execution of bytecode, with the assembly source location of the failing instruction.
+type SourceError struct {
+	Sym      string
+	Offset   int
+	Location SourceLocation
+	Err      error
+}
+
+// Error implements the error interface.
+func (e *SourceError) Error() string {
+	if e.Location.Text != "" {
+		return fmt.Sprintf("%s: %v (%s)", e.Location, e.Err, e.Location.Text)
+	}
+	return fmt.Sprintf("%s: %v", e.Location, e.Err)
+}
+
+// Unwrap returns the execution error.
+func (e *SourceError) Unwrap() error {
+	return e.Err
+}
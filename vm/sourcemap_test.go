@@ -0,0 +1,93 @@
+package vm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/grassrootseconomics/go-vise/cache"
+	"github.com/grassrootseconomics/go-vise/state"
+)
+
+func TestSourceMap(t *testing.T) {
+	sm := NewSourceMap("foo.vis")
+	sm.Add(0, 1, 1)
+	sm.Add(8, 3, 1)
+	sm.Add(14, 4, 5)
+	b, err := sm.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	smr := &SourceMap{}
+	err = smr.UnmarshalText(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	smr = smr.WithSource("LOAD foo 0\n\nMAP foo\nDOWN bar 1 bar_menu\n")
+	if smr.Len() != 3 {
+		t.Fatalf("expected 3 entries, got %d", smr.Len())
+	}
+	loc, ok := smr.Locate(10)
+	if !ok {
+		t.Fatal("expected location")
+	}
+	if loc.String() != "foo.vis:3:1" || loc.Text != "MAP foo" {
+		t.Fatalf("unexpected location: %s %s", loc, loc.Text)
+	}
+	loc, ok = smr.Locate(100)
+	if !ok || loc.Line != 4 || loc.Column != 5 {
+		t.Fatalf("unexpected location: %s", loc)
+	}
+
+	err = smr.UnmarshalText([]byte("foo.vis\n0 1\n"))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestRunSourceError(t *testing.T) {
+	st := state.NewState(5)
+	rs := newTestResource(st)
+	b := NewLine(nil, LOAD, []string{"one"}, []byte{0x00}, nil)
+	offset := len(b)
+	b = NewLine(b, MOVE, []string{"nonexistent"}, nil, nil)
+	rs.AddBytecode(ctx, "foo", b)
+	rs.Lock()
+	ca := cache.NewCache()
+	sm := NewSourceMap("foo.vis").WithSource("LOAD one 0\nMOVE nonexistent\n")
+	sm.Add(0, 1, 1)
+	sm.Add(offset, 2, 1)
+	vm := NewVm(st, &rs, ca, nil).WithSourceMaps(func(ctx context.Context, sym string) (*SourceMap, error) {
+		if sym != "foo" {
+			return nil, errors.New("no map")
+		}
+		return sm, nil
+	})
+
+	st.Down("foo")
+	_, err := vm.Run(ctx, b)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	var serr *SourceError
+	if !errors.As(err, &serr) {
+		t.Fatalf("expected source error, got %v", err)
+	}
+	if serr.Offset != offset || serr.Location.Line != 2 {
+		t.Fatalf("unexpected location: %v", serr)
+	}
+	if !strings.HasPrefix(err.Error(), "foo.vis:2:1: ") || !strings.HasSuffix(err.Error(), "(MOVE nonexistent)") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ph := NewParseHandler().WithDefaultHandlers().WithSourceMap(sm)
+	r, err := ph.ToString(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := "LOAD one 0\t# foo.vis:1:1: LOAD one 0\nMOVE nonexistent\t# foo.vis:2:1: MOVE nonexistent\n"
+	if r != expect {
+		t.Fatalf("expected:\n\t%s\ngot:\n\t%s", expect, r)
+	}
+}
@@ -75,6 +75,11 @@ func ParseInCmp(b []byte) (string, string, []byte, error) {
 	return parseTwoSym(b)
 }
 
+// ParseInList parses and extracts the expected argument portion of a INLIST instruction
+func ParseInList(b []byte) (string, string, []byte, error) {
+	return parseTwoSym(b)
+}
+
 // ParseMPrev parses and extracts the expected argument portion of a MPREV instruction
 func ParseMPrev(b []byte) (string, string, []byte, error) {
 	return parseTwoSym(b)